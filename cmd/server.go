@@ -2,13 +2,20 @@ package cmd
 
 import (
 	"gqlexample/graph"
+	"gqlexample/graph/loaders"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gqlexample/pkg/config"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/export"
 	"gqlexample/pkg/middware"
 
+	"github.com/99designs/gqlgen/graphql/executor"
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/handler/extension"
 	"github.com/99designs/gqlgen/graphql/handler/lru"
@@ -21,23 +28,64 @@ import (
 var cfg = config.GetConfig()
 
 func Run() {
-	schema := graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver()})
+	resolver := graph.NewResolver(cfg)
+	defer resolver.Close()
+	schema := graph.NewExecutableSchema(graph.Config{Resolvers: resolver})
 	srv := handler.New(schema)
 
+	srv.AddTransport(transport.SSE{})
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+	})
 	srv.AddTransport(transport.Options{})
 	srv.AddTransport(transport.GET{})
 	srv.AddTransport(transport.POST{})
 	srv.AroundOperations(middware.GqlLogger)
+	// 重复请求直接返回首次响应，不再记录审计日志
+	srv.AroundOperations(resolver.Idempotency.AroundOperations)
+	srv.AroundOperations(resolver.Audit.AroundOperations)
+	srv.AroundOperations(resolver.ActiveUserGuard)
+	srv.AroundResponses(loaders.AroundResponses(resolver.NewLoaders))
+	srv.SetErrorPresenter(errcode.Presenter)
 
-	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	queryCache := lru.New[*ast.QueryDocument](1000)
+	apqCache := lru.New[string](100)
+	srv.SetQueryCache(queryCache)
 
 	srv.Use(extension.Introspection{})
 	srv.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New[string](100),
+		Cache: apqCache,
+	})
+
+	// 导出接口与 /query 共用持久化查询缓存，仅执行查询操作
+	exporter := executor.New(schema)
+	exporter.AroundOperations(middware.GqlLogger)
+	exporter.AroundOperations(resolver.ExportGuard)
+	exporter.AroundResponses(loaders.AroundResponses(resolver.NewLoaders))
+	exporter.SetErrorPresenter(errcode.Presenter)
+	exporter.SetQueryCache(queryCache)
+	exporter.Use(extension.AutomaticPersistedQuery{
+		Cache: apqCache,
 	})
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", srv)
+	http.Handle("/query", middware.Auth(middware.IdempotencyKey(srv)))
+	http.Handle("/export", middware.Auth(export.Handler(exporter)))
+	http.Handle("/audit/export/", middware.Auth(resolver.AuditExportHandler()))
+	http.Handle("/candles/export/", middware.Auth(resolver.CandleExportHandler()))
+
+	// 收到 SIGHUP 时重新加载交易时段配置
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := resolver.ReloadTradingCalendar(); err != nil {
+				zap.L().Error("Failed to reload trading calendar", zap.Error(err))
+				continue
+			}
+			zap.L().Info("Trading calendar reloaded")
+		}
+	}()
 
 	socketPath := cfg.SocketPath
 
@@ -0,0 +1,338 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/graph/scalar"
+	"gqlexample/graph/store"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/ledger"
+	"gqlexample/pkg/matching"
+	"gqlexample/pkg/utils"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const CodeInsufficientFunds = "INSUFFICIENT_FUNDS"
+
+// 账簿中的内部账户
+const (
+	externalBook = "external/bank"
+	clearingBook = "clearing"
+	feeBook      = "house/fees"
+)
+
+var (
+	errInvalidAmount    = errors.New("amount must be positive")
+	errAccountAccess    = errors.New("account belongs to another user")
+	errCurrencyMismatch = errors.New("instrument currency differs from account currency")
+)
+
+func cashBook(accountID string) string {
+	return "accounts/" + accountID + "/cash"
+}
+
+func reservedBook(accountID string) string {
+	return "accounts/" + accountID + "/reserved"
+}
+
+// hold 买单占用的资金，unit 为每单位数量占用的金额，amount 为尚未释放的金额
+type hold struct {
+	accountID string
+	currency  string
+	orderCode string
+	unit      decimal.Decimal
+	amount    decimal.Decimal
+}
+
+// holdBook 按订单 ID 记录占用的资金或委托金额
+type holdBook[T any] struct {
+	mu      sync.Mutex
+	byOrder map[string]*T
+}
+
+func newHoldBook[T any]() *holdBook[T] {
+	return &holdBook[T]{byOrder: make(map[string]*T)}
+}
+
+func (h *holdBook[T]) put(orderID string, hd *T) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.byOrder[orderID] = hd
+}
+
+func (h *holdBook[T]) get(orderID string) *T {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.byOrder[orderID]
+}
+
+func (h *holdBook[T]) take(orderID string) *T {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hd := h.byOrder[orderID]
+	delete(h.byOrder, orderID)
+	return hd
+}
+
+// accessAccount 返回账户，仅账户所有者或管理员可访问
+func (r *Resolver) accessAccount(ctx context.Context, id string) (*model.Account, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account, ok := r.accounts.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrAccountNotFound, id)
+	}
+	if account.OwnerID != userID && r.requireAdmin(ctx) != nil {
+		return nil, errcode.New(CodeForbidden, fmt.Errorf("%w: %s", errAccountAccess, id))
+	}
+	return account, nil
+}
+
+// post 记账，余额不足时返回带错误码的错误
+func (r *Resolver) post(kind ledger.Kind, currency, reference string, postings []ledger.Posting, guards ...ledger.Guard) error {
+	var nonZero []ledger.Posting
+	for _, p := range postings {
+		if !p.Amount.IsZero() {
+			nonZero = append(nonZero, p)
+		}
+	}
+	if len(nonZero) == 0 {
+		return nil
+	}
+	_, err := r.ledger.Post(kind, currency, reference, r.now(), nonZero, guards...)
+	if errors.Is(err, ledger.ErrInsufficientFunds) {
+		return errcode.New(CodeInsufficientFunds, err)
+	}
+	return err
+}
+
+// transfer 在可用资金和占用资金间划转，amount 为正时占用，要求可用资金不为负
+func (r *Resolver) transfer(kind ledger.Kind, accountID, currency, reference string, amount decimal.Decimal) error {
+	var guards []ledger.Guard
+	if amount.IsPositive() {
+		guards = append(guards, ledger.Guard{Account: cashBook(accountID)})
+	}
+	return r.post(kind, currency, reference, []ledger.Posting{
+		{Account: cashBook(accountID), Amount: amount.Neg()},
+		{Account: reservedBook(accountID), Amount: amount},
+	}, guards...)
+}
+
+// reserveBuyingPower 为买单按委托金额及预估手续费占用资金，卖单不占用，未开户账户不能下单
+func (r *Resolver) reserveBuyingPower(input model.NewOrder, inst *model.Instrument, notional decimal.Decimal) (*hold, error) {
+	account, ok := r.accounts.Get(input.AccountID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrAccountNotFound, input.AccountID)
+	}
+	if inst.Currency != account.Currency {
+		return nil, fmt.Errorf("%w: %s is %s, account %s is %s", errCurrencyMismatch, inst.ID, inst.Currency, account.ID, account.Currency)
+	}
+	if input.Side != model.OrderSideBuy || !notional.IsPositive() {
+		return nil, nil
+	}
+
+	amount := notional.Mul(decimal.NewFromInt(1).Add(r.feeRate))
+	h := &hold{
+		accountID: account.ID,
+		currency:  account.Currency,
+		orderCode: newOrderCode(),
+		unit:      amount.Div(decimal.NewFromInt32(input.Quantity)),
+		amount:    amount,
+	}
+	if err := r.transfer(ledger.Reserve, h.accountID, h.currency, h.orderCode, amount); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// cancelHold 订单未创建时退回占用的资金
+func (r *Resolver) cancelHold(h *hold) {
+	if h == nil {
+		return
+	}
+	if err := r.transfer(ledger.Release, h.accountID, h.currency, h.orderCode, h.amount.Neg()); err != nil {
+		zap.L().Error("Failed to release buying power", zap.String("order", h.orderCode), zap.Error(err))
+	}
+}
+
+// adjustHold 改单后按剩余数量和新价格调整占用的资金，需在订单簿锁内调用
+func (r *Resolver) adjustHold(o *model.Order) error {
+	h := r.holds.get(o.Id)
+	if h == nil {
+		return nil
+	}
+	unit := o.Price.Amount.Mul(decimal.NewFromInt(1).Add(r.feeRate))
+	required := unit.Mul(decimal.NewFromInt32(o.Quantity - o.FilledQuantity))
+	if err := r.transfer(ledger.Reserve, h.accountID, h.currency, o.OrderId, required.Sub(h.amount)); err != nil {
+		return err
+	}
+	h.unit, h.amount = unit, required
+	return nil
+}
+
+// releaseHold 订单结束后释放剩余占用的资金及未成交部分的当日委托金额，需在订单簿锁内调用
+func (r *Resolver) releaseHold(o *model.Order) {
+	if o.IsOpen() || o.Status == model.OrderStatusPendingApproval {
+		return
+	}
+	r.releaseNotional(o)
+	if h := r.holds.take(o.Id); h != nil && h.amount.IsPositive() {
+		if err := r.transfer(ledger.Release, h.accountID, h.currency, o.OrderId, h.amount.Neg()); err != nil {
+			zap.L().Error("Failed to release buying power", zap.String("order", o.Id), zap.Error(err))
+		}
+	}
+}
+
+// makerOrders 查询 side 方向数量为 quantity 的订单以市价撮合时的对手方订单，包含同数量限价单可能成交的全部对手方
+// 对手方的账户、方向及订单编号不会修改，需在订单簿锁内、订单存储锁外调用
+func (r *Resolver) makerOrders(b *matching.Book, side model.OrderSide, quantity int32) map[string]*model.Order {
+	mo := matchingOrder(&model.Order{Side: side, Type: model.OrderTypeMarket, Quantity: quantity})
+	makers := make(map[string]*model.Order)
+	for _, f := range b.Preview(mo) {
+		if o, ok := r.orders.Get(f.MakerID); ok {
+			makers[o.Id] = o
+		}
+	}
+	return makers
+}
+
+// settleFills 撮合前按预演的成交结算双方资金，需在订单簿锁内调用，th 为主动方占用的资金，makers 由 makerOrders 查询
+// 任一笔记账失败时冲正本次已记账的交易并返回错误，成功时返回冲正函数，供后续步骤失败时在订单簿锁内调用
+func (r *Resolver) settleFills(taker *model.Order, th *hold, fills []matching.Fill, makers map[string]*model.Order) (func(), error) {
+	var undos []func()
+	undo := func() {
+		for i := len(undos) - 1; i >= 0; i-- {
+			undos[i]()
+		}
+	}
+	for _, f := range fills {
+		maker, ok := makers[f.MakerID]
+		if !ok {
+			undo()
+			return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, f.MakerID)
+		}
+		for _, side := range []struct {
+			order *model.Order
+			hold  *hold
+		}{{maker, r.holds.get(maker.Id)}, {taker, th}} {
+			u, err := r.settleFill(side.order, side.hold, f)
+			if err != nil {
+				undo()
+				return nil, err
+			}
+			undos = append(undos, u)
+		}
+	}
+	return undo, nil
+}
+
+// settleFill 按成交为已开户账户结算资金及手续费，买方优先使用占用的资金 h，返回冲正函数
+func (r *Resolver) settleFill(o *model.Order, h *hold, f matching.Fill) (func(), error) {
+	account, ok := r.accounts.Get(o.AccountId)
+	if !ok {
+		return func() {}, nil
+	}
+	notional := f.Price.Mul(decimal.NewFromInt32(f.Quantity))
+	postings := []ledger.Posting{
+		{Account: cashBook(account.ID), Amount: notional},
+		{Account: clearingBook, Amount: notional.Neg()},
+	}
+	// 买方成交及手续费不得使可用资金为负
+	var guards []ledger.Guard
+	released := decimal.Zero
+	if o.Side == model.OrderSideBuy {
+		guards = append(guards, ledger.Guard{Account: cashBook(account.ID)})
+		if h != nil {
+			released = decimal.Min(h.amount, h.unit.Mul(decimal.NewFromInt32(f.Quantity)))
+		}
+		postings = []ledger.Posting{
+			{Account: reservedBook(account.ID), Amount: released.Neg()},
+			{Account: cashBook(account.ID), Amount: released.Sub(notional)},
+			{Account: clearingBook, Amount: notional},
+		}
+	}
+	if err := r.post(ledger.Trade, account.Currency, o.OrderId, postings, guards...); err != nil {
+		return nil, err
+	}
+
+	fee := scalar.PrecisionFor(o.InstrumentId).Round(notional.Mul(r.feeRate))
+	feePostings := []ledger.Posting{
+		{Account: cashBook(account.ID), Amount: fee.Neg()},
+		{Account: feeBook, Amount: fee},
+	}
+	if err := r.post(ledger.Fee, account.Currency, o.OrderId, feePostings, guards...); err != nil {
+		r.reverse(ledger.Trade, account.Currency, o.OrderId, postings)
+		return nil, err
+	}
+
+	if h != nil {
+		h.amount = h.amount.Sub(released)
+	}
+	return func() {
+		r.reverse(ledger.Fee, account.Currency, o.OrderId, feePostings)
+		r.reverse(ledger.Trade, account.Currency, o.OrderId, postings)
+		if h != nil {
+			h.amount = h.amount.Add(released)
+		}
+	}, nil
+}
+
+// reverse 按相反金额冲正已记账的交易，账簿只追加不修改
+func (r *Resolver) reverse(kind ledger.Kind, currency, reference string, postings []ledger.Posting) {
+	reversed := make([]ledger.Posting, len(postings))
+	for i, p := range postings {
+		reversed[i] = ledger.Posting{Account: p.Account, Amount: p.Amount.Neg()}
+	}
+	if err := r.post(kind, currency, reference, reversed); err != nil {
+		zap.L().Error("Failed to reverse ledger postings", zap.String("reference", reference), zap.Error(err))
+	}
+}
+
+// accountBalance 由账簿汇总账户余额
+func (r *Resolver) accountBalance(a *model.Account) *model.AccountBalance {
+	available := r.ledger.Balance(cashBook(a.ID), a.Currency)
+	reserved := r.ledger.Balance(reservedBook(a.ID), a.Currency)
+	return &model.AccountBalance{
+		Available: available,
+		Reserved:  reserved,
+		Total:     available.Add(reserved),
+	}
+}
+
+func ledgerEntry(e ledger.Entry) *model.LedgerEntry {
+	book := model.LedgerBookCash
+	if strings.HasSuffix(e.Account, "/reserved") {
+		book = model.LedgerBookReserved
+	}
+	return &model.LedgerEntry{
+		ID:            e.ID,
+		TransactionID: e.TxID,
+		Kind:          model.LedgerEntryKind(e.Kind),
+		Book:          book,
+		Amount:        e.Amount,
+		Currency:      e.Currency,
+		Reference:     e.Reference,
+		CreatedAt:     e.At,
+	}
+}
+
+// checkAmount 校验出入金金额为正数
+func checkAmount(amount decimal.Decimal) error {
+	if !amount.IsPositive() {
+		return fmt.Errorf("%w: %s", errInvalidAmount, amount)
+	}
+	return nil
+}
+
+// newOrderCode 生成订单编号，资金占用及结算分录以订单编号为引用
+func newOrderCode() string {
+	return utils.GenerateCode("ORD")
+}
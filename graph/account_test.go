@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccount_BuyingPowerAndSettlement(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	admin := middware.WithUserID(context.Background(), "admin")
+
+	owner, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "trader", Name: "Trader"})
+	require.NoError(t, err)
+	ctx := middware.WithUserID(context.Background(), owner.ID)
+	for _, id := range []string{"CASH1", "CASH2"} {
+		_, err = r.Mutation().CreateAccount(ctx, model.NewAccount{ID: id, OwnerID: owner.ID, Currency: "cny"})
+		require.NoError(t, err)
+	}
+	_, err = r.Mutation().CreateAccount(middware.WithUserID(context.Background(), "U9"), model.NewAccount{ID: "X", OwnerID: owner.ID, Currency: "CNY"})
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	// 入金需管理员权限
+	_, err = r.Mutation().Deposit(ctx, "CASH1", decimal.NewFromInt(10000), nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().Deposit(admin, "CASH1", decimal.NewFromInt(10000), nil)
+	require.NoError(t, err)
+	balance := func(id string) *model.AccountBalance {
+		account, err := r.Query().Account(ctx, id)
+		require.NoError(t, err)
+		b, err := r.Account().Balance(ctx, account)
+		require.NoError(t, err)
+		return b
+	}
+
+	// 买单按委托金额及手续费占用资金，资金不足时拒绝
+	buy, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "CASH1"))
+	require.NoError(t, err)
+	assert.Equal(t, "8999.7", balance("CASH1").Available.String())
+	assert.Equal(t, "1000.3", balance("CASH1").Reserved.String())
+	now = now.Add(time.Minute)
+	tooLarge := newOrderInput("600000.SH", "CASH1")
+	tooLarge.Quantity = 1000
+	_, err = r.Mutation().PlaceOrder(ctx, *tooLarge)
+	assert.Equal(t, CodeInsufficientFunds, errcode.Code(err))
+
+	// 成交后结算并收取手续费，卖方未占用资金
+	sell := newOrderInput("600000.SH", "CASH2")
+	sell.Side = model.OrderSideSell
+	_, err = r.Mutation().PlaceOrder(ctx, *sell)
+	require.NoError(t, err)
+	assert.Equal(t, "8999.7", balance("CASH1").Available.String())
+	assert.True(t, balance("CASH1").Reserved.IsZero())
+	assert.Equal(t, "999.7", balance("CASH2").Total.String())
+
+	// 撤单释放占用的资金
+	now = now.Add(time.Minute)
+	rest := newOrderInput("600000.SH", "CASH1")
+	price := decimal.RequireFromString("9.90")
+	rest.Price = &price
+	order, err := r.Mutation().PlaceOrder(ctx, *rest)
+	require.NoError(t, err)
+	assert.False(t, balance("CASH1").Reserved.IsZero())
+	_, err = r.Mutation().CancelOrder(ctx, order.Id, order.Version)
+	require.NoError(t, err)
+	assert.True(t, balance("CASH1").Reserved.IsZero())
+	assert.Equal(t, "8999.7", balance("CASH1").Available.String())
+
+	_, err = r.Mutation().Withdraw(ctx, "CASH2", decimal.NewFromInt(1000), nil)
+	assert.Equal(t, CodeInsufficientFunds, errcode.Code(err))
+	_, err = r.Mutation().Withdraw(middware.WithUserID(context.Background(), "U9"), "CASH2", decimal.NewFromInt(1), nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().Withdraw(ctx, "CASH2", decimal.RequireFromString("999.7"), nil)
+	require.NoError(t, err)
+
+	entries, err := r.Query().LedgerEntries(ctx, "CASH1")
+	require.NoError(t, err)
+	kinds := make([]model.LedgerEntryKind, 0, len(entries))
+	for _, e := range entries {
+		kinds = append(kinds, e.Kind)
+	}
+	assert.Contains(t, kinds, model.LedgerEntryKindFee)
+	assert.Equal(t, buy.OrderId, entries[1].Reference)
+	assert.True(t, r.ledger.Total("CNY").IsZero())
+
+	accounts, err := r.Query().Accounts(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, accounts, 2)
+	assert.Equal(t, "CNY", accounts[0].Currency)
+}
+
+func TestAccount_SettlementRejectsUnfundedFill(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	admin := middware.WithUserID(context.Background(), "admin")
+	seller := newTestAccount(t, r, "F2")
+
+	owner, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "buyer", Name: "Buyer"})
+	require.NoError(t, err)
+	ctx := middware.WithUserID(context.Background(), owner.ID)
+	_, err = r.Mutation().CreateAccount(ctx, model.NewAccount{ID: "F1", OwnerID: owner.ID, Currency: "CNY"})
+	require.NoError(t, err)
+	_, err = r.Mutation().Deposit(admin, "F1", decimal.NewFromInt(1000), nil)
+	require.NoError(t, err)
+	balance := func(ctx context.Context, id string) *model.AccountBalance {
+		account, err := r.Query().Account(ctx, id)
+		require.NoError(t, err)
+		b, err := r.Account().Balance(ctx, account)
+		require.NoError(t, err)
+		return b
+	}
+
+	// 空订单簿上的市价单无法估算金额，不占用资金并等待审批
+	market := newOrderInput("600000.SH", "F1")
+	orderType := model.OrderTypeMarket
+	market.Type = &orderType
+	market.Price = nil
+	pending, err := r.Mutation().PlaceOrder(ctx, *market)
+	require.NoError(t, err)
+	require.Equal(t, model.OrderStatusPendingApproval, pending.Status)
+	assert.True(t, balance(ctx, "F1").Reserved.IsZero())
+
+	sell := newOrderInput("600000.SH", "F2")
+	sell.Side = model.OrderSideSell
+	price := decimal.NewFromInt(20)
+	sell.Price = &price
+	_, err = r.Mutation().PlaceOrder(seller, *sell)
+	require.NoError(t, err)
+
+	// 审批时可用资金不足以结算成交，不成交也不修改订单、持仓及账簿
+	_, err = r.Mutation().ApproveOrder(admin, pending.Id)
+	assert.Equal(t, CodeInsufficientFunds, errcode.Code(err))
+	order, ok := r.orders.Get(pending.Id)
+	require.True(t, ok)
+	assert.Equal(t, model.OrderStatusPendingApproval, order.Status)
+	assert.Zero(t, order.FilledQuantity)
+	assert.Empty(t, r.positions.List("F1"))
+	assert.Equal(t, "1000", balance(ctx, "F1").Available.String())
+	assert.Equal(t, "1000000", balance(seller, "F2").Available.String())
+	book, err := r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	require.Len(t, book.Asks, 1)
+	assert.Equal(t, int32(100), book.Asks[0].Quantity)
+
+	// 补足资金后审批通过并成交
+	_, err = r.Mutation().Deposit(admin, "F1", decimal.NewFromInt(1001), nil)
+	require.NoError(t, err)
+	approved, err := r.Mutation().ApproveOrder(admin, pending.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusFilled, approved.Status)
+	assert.Equal(t, "0.4", balance(ctx, "F1").Available.String())
+}
@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+	"slices"
+)
+
+const (
+	CodeForbidden       = "FORBIDDEN"
+	CodeUnauthenticated = "UNAUTHENTICATED"
+)
+
+var (
+	errForbidden       = errors.New("admin permission required")
+	errUnauthenticated = errors.New("user not authenticated")
+	errNotApprover     = errors.New("approver role required")
+	errNotAuditor      = errors.New("auditor role required")
+)
+
+// requireUser 返回当前用户，未登录时返回错误
+func requireUser(ctx context.Context) (string, error) {
+	userID := middware.UserIDFromContext(ctx)
+	if userID == "" {
+		return "", errcode.New(CodeUnauthenticated, errUnauthenticated)
+	}
+	return userID, nil
+}
+
+// requireAdmin 校验当前用户在配置的管理员列表中
+func (r *Resolver) requireAdmin(ctx context.Context) error {
+	userID := middware.UserIDFromContext(ctx)
+	if userID == "" || !slices.Contains(r.cfg.Admin.Users, userID) {
+		return errcode.New(CodeForbidden, fmt.Errorf("%w: user %q", errForbidden, userID))
+	}
+	return nil
+}
+
+// requireApprover 校验当前用户在配置的审批人列表中，返回当前用户
+func (r *Resolver) requireApprover(ctx context.Context) (string, error) {
+	userID := middware.UserIDFromContext(ctx)
+	if userID == "" || !slices.Contains(r.cfg.Approval.Approvers, userID) {
+		return "", errcode.New(CodeForbidden, fmt.Errorf("%w: user %q", errNotApprover, userID))
+	}
+	return userID, nil
+}
+
+// requireAuditor 校验当前用户为管理员或在配置的审计人员列表中
+func (r *Resolver) requireAuditor(ctx context.Context) error {
+	userID := middware.UserIDFromContext(ctx)
+	if userID == "" || !(slices.Contains(r.cfg.Admin.Users, userID) || slices.Contains(r.cfg.Audit.Auditors, userID)) {
+		return errcode.New(CodeForbidden, fmt.Errorf("%w: user %q", errNotAuditor, userID))
+	}
+	return nil
+}
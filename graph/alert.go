@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"errors"
+	"gqlexample/graph/model"
+	"gqlexample/graph/subscriptions"
+	"gqlexample/pkg/alert"
+)
+
+var errInvalidAlertPrice = errors.New("alert price must be positive")
+
+// publishQuote 发布订单簿行情并按最新成交价检查价格提醒，触发的提醒推送到所属用户
+func (r *Resolver) publishQuote(q *model.Quote) {
+	r.QuoteHub.Publish(quoteKey(q.InstrumentID, q.Source), q)
+	if q.Last == nil {
+		return
+	}
+	for _, a := range r.alerts.Evaluate(q.InstrumentID, *q.Last, q.Timestamp) {
+		r.SubscriptionManager.Publish(subscriptions.Event{
+			Topic:   subscriptions.TopicAlerts,
+			Channel: a.Owner,
+			Payload: priceAlert(a),
+		})
+	}
+}
+
+func alertCondition(c model.AlertCondition) alert.Condition {
+	if c == model.AlertConditionBelow {
+		return alert.Below
+	}
+	return alert.Above
+}
+
+func priceAlert(a alert.Alert) *model.PriceAlert {
+	result := &model.PriceAlert{
+		ID:           a.ID,
+		InstrumentID: a.InstrumentID,
+		Condition:    model.AlertConditionAbove,
+		Price:        a.Price,
+		Rearm:        a.Rearm,
+		Active:       a.Armed,
+		TriggerCount: int32(a.TriggerCount),
+		CreatedAt:    a.CreatedAt,
+	}
+	if a.Condition == alert.Below {
+		result.Condition = model.AlertConditionBelow
+	}
+	if a.TriggerCount > 0 {
+		price, at := a.TriggeredPrice, a.TriggeredAt
+		result.TriggeredPrice, result.TriggeredAt = &price, &at
+	}
+	return result
+}
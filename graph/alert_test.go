@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/alert"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPriceAlert_TriggeredByFill(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx, cancel := context.WithCancel(middware.WithUserID(context.Background(), "U1"))
+	defer cancel()
+
+	_, err := r.Query().Alerts(context.Background())
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+
+	above, err := r.Mutation().CreatePriceAlert(ctx, "600000.SH", model.AlertConditionAbove, decimal.RequireFromString("10"), nil)
+	require.NoError(t, err)
+	below, err := r.Mutation().CreatePriceAlert(ctx, "600000.SH", model.AlertConditionBelow, decimal.RequireFromString("9"), nil)
+	require.NoError(t, err)
+	triggered, err := r.Subscription().AlertTriggered(ctx)
+	require.NoError(t, err)
+
+	sell := newOrderInput("600000.SH", "S1")
+	sell.Side = model.OrderSideSell
+	_, err = r.Mutation().PlaceOrder(newTestAccount(t, r, "S1"), *sell)
+	require.NoError(t, err)
+	_, err = r.Mutation().PlaceOrder(newTestAccount(t, r, "B1"), *newOrderInput("600000.SH", "B1"))
+	require.NoError(t, err)
+
+	select {
+	case a := <-triggered:
+		assert.Equal(t, above.ID, a.ID)
+		assert.False(t, a.Active)
+		assert.Equal(t, "10", a.TriggeredPrice.String())
+	case <-time.After(time.Second):
+		t.Fatal("alert not triggered")
+	}
+
+	alerts, err := r.Query().Alerts(ctx)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, int32(1), alerts[0].TriggerCount)
+	assert.True(t, alerts[1].Active)
+
+	// 其他用户不可删除
+	_, err = r.Mutation().DeleteAlert(middware.WithUserID(context.Background(), "U2"), below.ID)
+	assert.ErrorIs(t, err, alert.ErrAlertNotFound)
+	ok, err := r.Mutation().DeleteAlert(ctx, below.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
@@ -0,0 +1,276 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/graph/subscriptions"
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/matching"
+	"gqlexample/pkg/task"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultApprovalExpiry = 10 * time.Minute
+	approvalExpiredReason = "approval expired"
+
+	CodeApprovalRequired = "APPROVAL_REQUIRED"
+)
+
+var (
+	errSelfApproval     = errors.New("order must be reviewed by another user")
+	errNotPending       = errors.New("order is not pending approval")
+	errUnknownSubmitter = errors.New("order submitter unknown")
+	errAmendApproval    = errors.New("amended order exceeds approval threshold")
+)
+
+// initApproval 按配置初始化大额订单审批，阈值无效时不启用审批
+func (r *Resolver) initApproval(cfg config.ApprovalConfig) {
+	r.approvalTasks = task.NewTaskManager()
+	r.approvalExpiry = cfg.Expiry
+	if r.approvalExpiry <= 0 {
+		r.approvalExpiry = defaultApprovalExpiry
+	}
+	if cfg.Threshold == "" {
+		return
+	}
+	threshold, err := decimal.NewFromString(cfg.Threshold)
+	if err != nil || threshold.IsNegative() {
+		zap.L().Error("Invalid approval threshold, approval disabled", zap.String("threshold", cfg.Threshold), zap.Error(err))
+		return
+	}
+	r.approvalThreshold = threshold
+}
+
+// approvalNotional 按委托数量及参考价估算审批金额，需在订单簿锁内调用
+// 限价单取委托价，市价单取对手方最差成交价、最优价及最新成交价中的最高者，均不存在时返回 false
+func approvalNotional(b *matching.Book, o *model.Order) (decimal.Decimal, bool) {
+	quantity := decimal.NewFromInt32(o.Quantity)
+	if o.Price != nil {
+		return o.Price.Amount.Mul(quantity), true
+	}
+	m := riskMarket(b, o.Side, o.Quantity-o.FilledQuantity, nil)
+	price := decimal.Max(m.Sweep, m.Best, m.Last)
+	if !price.IsPositive() {
+		return decimal.Zero, false
+	}
+	return price.Mul(quantity), true
+}
+
+// requiresApproval 审批金额超过阈值或无法估算时需要审批，需在订单簿锁内调用
+func (r *Resolver) requiresApproval(b *matching.Book, o *model.Order) bool {
+	if !r.approvalThreshold.IsPositive() {
+		return false
+	}
+	notional, ok := approvalNotional(b, o)
+	return !ok || notional.GreaterThan(r.approvalThreshold)
+}
+
+// checkAmendApproval 改单后超过审批阈值且金额增加时拒绝改单，需撤单后重新下单并审批
+func (r *Resolver) checkAmendApproval(b *matching.Book, before, after *model.Order) error {
+	if !r.requiresApproval(b, after) {
+		return nil
+	}
+	previous, ok := approvalNotional(b, before)
+	if notional, _ := approvalNotional(b, after); ok && !notional.GreaterThan(previous) {
+		return nil
+	}
+	return errcode.New(CodeApprovalRequired, fmt.Errorf("%w: %s", errAmendApproval, after.Id)).
+		With("threshold", r.approvalThreshold.String())
+}
+
+// createPendingOrder 保存待审批订单并安排过期，资金占用保留到审批结束
+func (r *Resolver) createPendingOrder(ctx context.Context, order *model.Order, h *hold, notional decimal.Decimal) (*model.Order, error) {
+	// 审批需核对下单人，匿名提交的订单不能进入审批
+	submitter, err := requireUser(ctx)
+	if err != nil {
+		r.cancelHold(h)
+		return nil, err
+	}
+	order.Status = model.OrderStatusPendingApproval
+	order.SubmittedBy = &submitter
+	expiresAt := r.now().Add(r.approvalExpiry)
+	order.ApprovalExpiresAt = &expiresAt
+
+	err = r.orderBooks.Do(order.InstrumentId, func(*matching.Book) error {
+		created, err := r.orders.Create(order)
+		if err != nil {
+			return err
+		}
+		if h != nil {
+			r.holds.put(created.Id, h)
+		}
+		r.holdNotional(created, notional)
+		return nil
+	})
+	if err != nil {
+		r.cancelHold(h)
+		return nil, err
+	}
+	id := order.Id
+	r.approvalTasks.AddTask(id, task.NewDelayedTask(r.approvalExpiry, func() { r.expireApproval(id) }))
+
+	zap.L().Info("Order pending approval", zap.String("order", id), zap.String("account", order.AccountId))
+	audit.Record(ctx, "Order", id, nil, order)
+	r.publishOrder(order)
+	r.publishApproval(order)
+	return order, nil
+}
+
+// reviewOrder 在订单簿锁内将待审批订单更新为审批结果，reviewer 不能是下单人或账户所有者
+// prepare 在更新订单前调用，可为空
+func (r *Resolver) reviewOrder(change *bookChange, id, reviewer string, prepare func(*matching.Book), fn func(*matching.Book, *model.Order) error, after func(*matching.Book, *model.Order) *model.Order) (before model.Order, order *model.Order, err error) {
+	err = r.withBook(change, func(b *matching.Book) error {
+		if prepare != nil {
+			prepare(b)
+		}
+		var err error
+		order, err = r.orders.Update(id, store.AnyVersion, func(o *model.Order) error {
+			before = *o.Clone()
+			if o.Status != model.OrderStatusPendingApproval {
+				return errcode.New(store.CodeConflict, fmt.Errorf("%w: %s is %s", errNotPending, o.Id, o.Status))
+			}
+			if reviewer != "" {
+				if err := r.checkReviewer(o, reviewer); err != nil {
+					return err
+				}
+			}
+			if err := fn(b, o); err != nil {
+				return err
+			}
+			o.ApprovalExpiresAt = nil
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		r.approvalTasks.CancelTask(id)
+		order = after(b, order)
+		return nil
+	})
+	return before, order, err
+}
+
+// checkReviewer 审批人不能是下单人或账户所有者，下单人未知的订单不能审批
+func (r *Resolver) checkReviewer(o *model.Order, reviewer string) error {
+	if o.SubmittedBy == nil {
+		return errcode.New(CodeForbidden, fmt.Errorf("%w: %s", errUnknownSubmitter, o.Id))
+	}
+	if *o.SubmittedBy == reviewer {
+		return errcode.New(CodeForbidden, fmt.Errorf("%w: %s", errSelfApproval, o.Id))
+	}
+	if account, ok := r.accounts.Get(o.AccountId); ok && account.OwnerID == reviewer {
+		return errcode.New(CodeForbidden, fmt.Errorf("%w: %s owns %s", errSelfApproval, reviewer, o.AccountId))
+	}
+	return nil
+}
+
+// approveOrder 审批通过后按当前行情重新做风控检查，再将订单送入撮合
+func (r *Resolver) approveOrder(ctx context.Context, id, reviewer string) (*model.Order, error) {
+	current, ok := r.orders.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+	inst, ok := r.InstrumentCatalog.Get(current.InstrumentId)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+	if err := r.checkTradingOpen(inst); err != nil {
+		return nil, err
+	}
+
+	var makers map[string]*model.Order
+	var undo func()
+	change := &bookChange{instrumentID: current.InstrumentId}
+	before, order, err := r.reviewOrder(change, id, reviewer, func(b *matching.Book) {
+		// 待审批订单不能改单，方向及数量不变
+		makers = r.makerOrders(b, current.Side, current.Quantity)
+	}, func(b *matching.Book, o *model.Order) error {
+		// 提交后行情或限额可能已变化
+		if _, err := r.risk.Check(riskOrder(b, o)); err != nil {
+			return err
+		}
+		// 资金不足或记账失败时订单保持待审批
+		var err error
+		if undo, err = r.settleFills(o, r.holds.get(o.Id), b.Preview(matchingOrder(o)), makers); err != nil {
+			return err
+		}
+		o.Status = model.OrderStatusNew
+		o.ReviewedBy = &reviewer
+		return nil
+	}, func(b *matching.Book, o *model.Order) *model.Order {
+		return r.matchOrder(ctx, change, b, o)
+	})
+	if err != nil {
+		if undo != nil {
+			_ = r.orderBooks.Do(current.InstrumentId, func(*matching.Book) error {
+				undo()
+				return nil
+			})
+		}
+		return nil, err
+	}
+
+	zap.L().Info("Order approved", zap.String("order", id), zap.String("reviewer", reviewer))
+	audit.Record(ctx, "Order", id, &before, order)
+	change.orders = append(change.orders, order)
+	r.publishBookChange(change)
+	r.publishApproval(order)
+	return order, nil
+}
+
+// rejectOrder 拒绝待审批订单并释放资金占用，reviewer 为空表示审批过期
+func (r *Resolver) rejectOrder(ctx context.Context, id, reviewer, reason string) (*model.Order, error) {
+	current, ok := r.orders.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+
+	change := &bookChange{instrumentID: current.InstrumentId}
+	before, order, err := r.reviewOrder(change, id, reviewer, nil, func(_ *matching.Book, o *model.Order) error {
+		o.Status = model.OrderStatusRejected
+		if reviewer != "" {
+			o.ReviewedBy = &reviewer
+		}
+		if reason != "" {
+			o.RejectReason = &reason
+		}
+		return nil
+	}, func(_ *matching.Book, o *model.Order) *model.Order {
+		r.releaseHold(o)
+		return o
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	zap.L().Info("Order rejected", zap.String("order", id), zap.String("reviewer", reviewer), zap.String("reason", reason))
+	audit.Record(ctx, "Order", id, &before, order)
+	r.publishOrder(order)
+	r.publishApproval(order)
+	return order, nil
+}
+
+// expireApproval 审批过期后自动拒绝订单，订单已审批或撤销时忽略
+func (r *Resolver) expireApproval(id string) {
+	if _, err := r.rejectOrder(context.Background(), id, "", approvalExpiredReason); err != nil && !errors.Is(err, errNotPending) {
+		zap.L().Error("Failed to expire pending approval", zap.String("order", id), zap.Error(err))
+	}
+}
+
+// publishApproval 向审批人推送待审批订单的状态变化
+func (r *Resolver) publishApproval(order *model.Order) {
+	r.SubscriptionManager.Publish(subscriptions.Event{
+		Topic:   subscriptions.TopicApprovals,
+		Channel: subscriptions.AnyChannel,
+		Payload: order,
+	})
+}
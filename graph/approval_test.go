@@ -0,0 +1,179 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/risk"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproval_FourEyes(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	r.approvalThreshold = decimal.NewFromInt(500)
+	admin := middware.WithUserID(context.Background(), "admin")
+	officer := middware.WithUserID(context.Background(), "risk_officer")
+
+	owner, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "trader", Name: "Trader"})
+	require.NoError(t, err)
+	ctx := middware.WithUserID(context.Background(), owner.ID)
+	_, err = r.Mutation().CreateAccount(ctx, model.NewAccount{ID: "CASH1", OwnerID: owner.ID, Currency: "CNY"})
+	require.NoError(t, err)
+	_, err = r.Mutation().Deposit(admin, "CASH1", decimal.NewFromInt(10000), nil)
+	require.NoError(t, err)
+
+	// 超过阈值的订单等待审批，不进入订单簿但保留资金占用
+	pending, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "CASH1"))
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPendingApproval, pending.Status)
+	require.NotNil(t, pending.SubmittedBy)
+	assert.Equal(t, owner.ID, *pending.SubmittedBy)
+	require.NotNil(t, pending.ApprovalExpiresAt)
+	assert.NotNil(t, r.holds.get(pending.Id))
+
+	// 非审批人不能查看或审批
+	_, err = r.Query().PendingApprovals(ctx)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().ApproveOrder(ctx, pending.Id)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	orders, err := r.Query().PendingApprovals(admin)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+
+	// 审批通过后进入撮合，重复审批冲突
+	approved, err := r.Mutation().ApproveOrder(admin, pending.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusNew, approved.Status)
+	require.NotNil(t, approved.ReviewedBy)
+	assert.Equal(t, "admin", *approved.ReviewedBy)
+	assert.Nil(t, approved.ApprovalExpiresAt)
+	_, err = r.Mutation().ApproveOrder(officer, pending.Id)
+	assert.ErrorIs(t, err, errNotPending)
+	book, err := r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	assert.Len(t, book.Bids, 1)
+
+	// 审批人不能审批自己提交的订单
+	now = now.Add(time.Minute)
+	self, err := r.Mutation().PlaceOrder(admin, *newOrderInput("600000.SH", "CASH1"))
+	require.NoError(t, err)
+	_, err = r.Mutation().ApproveOrder(admin, self.Id)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	assert.ErrorIs(t, err, errSelfApproval)
+
+	// 拒绝后释放资金占用
+	now = now.Add(time.Minute)
+	rejected, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "CASH1"))
+	require.NoError(t, err)
+	reason := "too large"
+	rejected, err = r.Mutation().RejectOrder(officer, rejected.Id, &reason)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusRejected, rejected.Status)
+	require.NotNil(t, rejected.RejectReason)
+	assert.Equal(t, reason, *rejected.RejectReason)
+	assert.Nil(t, r.holds.get(rejected.Id))
+
+	// 待审批订单可撤销
+	cancelled, err := r.Mutation().CancelOrder(admin, self.Id, self.Version)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, cancelled.Status)
+	orders, err = r.Query().PendingApprovals(admin)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+func TestApproval_Expiry(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	r.approvalThreshold = decimal.NewFromInt(500)
+	r.approvalExpiry = 20 * time.Millisecond
+	ctx := newTestAccount(t, r, "A1")
+
+	subCtx, cancel := context.WithCancel(middware.WithUserID(context.Background(), "admin"))
+	defer cancel()
+	updates, err := r.Subscription().ApprovalUpdated(subCtx)
+	require.NoError(t, err)
+
+	order, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A1"))
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPendingApproval, (<-updates).Status)
+
+	// 超时未审批自动拒绝
+	expired := <-updates
+	assert.Equal(t, order.Id, expired.Id)
+	assert.Equal(t, model.OrderStatusRejected, expired.Status)
+	require.NotNil(t, expired.RejectReason)
+	assert.Equal(t, approvalExpiredReason, *expired.RejectReason)
+	assert.Nil(t, expired.ReviewedBy)
+}
+
+func TestApproval_Checks(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	r.approvalThreshold = decimal.NewFromInt(1500)
+	admin := middware.WithUserID(context.Background(), "admin")
+	officer := middware.WithUserID(context.Background(), "risk_officer")
+	ctx := newTestAccount(t, r, "A1")
+
+	// 改单超过阈值时拒绝，减少金额的改单不受影响
+	order, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A1"))
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusNew, order.Status)
+	qty := int32(200)
+	_, err = r.Mutation().AmendOrder(ctx, order.Id, model.AmendOrder{Quantity: &qty, ExpectedVersion: order.Version})
+	assert.Equal(t, CodeApprovalRequired, errcode.Code(err))
+	price := decimal.RequireFromString("9.90")
+	_, err = r.Mutation().AmendOrder(ctx, order.Id, model.AmendOrder{Price: &price, ExpectedVersion: order.Version})
+	require.NoError(t, err)
+
+	// 无参考价的市价单需要审批
+	now = now.Add(time.Minute)
+	market := newOrderInput("600000.SH", "A1")
+	orderType := model.OrderTypeMarket
+	market.Type, market.Price = &orderType, nil
+	pending, err := r.Mutation().PlaceOrder(ctx, *market)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPendingApproval, pending.Status)
+
+	// 账户所有者不能审批管理员代为提交的订单
+	owner, err := r.Query().Account(ctx, "A1")
+	require.NoError(t, err)
+	r.cfg.Approval.Approvers = append(r.cfg.Approval.Approvers, owner.OwnerID)
+	now = now.Add(time.Minute)
+	large := newOrderInput("600000.SH", "A1")
+	large.Quantity = 200
+	pending, err = r.Mutation().PlaceOrder(admin, *large)
+	require.NoError(t, err)
+	_, err = r.Mutation().ApproveOrder(ctx, pending.Id)
+	assert.ErrorIs(t, err, errSelfApproval)
+
+	// 审批时重新做风控检查
+	_, err = r.Mutation().SetKillSwitch(admin, "A1", true)
+	require.NoError(t, err)
+	_, err = r.Mutation().ApproveOrder(officer, pending.Id)
+	assert.Equal(t, risk.CodeKillSwitch, errcode.Code(err))
+	current, _ := r.orders.Get(pending.Id)
+	assert.Equal(t, model.OrderStatusPendingApproval, current.Status)
+
+	// 下单人未知的订单不能审批
+	_, err = r.createPendingOrder(context.Background(), &model.Order{InstrumentId: "600000.SH", AccountId: "A1"}, nil, decimal.Zero)
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+	_, err = r.orders.Update(pending.Id, store.AnyVersion, func(o *model.Order) error {
+		o.SubmittedBy = nil
+		return nil
+	})
+	require.NoError(t, err)
+	_, err = r.Mutation().ApproveOrder(officer, pending.Id)
+	assert.ErrorIs(t, err, errUnknownSubmitter)
+}
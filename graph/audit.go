@@ -0,0 +1,74 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/export"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errNoAuditEntries = errors.New("no audit entries match the filter")
+
+// auditFilter 构造审计日志查询条件
+func auditFilter(entityType, entityID *string, from, to *time.Time) audit.Filter {
+	var f audit.Filter
+	if entityType != nil {
+		f.EntityType = *entityType
+	}
+	if entityID != nil {
+		f.EntityID = *entityID
+	}
+	if from != nil {
+		f.From = *from
+	}
+	if to != nil {
+		f.To = *to
+	}
+	return f
+}
+
+// auditEntry 将审计记录转换为 GraphQL 类型
+func auditEntry(e *audit.Entry) *model.AuditEntry {
+	entry := &model.AuditEntry{
+		ID:        strconv.FormatInt(e.Seq, 10),
+		Timestamp: e.Timestamp,
+		Operation: e.Operation,
+		Changes:   make([]*model.AuditChange, 0, len(e.Changes)),
+		Status:    model.AuditStatus(e.Status),
+	}
+	if e.Principal != "" {
+		entry.Principal = &e.Principal
+	}
+	if len(e.Variables) > 0 {
+		if vars, err := json.Marshal(e.Variables); err == nil {
+			s := string(vars)
+			entry.Variables = &s
+		}
+	}
+	if e.Error != "" {
+		entry.Error = &e.Error
+	}
+	for _, c := range e.Changes {
+		change := &model.AuditChange{EntityType: c.EntityType, EntityID: c.EntityID}
+		if len(c.Before) > 0 {
+			before := string(c.Before)
+			change.Before = &before
+		}
+		if len(c.After) > 0 {
+			after := string(c.After)
+			change.After = &after
+		}
+		entry.Changes = append(entry.Changes, change)
+	}
+	return entry
+}
+
+// AuditExportHandler 下载 exportAuditLog 生成的文件，仅管理员和审计人员可访问
+func (r *Resolver) AuditExportHandler() http.Handler {
+	return export.FileHandler(config.ResolvePath(r.cfg.Audit.ExportDir), r.requireAuditor)
+}
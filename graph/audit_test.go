@@ -0,0 +1,53 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditLog_Access(t *testing.T) {
+	r := newTestResolver(t, func(cfg *config.Config) {
+		cfg.Audit.Auditors = []string{"auditor"}
+	})
+	require.NoError(t, r.Audit.Append(&audit.Entry{Timestamp: time.Now(), Operation: "createTodo", Principal: "U1", Status: "ok"}))
+	auditor := middware.WithUserID(context.Background(), "auditor")
+	user := middware.WithUserID(context.Background(), "U1")
+
+	// 普通用户不能查看或导出审计日志
+	_, err := r.Query().AuditLog(user, nil, nil, nil, nil, nil, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().ExportAuditLog(context.Background(), nil, nil, nil, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	conn, err := r.Query().AuditLog(auditor, nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), conn.TotalCount)
+	_, err = r.Query().AuditLog(middware.WithUserID(context.Background(), "admin"), nil, nil, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	// 导出返回文件名，通过下载接口获取内容
+	name, err := r.Mutation().ExportAuditLog(auditor, nil, nil, nil, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, name, "/")
+	download := func(ctx context.Context) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/audit/export/"+name, nil).WithContext(ctx)
+		r.AuditExportHandler().ServeHTTP(rec, req)
+		return rec
+	}
+	rec := download(auditor)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "createTodo")
+	assert.Equal(t, http.StatusForbidden, download(user).Code)
+}
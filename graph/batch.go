@@ -0,0 +1,136 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/idempotency"
+	"gqlexample/pkg/middware"
+	"sort"
+)
+
+const (
+	CodeBatchTooLarge = "BATCH_TOO_LARGE"
+	CodeBatchAborted  = "BATCH_ABORTED"
+	// CodeBadUserInput 未指定错误码的条目错误
+	CodeBadUserInput = "BAD_USER_INPUT"
+
+	defaultMaxBatchSize = 500
+)
+
+var (
+	errBatchTooLarge = errors.New("batch too large")
+	errBatchAborted  = errors.New("batch aborted because other items failed")
+
+	errDuplicateMutationID = errors.New("duplicate clientMutationId in batch")
+	errOrderNotPlaced      = errors.New("order was not placed")
+)
+
+// checkBatchSize 校验批量条数不超过配置上限
+func (r *Resolver) checkBatchSize(n int) error {
+	limit := r.cfg.Batch.MaxSize
+	if limit <= 0 {
+		limit = defaultMaxBatchSize
+	}
+	if n > limit {
+		return errcode.New(CodeBatchTooLarge, fmt.Errorf("%w: %d items, at most %d", errBatchTooLarge, n, limit)).
+			With("maxSize", limit)
+	}
+	return nil
+}
+
+// batchError 将条目错误转换为结果，未附带错误码时使用 BAD_USER_INPUT
+func batchError(index int, err error) *model.BatchError {
+	code := errcode.Code(err)
+	if code == "" {
+		code = CodeBadUserInput
+	}
+	return &model.BatchError{
+		Index:   int32(index),
+		Code:    code,
+		Message: err.Error(),
+	}
+}
+
+// batchFailed 是否存在失败的条目
+func batchFailed(errs []error) bool {
+	for _, err := range errs {
+		if err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// abortBatch 原子模式下有条目失败时，失败条目返回自身错误，其余条目标记为已中止
+func abortBatch(errs []error) []*model.BatchError {
+	results := make([]*model.BatchError, len(errs))
+	for i, err := range errs {
+		if err == nil {
+			err = errcode.New(CodeBatchAborted, errBatchAborted)
+		}
+		results[i] = batchError(i, err)
+	}
+	return results
+}
+
+// orderClaims 批量下单中按 clientMutationId 占用的幂等键，与 placeOrder 共用键空间
+type orderClaims struct {
+	replayed []*model.Order
+	errs     []error
+	finish   []func(any, error)
+}
+
+// claimOrders 占用各订单的 clientMutationId，已成功下单的条目返回首次结果
+// 按键排序后依次占用，避免并发批次互相等待；同一批次内重复的键视为条目错误
+func (r *Resolver) claimOrders(ctx context.Context, inputs []*model.NewOrder) *orderClaims {
+	c := &orderClaims{
+		replayed: make([]*model.Order, len(inputs)),
+		errs:     make([]error, len(inputs)),
+		finish:   make([]func(any, error), len(inputs)),
+	}
+	keyed := make([]int, 0, len(inputs))
+	for i, input := range inputs {
+		if input.ClientMutationID != nil && *input.ClientMutationID != "" {
+			keyed = append(keyed, i)
+		}
+	}
+	sort.SliceStable(keyed, func(a, b int) bool {
+		return *inputs[keyed[a]].ClientMutationID < *inputs[keyed[b]].ClientMutationID
+	})
+
+	principal := middware.UserIDFromContext(ctx)
+	for n, i := range keyed {
+		key := *inputs[i].ClientMutationID
+		if n > 0 && key == *inputs[keyed[n-1]].ClientMutationID {
+			c.errs[i] = fmt.Errorf("%w: %s", errDuplicateMutationID, key)
+			continue
+		}
+		result, found, finish, err := r.Idempotency.Begin(ctx, principal, "placeOrder:"+key, idempotency.Fingerprint(*inputs[i]))
+		switch {
+		case err != nil:
+			c.errs[i] = err
+		case found:
+			c.replayed[i] = result.(*model.Order)
+		default:
+			c.finish[i] = finish
+		}
+	}
+	return c
+}
+
+// done 保存本次批量下单中成功创建的订单，其余占用的键释放以便重试
+func (c *orderClaims) done(results []model.OrderResult) {
+	for i, finish := range c.finish {
+		if finish == nil {
+			continue
+		}
+		if order, ok := results[i].(*model.Order); ok {
+			finish(order, nil)
+		} else {
+			finish(nil, errOrderNotPlaced)
+		}
+	}
+}
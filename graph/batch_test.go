@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"testing"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/idempotency"
+	"gqlexample/pkg/limits"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrderInput(instrumentID, accountID string) *model.NewOrder {
+	price := decimal.RequireFromString("10.00")
+	return &model.NewOrder{
+		InstrumentID: instrumentID,
+		AccountID:    accountID,
+		Side:         model.OrderSideBuy,
+		Price:        &price,
+		Quantity:     100,
+	}
+}
+
+func TestPlaceOrders_PartialFailure(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx := newTestAccount(t, r, "B1")
+
+	results, err := r.Mutation().PlaceOrders(ctx, []*model.NewOrder{
+		newOrderInput("600000.SH", "B1"),
+		newOrderInput("404.SH", "B1"),
+		newOrderInput("600519.SH", "B1"),
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.IsType(t, &model.Order{}, results[0])
+	assert.IsType(t, &model.Order{}, results[2])
+	batchErr, ok := results[1].(*model.BatchError)
+	require.True(t, ok)
+	assert.Equal(t, int32(1), batchErr.Index)
+	assert.Equal(t, CodeBadUserInput, batchErr.Code)
+
+	// 同一账户再次下单受间隔限制
+	results, err = r.Mutation().PlaceOrders(ctx, []*model.NewOrder{
+		newOrderInput("600000.SH", "B1"),
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, limits.CodeTradingInterval, results[0].(*model.BatchError).Code)
+}
+
+func TestPlaceOrders_Atomic(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	atomic := true
+	ctx := newTestAccount(t, r, "B2")
+
+	results, err := r.Mutation().PlaceOrders(ctx, []*model.NewOrder{
+		newOrderInput("600000.SH", "B2"),
+		newOrderInput("404.SH", "B2"),
+	}, &atomic)
+	require.NoError(t, err)
+	assert.Equal(t, CodeBatchAborted, results[0].(*model.BatchError).Code)
+	assert.Equal(t, CodeBadUserInput, results[1].(*model.BatchError).Code)
+	assert.Empty(t, r.orders.List())
+
+	// 中止的批次不占用下单额度
+	limit, err := r.Query().TradingLimits(ctx, "B2")
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), limit.OrderCount)
+}
+
+func TestPlaceOrders_ClientMutationID(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx := newTestAccount(t, r, "C1", "C2", "C3", "C4")
+	keyed := func(account, key string) *model.NewOrder {
+		input := newOrderInput("600000.SH", account)
+		input.ClientMutationID = &key
+		return input
+	}
+
+	first, err := r.Mutation().PlaceOrders(ctx, []*model.NewOrder{keyed("C1", "k1"), keyed("C2", "k2")}, nil)
+	require.NoError(t, err)
+	placed := first[0].(*model.Order)
+
+	// 重试返回首次结果，不再重复下单；键相同但内容不同时报错
+	changed := keyed("C2", "k2")
+	changed.Quantity = 200
+	results, err := r.Mutation().PlaceOrders(ctx, []*model.NewOrder{keyed("C1", "k1"), keyed("C3", "k3"), changed}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, placed.Id, results[0].(*model.Order).Id)
+	assert.IsType(t, &model.Order{}, results[1])
+	assert.Equal(t, idempotency.CodeKeyReused, results[2].(*model.BatchError).Code)
+	assert.Len(t, r.orders.List(), 3)
+
+	// 与 placeOrder 共用幂等键
+	order, err := r.Mutation().PlaceOrder(ctx, *keyed("C1", "k1"))
+	require.NoError(t, err)
+	assert.Equal(t, placed.Id, order.Id)
+
+	// 同一批次内重复的键
+	results, err = r.Mutation().PlaceOrders(ctx, []*model.NewOrder{keyed("C4", "k4"), keyed("C4", "k4")}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &model.Order{}, results[0])
+	assert.Contains(t, results[1].(*model.BatchError).Message, errDuplicateMutationID.Error())
+
+	// 不能为他人的账户下单
+	other := newTestAccount(t, r, "C5")
+	results, err = r.Mutation().PlaceOrders(other, []*model.NewOrder{newOrderInput("600000.SH", "C1"), newOrderInput("600000.SH", "C5")}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, CodeForbidden, results[0].(*model.BatchError).Code)
+	assert.IsType(t, &model.Order{}, results[1])
+}
+
+func TestCreateTodos_TooLarge(t *testing.T) {
+	r := newTestResolver(t)
+	_, err := r.Mutation().CreateTodos(context.Background(), make([]*model.NewTodo, r.cfg.Batch.MaxSize+1), nil)
+	assert.ErrorIs(t, err, errBatchTooLarge)
+}
@@ -0,0 +1,82 @@
+package graph
+
+import (
+	"errors"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/candle"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/export"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	defaultCandleIntervals = []string{"1s", "1m", "5m", "1d"}
+	errNoCandles           = errors.New("no candles to export")
+)
+
+// newCandles 按配置创建 K 线聚合器，进行中的 K 线推送到订阅，无效周期忽略
+func (r *Resolver) newCandles(cfg config.CandleConfig) *candle.Aggregator {
+	specs := cfg.Intervals
+	if len(specs) == 0 {
+		specs = defaultCandleIntervals
+	}
+	intervals := make([]time.Duration, 0, len(specs))
+	for _, spec := range specs {
+		interval, err := candle.ParseInterval(spec)
+		if err != nil {
+			zap.L().Warn("Invalid candle interval, skipped", zap.String("interval", spec), zap.Error(err))
+			continue
+		}
+		intervals = append(intervals, interval)
+	}
+
+	return candle.New(intervals, cfg.History, r.alignCandle, func(b candle.Bar) {
+		r.candleHub.Publish(candleKey(b.InstrumentID, b.Interval), candleBar(b))
+	})
+}
+
+// alignCandle 交易时段内按时段开始时刻对齐且不跨越时段结束，其余按交易时区的自然时间对齐
+func (r *Resolver) alignCandle(instrumentID string, interval time.Duration, at time.Time) (time.Time, time.Time) {
+	loc := time.Local
+	if cal, err := r.tradingCalendar(); err == nil {
+		loc = cal.Location()
+		if inst, ok := r.InstrumentCatalog.Get(instrumentID); ok && interval < 24*time.Hour {
+			if start, end, ok := cal.SessionOf(inst.Product, at); ok {
+				barStart := start.Add(at.Sub(start) / interval * interval)
+				barEnd := barStart.Add(interval)
+				if barEnd.After(end) {
+					barEnd = end
+				}
+				return barStart, barEnd
+			}
+		}
+	}
+	return candle.AlignClock(loc)(instrumentID, interval, at)
+}
+
+func candleKey(instrumentID string, interval time.Duration) string {
+	return instrumentID + "|" + candle.FormatInterval(interval)
+}
+
+func candleBar(b candle.Bar) *model.Candle {
+	return &model.Candle{
+		InstrumentID: b.InstrumentID,
+		Interval:     candle.FormatInterval(b.Interval),
+		Start:        b.Start,
+		End:          b.End,
+		Open:         b.Open,
+		High:         b.High,
+		Low:          b.Low,
+		Close:        b.Close,
+		Volume:       b.Volume,
+		Complete:     b.Complete,
+	}
+}
+
+// CandleExportHandler 下载 flushCandles 生成的文件，仅管理员可访问
+func (r *Resolver) CandleExportHandler() http.Handler {
+	return export.FileHandler(config.ResolvePath(r.cfg.Candle.ExportDir), r.requireAdmin)
+}
@@ -0,0 +1,104 @@
+package graph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/candle"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandles_FromFills(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := r.Subscription().CandleUpdated(ctx, "600000.SH", "1m")
+	require.NoError(t, err)
+
+	sell := newOrderInput("600000.SH", "S1")
+	sell.Side = model.OrderSideSell
+	_, err = r.Mutation().PlaceOrder(newTestAccount(t, r, "S1"), *sell)
+	require.NoError(t, err)
+	buy := newOrderInput("600000.SH", "B1")
+	_, err = r.Mutation().PlaceOrder(newTestAccount(t, r, "B1"), *buy)
+	require.NoError(t, err)
+
+	select {
+	case bar := <-updates:
+		assert.Equal(t, "10", bar.Close.String())
+		assert.Equal(t, int64(100), bar.Volume)
+		assert.False(t, bar.Complete)
+	case <-time.After(time.Second):
+		t.Fatal("no candle update")
+	}
+
+	// 分钟线按交易时段开始对齐
+	r.candles.Add("600000.SH", decimal.RequireFromString("10.50"), 200, tradingTime().Add(90*time.Second))
+	bars, err := r.Query().Candles(ctx, "600000.SH", "1m", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	assert.True(t, tradingTime().Equal(bars[0].Start))
+	assert.True(t, bars[0].Complete)
+	assert.True(t, tradingTime().Add(time.Minute).Equal(bars[1].Start))
+	assert.Equal(t, "10.5", bars[1].Open.String())
+
+	_, err = r.Query().Candles(ctx, "600000.SH", "7m", nil, nil)
+	assert.ErrorIs(t, err, candle.ErrUnsupportedInterval)
+	_, err = r.Query().Candles(ctx, "UNKNOWN", "1m", nil, nil)
+	assert.Error(t, err)
+}
+
+func TestAlignCandle_SessionEnd(t *testing.T) {
+	r := newTestResolver(t)
+	// 上午时段 11:30 结束，K 线不跨越时段结束
+	at := tradingTime().Add(time.Hour + 29*time.Minute)
+	start, end := r.alignCandle("600000.SH", 5*time.Minute, at)
+	assert.True(t, tradingTime().Add(85*time.Minute).Equal(start))
+	assert.True(t, tradingTime().Add(90*time.Minute).Equal(end))
+
+	start, end = r.alignCandle("600000.SH", 7*time.Minute, at)
+	assert.True(t, tradingTime().Add(89*time.Minute).Equal(start))
+	assert.True(t, tradingTime().Add(90*time.Minute).Equal(end))
+}
+
+func TestFlushCandles(t *testing.T) {
+	r := newTestResolver(t)
+	admin := middware.WithUserID(context.Background(), "admin")
+	user := middware.WithUserID(context.Background(), "U1")
+
+	// 成交量超过 32 位整数范围时不截断
+	r.candles.Add("600000.SH", decimal.RequireFromString("10.00"), 3000000000, tradingTime())
+	bars, err := r.Query().Candles(admin, "600000.SH", "1m", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, bars, 1)
+	assert.Equal(t, int64(3000000000), bars[0].Volume)
+
+	_, err = r.Mutation().FlushCandles(user, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	// 导出返回文件名，通过下载接口获取内容
+	name, err := r.Mutation().FlushCandles(admin, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, name, "/")
+	download := func(ctx context.Context) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/candles/export/"+name, nil).WithContext(ctx)
+		r.CandleExportHandler().ServeHTTP(rec, req)
+		return rec
+	}
+	rec := download(admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "3000000000")
+	assert.Equal(t, http.StatusForbidden, download(user).Code)
+}
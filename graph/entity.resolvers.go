@@ -0,0 +1,42 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.68
+
+import (
+	"context"
+	"gqlexample/graph/model"
+)
+
+// FindManyInstrumentByIDs is the resolver for the findManyInstrumentByIDs field.
+func (r *entityResolver) FindManyInstrumentByIDs(ctx context.Context, reps []*model.InstrumentByIDsInput) ([]*model.Instrument, error) {
+	instruments := make([]*model.Instrument, len(reps))
+	for i, rep := range reps {
+		instruments[i], _ = r.InstrumentCatalog.Get(rep.ID)
+	}
+	return instruments, nil
+}
+
+// FindManyOrderByIDs is the resolver for the findManyOrderByIDs field.
+func (r *entityResolver) FindManyOrderByIDs(ctx context.Context, reps []*model.OrderByIDsInput) ([]*model.Order, error) {
+	orders := make([]*model.Order, len(reps))
+	for i, rep := range reps {
+		orders[i], _ = r.orders.Get(rep.ID)
+	}
+	return orders, nil
+}
+
+// FindManyUserByIDs is the resolver for the findManyUserByIDs field.
+func (r *entityResolver) FindManyUserByIDs(ctx context.Context, reps []*model.UserByIDsInput) ([]*model.User, error) {
+	users := make([]*model.User, len(reps))
+	for i, rep := range reps {
+		users[i], _ = r.users.Get(rep.ID)
+	}
+	return users, nil
+}
+
+// Entity returns EntityResolver implementation.
+func (r *Resolver) Entity() EntityResolver { return &entityResolver{r} }
+
+type entityResolver struct{ *Resolver }
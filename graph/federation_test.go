@@ -0,0 +1,76 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/middware"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const entitiesQuery = `query($representations: [_Any!]!) {
+	_entities(representations: $representations) {
+		__typename
+		... on Order { id status instrumentId }
+		... on User { id username displayName }
+		... on Instrument { id symbol tickSize }
+	}
+}`
+
+// tradingTime 返回处于交易时段内的时刻，避免下单受运行测试时间影响
+func tradingTime() time.Time {
+	return time.Date(2024, 1, 2, 10, 0, 0, 0, time.FixedZone("CST", 8*3600))
+}
+
+func TestFederation_Entities(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx := middware.WithUserID(context.Background(), "admin")
+
+	user, err := r.Mutation().CreateUser(ctx, model.NewUser{Username: "fed_alice", Name: "Alice"})
+	require.NoError(t, err)
+	order, err := r.Mutation().PlaceOrder(newTestAccount(t, r, "A1"), *newOrderInput("600000.SH", "A1"))
+	require.NoError(t, err)
+
+	c := client.New(handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: r})))
+
+	// 网关按类型批量发送 representations，未知实体返回 null
+	var resp struct {
+		Entities []map[string]any `json:"_entities"`
+	}
+	err = c.Post(entitiesQuery, &resp, client.Var("representations", []map[string]any{
+		{"__typename": "Order", "id": order.Id},
+		{"__typename": "User", "id": user.ID, "email": "alice@example.com"},
+		{"__typename": "Instrument", "id": "600000.SH"},
+		{"__typename": "Order", "id": "404"},
+		{"__typename": "User", "id": user.ID},
+	}))
+	require.NoError(t, err)
+	require.Len(t, resp.Entities, 5)
+
+	assert.Equal(t, "Order", resp.Entities[0]["__typename"])
+	assert.Equal(t, "NEW", resp.Entities[0]["status"])
+	assert.Equal(t, "Alice <alice@example.com>", resp.Entities[1]["displayName"])
+	assert.Equal(t, "fed_alice", resp.Entities[1]["username"])
+	assert.Equal(t, "600000.SH", resp.Entities[2]["id"])
+	assert.Nil(t, resp.Entities[3])
+	assert.Equal(t, "Alice", resp.Entities[4]["displayName"])
+}
+
+func TestFederation_Service(t *testing.T) {
+	c := client.New(handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: newTestResolver(t)})))
+
+	var resp struct {
+		Service struct {
+			SDL string `json:"sdl"`
+		} `json:"_service"`
+	}
+	require.NoError(t, c.Post(`{ _service { sdl } }`, &resp))
+	assert.Contains(t, resp.Service.SDL, `@key(fields: "id")`)
+}
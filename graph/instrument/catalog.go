@@ -0,0 +1,215 @@
+package instrument
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/graph/scalar"
+	"gqlexample/pkg/utils"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnknownInstrument = errors.New("unknown instrument")
+	ErrNotTrading        = errors.New("instrument is not trading")
+)
+
+// record CSV 中的一行合约数据
+type record struct {
+	ID       string `csv:"id"`
+	Symbol   string `csv:"symbol"`
+	Exchange string `csv:"exchange"`
+	Product  string `csv:"product"`
+	TickSize string `csv:"tick_size"`
+	LotSize  int    `csv:"lot_size"`
+	Currency string `csv:"currency"`
+	Status   string `csv:"status"`
+}
+
+// Catalog 合约参考数据目录，从 CSV 导入并定时刷新
+type Catalog struct {
+	path        string
+	mu          sync.RWMutex
+	instruments map[string]*model.Instrument
+
+	refreshMu sync.Mutex
+	stopCh    chan struct{}
+}
+
+func NewCatalog(path string) *Catalog {
+	return &Catalog{
+		path:        path,
+		instruments: make(map[string]*model.Instrument),
+	}
+}
+
+// Load 从 CSV 重新加载全部合约，任一行无效时保留原有数据
+func (c *Catalog) Load() error {
+	records, err := utils.ReadFromCsv[record](c.path)
+	if err != nil {
+		return err
+	}
+
+	instruments := make(map[string]*model.Instrument, len(records))
+	for i, r := range records {
+		inst, err := r.toModel()
+		if err != nil {
+			return fmt.Errorf("line %d: %w", i+2, err)
+		}
+		instruments[inst.ID] = inst
+	}
+
+	c.mu.Lock()
+	c.instruments = instruments
+	c.mu.Unlock()
+
+	zap.L().Info("Instrument catalog loaded", zap.String("path", c.path), zap.Int("count", len(instruments)))
+	return nil
+}
+
+// StartRefresh 按固定间隔重新加载合约目录，已在刷新时先停止原有的定时刷新
+func (c *Catalog) StartRefresh(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.stopCh != nil {
+		close(c.stopCh)
+	}
+	stop := make(chan struct{})
+	c.stopCh = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Load(); err != nil {
+					zap.L().Error("Failed to refresh instrument catalog", zap.Error(err))
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时刷新
+func (c *Catalog) Stop() {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	if c.stopCh != nil {
+		close(c.stopCh)
+		c.stopCh = nil
+	}
+}
+
+// Get 按 ID 查询合约
+func (c *Catalog) Get(id string) (*model.Instrument, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	inst, ok := c.instruments[id]
+	return inst, ok
+}
+
+// List 按过滤条件查询合约，结果按 ID 排序
+func (c *Catalog) List(filter *model.InstrumentFilter) []*model.Instrument {
+	c.mu.RLock()
+	result := make([]*model.Instrument, 0, len(c.instruments))
+	for _, inst := range c.instruments {
+		if matches(inst, filter) {
+			result = append(result, inst)
+		}
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// CheckOrder 校验下单合约存在且可交易，价格为最小变动价位的整数倍，数量为交易单位的整数倍
+func (c *Catalog) CheckOrder(instrumentID string, price decimal.Decimal, quantity int32) (*model.Instrument, error) {
+	inst, err := c.CheckMarketOrder(instrumentID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if err := scalar.PrecisionFor(instrumentID).Check(price); err != nil {
+		return nil, err
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("price must be positive: %s", price)
+	}
+	if inst.TickSize.IsPositive() && !price.Mod(inst.TickSize).IsZero() {
+		return nil, fmt.Errorf("price %s is not a multiple of tick size %s", price, inst.TickSize)
+	}
+	return inst, nil
+}
+
+// CheckMarketOrder 校验不带价格的市价单，仅检查合约状态及数量
+func (c *Catalog) CheckMarketOrder(instrumentID string, quantity int32) (*model.Instrument, error) {
+	inst, ok := c.Get(instrumentID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownInstrument, instrumentID)
+	}
+	if inst.TradingStatus != model.TradingStatusTrading {
+		return nil, fmt.Errorf("%w: %s is %s", ErrNotTrading, instrumentID, inst.TradingStatus)
+	}
+	if quantity <= 0 || (inst.LotSize > 0 && quantity%inst.LotSize != 0) {
+		return nil, fmt.Errorf("quantity %d is not a multiple of lot size %d", quantity, inst.LotSize)
+	}
+	return inst, nil
+}
+
+func (r record) toModel() (*model.Instrument, error) {
+	if r.ID == "" {
+		return nil, errors.New("missing instrument id")
+	}
+	tickSize, err := decimal.NewFromString(r.TickSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tick size %q: %w", r.TickSize, err)
+	}
+	status := model.TradingStatus(r.Status)
+	if !status.IsValid() {
+		return nil, fmt.Errorf("invalid trading status %q", r.Status)
+	}
+
+	return &model.Instrument{
+		ID:            r.ID,
+		Symbol:        r.Symbol,
+		Exchange:      r.Exchange,
+		Product:       r.Product,
+		TickSize:      tickSize,
+		LotSize:       int32(r.LotSize),
+		Currency:      r.Currency,
+		TradingStatus: status,
+	}, nil
+}
+
+func matches(inst *model.Instrument, filter *model.InstrumentFilter) bool {
+	if filter == nil {
+		return true
+	}
+	if filter.Symbol != nil && *filter.Symbol != inst.Symbol {
+		return false
+	}
+	if filter.Exchange != nil && *filter.Exchange != inst.Exchange {
+		return false
+	}
+	if filter.Product != nil && *filter.Product != inst.Product {
+		return false
+	}
+	if filter.Currency != nil && *filter.Currency != inst.Currency {
+		return false
+	}
+	if filter.TradingStatus != nil && *filter.TradingStatus != inst.TradingStatus {
+		return false
+	}
+	return true
+}
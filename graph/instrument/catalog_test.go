@@ -0,0 +1,92 @@
+package instrument
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+const testCatalog = `id,symbol,exchange,product,tick_size,lot_size,currency,status
+600000.SH,浦发银行,SSE,SSE_STOCK,0.01,100,CNY,TRADING
+430047.BJ,诺思兰德,BSE,BSE_STOCK,0.05,100,CNY,TRADING
+830799.BJ,艾融软件,BSE,BSE_STOCK,0.01,100,CNY,HALTED
+`
+
+func newTestCatalog(t *testing.T) *Catalog {
+	path := filepath.Join(t.TempDir(), "instruments.csv")
+	assert.NoError(t, os.WriteFile(path, []byte(testCatalog), 0644))
+
+	c := NewCatalog(path)
+	assert.NoError(t, c.Load())
+	return c
+}
+
+func TestMain(m *testing.M) {
+	// 测试日志不写入配置的日志文件
+	zap.ReplaceGlobals(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+func TestCatalog_Load(t *testing.T) {
+	c := newTestCatalog(t)
+
+	inst, ok := c.Get("430047.BJ")
+	assert.True(t, ok)
+	assert.Equal(t, "BSE_STOCK", inst.Product)
+	assert.True(t, inst.TickSize.Equal(decimal.RequireFromString("0.05")))
+
+	product := "BSE_STOCK"
+	assert.Len(t, c.List(&model.InstrumentFilter{Product: &product}), 2)
+	assert.Len(t, c.List(nil), 3)
+}
+
+func TestCatalog_CheckOrder(t *testing.T) {
+	c := newTestCatalog(t)
+
+	_, err := c.CheckOrder("600000.SH", decimal.RequireFromString("10.01"), 200)
+	assert.NoError(t, err)
+
+	_, err = c.CheckOrder("000001.SZ", decimal.RequireFromString("10"), 100)
+	assert.True(t, errors.Is(err, ErrUnknownInstrument))
+
+	_, err = c.CheckOrder("830799.BJ", decimal.RequireFromString("10"), 100)
+	assert.True(t, errors.Is(err, ErrNotTrading))
+
+	// 价格不是最小变动价位的整数倍
+	_, err = c.CheckOrder("430047.BJ", decimal.RequireFromString("10.02"), 100)
+	assert.Error(t, err)
+
+	// 数量不是交易单位的整数倍
+	_, err = c.CheckOrder("600000.SH", decimal.RequireFromString("10"), 150)
+	assert.Error(t, err)
+}
+
+func TestCatalog_Refresh(t *testing.T) {
+	c := newTestCatalog(t)
+	c.StartRefresh(time.Millisecond)
+	// 重复启动时替换原有的定时刷新，可与停止并发调用
+	done := make(chan struct{})
+	go func() {
+		c.StartRefresh(time.Millisecond)
+		close(done)
+	}()
+	c.Stop()
+	<-done
+	c.Stop()
+
+	assert.NoError(t, os.WriteFile(c.path, []byte(testCatalog+"600001.SH,邯郸钢铁,SSE,SSE_STOCK,0.01,100,CNY,TRADING\n"), 0644))
+	c.StartRefresh(time.Millisecond)
+	defer c.Stop()
+	assert.Eventually(t, func() bool {
+		_, ok := c.Get("600001.SH")
+		return ok
+	}, time.Second, time.Millisecond)
+}
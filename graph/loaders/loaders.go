@@ -0,0 +1,104 @@
+package loaders
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"gqlexample/graph/instrument"
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/pkg/dataloader"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.uber.org/zap"
+)
+
+type ctxKey struct{}
+
+// metrics 累计的加载器统计，通过 /debug/vars 暴露
+var metrics = expvar.NewMap("dataloader")
+
+// Loaders 单个响应内共享的数据加载器集合
+type Loaders struct {
+	User       *dataloader.Loader[string, *model.User]
+	Instrument *dataloader.Loader[string, *model.Instrument]
+}
+
+// NewLoaders 创建一组新的加载器，每个响应独立一份以隔离缓存
+func NewLoaders(users *store.UserStore, catalog *instrument.Catalog, opts ...dataloader.Option) *Loaders {
+	return &Loaders{
+		User:       dataloader.New(newUserBatch(users), opts...),
+		Instrument: dataloader.New(newInstrumentBatch(catalog), opts...),
+	}
+}
+
+// AroundResponses 为每个响应注入新的加载器，响应结束后记录统计
+// 订阅的每次推送各自独立，长连接不会持续使用过期的缓存
+func AroundResponses(newLoaders func() *Loaders) graphql.ResponseMiddleware {
+	return func(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+		l := newLoaders()
+		defer l.report()
+		return next(WithLoaders(ctx, l))
+	}
+}
+
+// WithLoaders 将加载器写入上下文
+func WithLoaders(ctx context.Context, l *Loaders) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// For 从上下文获取加载器，未注入时返回 nil
+func For(ctx context.Context) *Loaders {
+	l, _ := ctx.Value(ctxKey{}).(*Loaders)
+	return l
+}
+
+// report 输出本次请求的加载统计并累计到全局指标
+func (l *Loaders) report() {
+	for name, stats := range map[string]dataloader.Stats{
+		"user":       l.User.Stats(),
+		"instrument": l.Instrument.Stats(),
+	} {
+		if stats.Loads == 0 {
+			continue
+		}
+		metrics.Add(name+".loads", stats.Loads)
+		metrics.Add(name+".hits", stats.Hits)
+		metrics.Add(name+".batches", stats.Batches)
+		metrics.Add(name+".keys", stats.Keys)
+
+		zap.L().Debug("Dataloader stats",
+			zap.String("loader", name),
+			zap.Int64("loads", stats.Loads),
+			zap.Int64("hits", stats.Hits),
+			zap.Int64("batches", stats.Batches),
+			zap.Int64("keys", stats.Keys),
+		)
+	}
+}
+
+func newUserBatch(users *store.UserStore) dataloader.BatchFunc[string, *model.User] {
+	return func(ctx context.Context, ids []string) ([]*model.User, []error) {
+		result := make([]*model.User, len(ids))
+		errs := make([]error, len(ids))
+		for i, id := range ids {
+			user, ok := users.Get(id)
+			if !ok {
+				errs[i] = fmt.Errorf("%w: %s", store.ErrUserNotFound, id)
+				continue
+			}
+			result[i] = user
+		}
+		return result, errs
+	}
+}
+
+func newInstrumentBatch(catalog *instrument.Catalog) dataloader.BatchFunc[string, *model.Instrument] {
+	return func(ctx context.Context, ids []string) ([]*model.Instrument, []error) {
+		instruments := make([]*model.Instrument, len(ids))
+		for i, id := range ids {
+			instruments[i], _ = catalog.Get(id)
+		}
+		return instruments, nil
+	}
+}
@@ -0,0 +1,45 @@
+package loaders
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"gqlexample/graph/store"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	// 测试日志不写入配置的日志文件
+	zap.ReplaceGlobals(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+func TestAroundResponses(t *testing.T) {
+	users := store.NewUserStore()
+	user, err := users.Create("alice", "Alice")
+	require.NoError(t, err)
+	mw := AroundResponses(func() *Loaders { return NewLoaders(users, nil) })
+
+	name := func() string {
+		var got string
+		mw(context.Background(), func(ctx context.Context) *graphql.Response {
+			u, err := For(ctx).User.Load(ctx, user.ID)
+			require.NoError(t, err)
+			got = u.Name
+			return nil
+		})
+		return got
+	}
+
+	// 同一连接上的后续响应使用新的加载器，读取到最新数据
+	assert.Equal(t, "Alice", name())
+	renamed := "Alicia"
+	_, err = users.Update(user.ID, nil, &renamed)
+	require.NoError(t, err)
+	assert.Equal(t, "Alicia", name())
+}
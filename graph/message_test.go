@@ -0,0 +1,55 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/graph/subscriptions"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageAdded_ReplayAndGap(t *testing.T) {
+	r := newTestResolver(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	channel := "room"
+	add := func(text string) *model.Message {
+		msg, err := r.Mutation().AddMessage(ctx, model.NewMessage{Text: text, CreatedBy: "U1", Channel: &channel})
+		require.NoError(t, err)
+		return msg
+	}
+	for _, text := range []string{"a", "b", "c"} {
+		add(text)
+	}
+
+	since := "1"
+	ch, err := r.Subscription().MessageAdded(ctx, channel, &since)
+	require.NoError(t, err)
+	next := func() string {
+		select {
+		case msg := <-ch:
+			return msg.Text
+		case <-time.After(time.Second):
+			t.Fatal("no message received")
+			return ""
+		}
+	}
+
+	// 回放 since 之后的历史消息
+	assert.Equal(t, "b", next())
+	assert.Equal(t, "c", next())
+
+	// 未推送的消息在下一条推送前从历史补齐，重复的消息跳过
+	_, err = r.messages.Append(&model.Message{Channel: channel, Text: "d"})
+	require.NoError(t, err)
+	first := r.messages.After(channel, 0, 1)[0]
+	r.SubscriptionManager.Publish(subscriptions.Event{Topic: subscriptions.TopicMessages, Channel: channel, Payload: first})
+	add("e")
+	assert.Equal(t, "d", next())
+	assert.Equal(t, "e", next())
+}
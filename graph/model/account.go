@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// Account 用户的资金账户，余额由账簿汇总，不保存在账户上
+type Account struct {
+	ID        string    `json:"id"`
+	OwnerID   string    `json:"ownerId"`
+	Currency  string    `json:"currency"`
+	CreatedAt time.Time `json:"createdAt"`
+}
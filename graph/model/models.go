@@ -1,7 +1,85 @@
 package model
 
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
 type Order struct {
-	Id           string `json:"id"`
-	InstrumentId string `json:"instrumentId"`
-	OrderId      string `json:"orderId"`
+	Id             string      `json:"id"`
+	InstrumentId   string      `json:"instrumentId"`
+	AccountId      string      `json:"accountId"`
+	OrderId        string      `json:"orderId"`
+	Status         OrderStatus `json:"status"`
+	Side           OrderSide   `json:"side"`
+	Type           OrderType   `json:"type"`
+	Price          *Money      `json:"price,omitempty"`
+	Quantity       int32       `json:"quantity"`
+	FilledQuantity int32       `json:"filledQuantity"`
+	Version        int32       `json:"version"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	// 以下为大额订单的审批信息
+	SubmittedBy       *string    `json:"submittedBy,omitempty"`
+	ReviewedBy        *string    `json:"reviewedBy,omitempty"`
+	ApprovalExpiresAt *time.Time `json:"approvalExpiresAt,omitempty"`
+	RejectReason      *string    `json:"rejectReason,omitempty"`
+}
+
+// IsOpen 订单是否仍可修改或撤销
+func (o *Order) IsOpen() bool {
+	return o.Status == OrderStatusNew || o.Status == OrderStatusPartiallyFilled
+}
+
+// Fill 记录成交数量并更新状态
+func (o *Order) Fill(quantity int32) {
+	o.FilledQuantity += quantity
+	if o.FilledQuantity >= o.Quantity {
+		o.Status = OrderStatusFilled
+	} else {
+		o.Status = OrderStatusPartiallyFilled
+	}
+}
+
+// Clone 深拷贝订单，修改副本的价格及审批信息不影响原订单
+func (o *Order) Clone() *Order {
+	c := *o
+	if o.Price != nil {
+		price := *o.Price
+		c.Price = &price
+	}
+	c.SubmittedBy = cloneString(o.SubmittedBy)
+	c.ReviewedBy = cloneString(o.ReviewedBy)
+	c.RejectReason = cloneString(o.RejectReason)
+	if o.ApprovalExpiresAt != nil {
+		at := *o.ApprovalExpiresAt
+		c.ApprovalExpiresAt = &at
+	}
+	return &c
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func (Order) IsEntity() {}
+
+func (Order) IsOrderResult() {}
+
+// Position 账户在合约上的净持仓
+type Position struct {
+	AccountID     string           `json:"accountId"`
+	InstrumentID  string           `json:"instrumentId"`
+	Quantity      int32            `json:"quantity"`
+	AverageCost   decimal.Decimal  `json:"averageCost"`
+	RealizedPnl   decimal.Decimal  `json:"realizedPnl"`
+	LastPrice     *decimal.Decimal `json:"lastPrice,omitempty"`
+	UnrealizedPnl *decimal.Decimal `json:"unrealizedPnl,omitempty"`
+	UpdatedAt     time.Time        `json:"updatedAt"`
+	// Cost 持仓成本，即开仓成交金额之和，空头为负；均价四舍五入，盈亏按成本精确计算
+	Cost decimal.Decimal `json:"-"`
 }
@@ -2,19 +2,179 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type OrderResult interface {
+	IsOrderResult()
+}
+
+type TodoResult interface {
+	IsTodoResult()
+}
+
+type AccountBalance struct {
+	Available decimal.Decimal `json:"available"`
+	Reserved  decimal.Decimal `json:"reserved"`
+	Total     decimal.Decimal `json:"total"`
+}
+
+type AmendOrder struct {
+	Price           *decimal.Decimal `json:"price,omitempty"`
+	Quantity        *int32           `json:"quantity,omitempty"`
+	ExpectedVersion int32            `json:"expectedVersion"`
+}
+
+type AuditChange struct {
+	EntityType string  `json:"entityType"`
+	EntityID   string  `json:"entityId"`
+	Before     *string `json:"before,omitempty"`
+	After      *string `json:"after,omitempty"`
+}
+
+type AuditEntry struct {
+	ID        string         `json:"id"`
+	Timestamp time.Time      `json:"timestamp"`
+	Operation string         `json:"operation"`
+	Principal *string        `json:"principal,omitempty"`
+	Variables *string        `json:"variables,omitempty"`
+	Changes   []*AuditChange `json:"changes"`
+	Status    AuditStatus    `json:"status"`
+	Error     *string        `json:"error,omitempty"`
+}
+
+type AuditEntryConnection struct {
+	Edges      []*AuditEntryEdge `json:"edges"`
+	PageInfo   *PageInfo         `json:"pageInfo"`
+	TotalCount int32             `json:"totalCount"`
+}
+
+type AuditEntryEdge struct {
+	Cursor string      `json:"cursor"`
+	Node   *AuditEntry `json:"node"`
+}
+
+type BatchError struct {
+	Index   int32  `json:"index"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (BatchError) IsTodoResult() {}
+
+func (BatchError) IsOrderResult() {}
+
+type Candle struct {
+	InstrumentID string          `json:"instrumentId"`
+	Interval     string          `json:"interval"`
+	Start        time.Time       `json:"start"`
+	End          time.Time       `json:"end"`
+	Open         decimal.Decimal `json:"open"`
+	High         decimal.Decimal `json:"high"`
+	Low          decimal.Decimal `json:"low"`
+	Close        decimal.Decimal `json:"close"`
+	Volume       int64           `json:"volume"`
+	Complete     bool            `json:"complete"`
+}
+
+type Fill struct {
+	ID           string          `json:"id"`
+	OrderID      string          `json:"orderId"`
+	InstrumentID string          `json:"instrumentId"`
+	AccountID    string          `json:"accountId"`
+	Side         OrderSide       `json:"side"`
+	Price        decimal.Decimal `json:"price"`
+	Quantity     int32           `json:"quantity"`
+	Liquidity    Liquidity       `json:"liquidity"`
+	ExecutedAt   time.Time       `json:"executedAt"`
+}
+
+type Instrument struct {
+	ID            string          `json:"id"`
+	Symbol        string          `json:"symbol"`
+	Exchange      string          `json:"exchange"`
+	Product       string          `json:"product"`
+	TickSize      decimal.Decimal `json:"tickSize"`
+	LotSize       int32           `json:"lotSize"`
+	Currency      string          `json:"currency"`
+	TradingStatus TradingStatus   `json:"tradingStatus"`
+}
+
+func (Instrument) IsEntity() {}
+
+type InstrumentByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type InstrumentFilter struct {
+	Symbol        *string        `json:"symbol,omitempty"`
+	Exchange      *string        `json:"exchange,omitempty"`
+	Product       *string        `json:"product,omitempty"`
+	Currency      *string        `json:"currency,omitempty"`
+	TradingStatus *TradingStatus `json:"tradingStatus,omitempty"`
+}
+
+type InstrumentRiskLimit struct {
+	InstrumentID string          `json:"instrumentId"`
+	MaxQuantity  int32           `json:"maxQuantity"`
+	MaxNotional  decimal.Decimal `json:"maxNotional"`
+}
+
+type LedgerEntry struct {
+	ID            string          `json:"id"`
+	TransactionID string          `json:"transactionId"`
+	Kind          LedgerEntryKind `json:"kind"`
+	Book          LedgerBook      `json:"book"`
+	Amount        decimal.Decimal `json:"amount"`
+	Currency      string          `json:"currency"`
+	Reference     string          `json:"reference"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
 type Message struct {
-	ID        string  `json:"id"`
-	Text      string  `json:"text"`
-	CreatedBy string  `json:"createdBy"`
-	Price     float64 `json:"price"`
+	ID        string          `json:"id"`
+	Channel   string          `json:"channel"`
+	Text      string          `json:"text"`
+	CreatedBy string          `json:"createdBy"`
+	Price     decimal.Decimal `json:"price"`
+}
+
+type Money struct {
+	Amount   decimal.Decimal `json:"amount"`
+	Currency string          `json:"currency"`
 }
 
 type Mutation struct {
 }
 
+type NewAccount struct {
+	ID       string `json:"id"`
+	OwnerID  string `json:"ownerId"`
+	Currency string `json:"currency"`
+}
+
 type NewMessage struct {
-	Text      string `json:"text"`
-	CreatedBy string `json:"createdBy"`
+	Text             string           `json:"text"`
+	CreatedBy        string           `json:"createdBy"`
+	Price            *decimal.Decimal `json:"price,omitempty"`
+	Channel          *string          `json:"channel,omitempty"`
+	ClientMutationID *string          `json:"clientMutationId,omitempty"`
+}
+
+type NewOrder struct {
+	InstrumentID     string           `json:"instrumentId"`
+	AccountID        string           `json:"accountId"`
+	Side             OrderSide        `json:"side"`
+	Type             *OrderType       `json:"type,omitempty"`
+	Price            *decimal.Decimal `json:"price,omitempty"`
+	Quantity         int32            `json:"quantity"`
+	ClientMutationID *string          `json:"clientMutationId,omitempty"`
 }
 
 type NewTodo struct {
@@ -22,13 +182,733 @@ type NewTodo struct {
 	UserID string `json:"userId"`
 }
 
+type NewUser struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type OrderBook struct {
+	InstrumentID string            `json:"instrumentId"`
+	Sequence     int32             `json:"sequence"`
+	Bids         []*OrderBookLevel `json:"bids"`
+	Asks         []*OrderBookLevel `json:"asks"`
+	LastPrice    *decimal.Decimal  `json:"lastPrice,omitempty"`
+}
+
+type OrderBookLevel struct {
+	Price    decimal.Decimal `json:"price"`
+	Quantity int32           `json:"quantity"`
+	Orders   int32           `json:"orders"`
+}
+
+type OrderBookUpdate struct {
+	InstrumentID string            `json:"instrumentId"`
+	Sequence     int32             `json:"sequence"`
+	Bids         []*OrderBookLevel `json:"bids"`
+	Asks         []*OrderBookLevel `json:"asks"`
+}
+
+type OrderByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type PageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor,omitempty"`
+}
+
+type PriceAlert struct {
+	ID             string           `json:"id"`
+	InstrumentID   string           `json:"instrumentId"`
+	Condition      AlertCondition   `json:"condition"`
+	Price          decimal.Decimal  `json:"price"`
+	Rearm          bool             `json:"rearm"`
+	Active         bool             `json:"active"`
+	TriggerCount   int32            `json:"triggerCount"`
+	TriggeredPrice *decimal.Decimal `json:"triggeredPrice,omitempty"`
+	TriggeredAt    *time.Time       `json:"triggeredAt,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+}
+
 type Query struct {
 }
 
+type Quote struct {
+	InstrumentID string           `json:"instrumentId"`
+	Bid          *decimal.Decimal `json:"bid,omitempty"`
+	BidSize      *int32           `json:"bidSize,omitempty"`
+	Ask          *decimal.Decimal `json:"ask,omitempty"`
+	AskSize      *int32           `json:"askSize,omitempty"`
+	Last         *decimal.Decimal `json:"last,omitempty"`
+	Timestamp    time.Time        `json:"timestamp"`
+	Source       QuoteSource      `json:"source"`
+}
+
+type RiskLimits struct {
+	MaxQuantity      int32                  `json:"maxQuantity"`
+	MaxNotional      decimal.Decimal        `json:"maxNotional"`
+	Instruments      []*InstrumentRiskLimit `json:"instruments"`
+	DailyNotional    decimal.Decimal        `json:"dailyNotional"`
+	CollarPercent    decimal.Decimal        `json:"collarPercent"`
+	FatFingerPercent decimal.Decimal        `json:"fatFingerPercent"`
+	KilledAccounts   []string               `json:"killedAccounts"`
+}
+
+type RiskLimitsInput struct {
+	MaxQuantity      *int32           `json:"maxQuantity,omitempty"`
+	MaxNotional      *decimal.Decimal `json:"maxNotional,omitempty"`
+	DailyNotional    *decimal.Decimal `json:"dailyNotional,omitempty"`
+	CollarPercent    *decimal.Decimal `json:"collarPercent,omitempty"`
+	FatFingerPercent *decimal.Decimal `json:"fatFingerPercent,omitempty"`
+}
+
+type SettlementRun struct {
+	TradingDay      string            `json:"tradingDay"`
+	Trigger         SettlementTrigger `json:"trigger"`
+	Status          SettlementStatus  `json:"status"`
+	Attempts        int32             `json:"attempts"`
+	StartedAt       time.Time         `json:"startedAt"`
+	FinishedAt      *time.Time        `json:"finishedAt,omitempty"`
+	CancelledOrders int32             `json:"cancelledOrders"`
+	Orders          int32             `json:"orders"`
+	Fills           int32             `json:"fills"`
+	Positions       int32             `json:"positions"`
+	Accounts        int32             `json:"accounts"`
+	ReportDir       *string           `json:"reportDir,omitempty"`
+	Error           *string           `json:"error,omitempty"`
+	SnapshotAt      *time.Time        `json:"snapshotAt,omitempty"`
+}
+
+type SimulatorStatus struct {
+	Running     bool     `json:"running"`
+	Seed        int64    `json:"seed"`
+	Volatility  float64  `json:"volatility"`
+	Instruments []string `json:"instruments"`
+}
+
 type Subscription struct {
 }
 
+type TodoConnection struct {
+	Edges      []*TodoEdge `json:"edges"`
+	PageInfo   *PageInfo   `json:"pageInfo"`
+	TotalCount int32       `json:"totalCount"`
+}
+
+type TodoEdge struct {
+	Cursor string `json:"cursor"`
+	Node   *Todo  `json:"node"`
+}
+
+type TradingLimits struct {
+	AccountID         string     `json:"accountId"`
+	TradingDay        string     `json:"tradingDay"`
+	OrderCount        int32      `json:"orderCount"`
+	MaxTradingCount   int32      `json:"maxTradingCount"`
+	Remaining         int32      `json:"remaining"`
+	TradingIntervalMs int32      `json:"tradingIntervalMs"`
+	LastOrderAt       *time.Time `json:"lastOrderAt,omitempty"`
+	NextOrderAt       *time.Time `json:"nextOrderAt,omitempty"`
+}
+
+type TradingPhase struct {
+	ProductID  string            `json:"productId"`
+	TradingDay *string           `json:"tradingDay,omitempty"`
+	Timezone   string            `json:"timezone"`
+	Sessions   []*TradingSession `json:"sessions"`
+}
+
+type TradingPhaseEvent struct {
+	ProductID string    `json:"productId"`
+	Open      bool      `json:"open"`
+	At        time.Time `json:"at"`
+}
+
+type TradingSession struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type UpdateTodo struct {
+	Text            *string `json:"text,omitempty"`
+	Done            *bool   `json:"done,omitempty"`
+	ExpectedVersion int32   `json:"expectedVersion"`
+}
+
+type UpdateUser struct {
+	Username *string `json:"username,omitempty"`
+	Name     *string `json:"name,omitempty"`
+}
+
 type User struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID       string     `json:"id"`
+	Username string     `json:"username"`
+	Name     string     `json:"name"`
+	Status   UserStatus `json:"status"`
+	// 由身份子图提供
+	Email *string `json:"email,omitempty"`
+	// 显示名称，网关提供 email 时附带邮箱
+	DisplayName string          `json:"displayName"`
+	Todos       *TodoConnection `json:"todos"`
+}
+
+func (User) IsEntity() {}
+
+type UserByIDsInput struct {
+	ID string `json:"ID"`
+}
+
+type AlertCondition string
+
+const (
+	AlertConditionAbove AlertCondition = "ABOVE"
+	AlertConditionBelow AlertCondition = "BELOW"
+)
+
+var AllAlertCondition = []AlertCondition{
+	AlertConditionAbove,
+	AlertConditionBelow,
+}
+
+func (e AlertCondition) IsValid() bool {
+	switch e {
+	case AlertConditionAbove, AlertConditionBelow:
+		return true
+	}
+	return false
+}
+
+func (e AlertCondition) String() string {
+	return string(e)
+}
+
+func (e *AlertCondition) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AlertCondition(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AlertCondition", str)
+	}
+	return nil
+}
+
+func (e AlertCondition) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AuditStatus string
+
+const (
+	AuditStatusOk      AuditStatus = "OK"
+	AuditStatusPartial AuditStatus = "PARTIAL"
+	AuditStatusError   AuditStatus = "ERROR"
+)
+
+var AllAuditStatus = []AuditStatus{
+	AuditStatusOk,
+	AuditStatusPartial,
+	AuditStatusError,
+}
+
+func (e AuditStatus) IsValid() bool {
+	switch e {
+	case AuditStatusOk, AuditStatusPartial, AuditStatusError:
+		return true
+	}
+	return false
+}
+
+func (e AuditStatus) String() string {
+	return string(e)
+}
+
+func (e *AuditStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditStatus", str)
+	}
+	return nil
+}
+
+func (e AuditStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LedgerBook string
+
+const (
+	LedgerBookCash     LedgerBook = "CASH"
+	LedgerBookReserved LedgerBook = "RESERVED"
+)
+
+var AllLedgerBook = []LedgerBook{
+	LedgerBookCash,
+	LedgerBookReserved,
+}
+
+func (e LedgerBook) IsValid() bool {
+	switch e {
+	case LedgerBookCash, LedgerBookReserved:
+		return true
+	}
+	return false
+}
+
+func (e LedgerBook) String() string {
+	return string(e)
+}
+
+func (e *LedgerBook) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LedgerBook(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LedgerBook", str)
+	}
+	return nil
+}
+
+func (e LedgerBook) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type LedgerEntryKind string
+
+const (
+	LedgerEntryKindDeposit    LedgerEntryKind = "DEPOSIT"
+	LedgerEntryKindWithdrawal LedgerEntryKind = "WITHDRAWAL"
+	LedgerEntryKindReserve    LedgerEntryKind = "RESERVE"
+	LedgerEntryKindRelease    LedgerEntryKind = "RELEASE"
+	LedgerEntryKindTrade      LedgerEntryKind = "TRADE"
+	LedgerEntryKindFee        LedgerEntryKind = "FEE"
+)
+
+var AllLedgerEntryKind = []LedgerEntryKind{
+	LedgerEntryKindDeposit,
+	LedgerEntryKindWithdrawal,
+	LedgerEntryKindReserve,
+	LedgerEntryKindRelease,
+	LedgerEntryKindTrade,
+	LedgerEntryKindFee,
+}
+
+func (e LedgerEntryKind) IsValid() bool {
+	switch e {
+	case LedgerEntryKindDeposit, LedgerEntryKindWithdrawal, LedgerEntryKindReserve, LedgerEntryKindRelease, LedgerEntryKindTrade, LedgerEntryKindFee:
+		return true
+	}
+	return false
+}
+
+func (e LedgerEntryKind) String() string {
+	return string(e)
+}
+
+func (e *LedgerEntryKind) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = LedgerEntryKind(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid LedgerEntryKind", str)
+	}
+	return nil
+}
+
+func (e LedgerEntryKind) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type Liquidity string
+
+const (
+	LiquidityMaker Liquidity = "MAKER"
+	LiquidityTaker Liquidity = "TAKER"
+)
+
+var AllLiquidity = []Liquidity{
+	LiquidityMaker,
+	LiquidityTaker,
+}
+
+func (e Liquidity) IsValid() bool {
+	switch e {
+	case LiquidityMaker, LiquidityTaker:
+		return true
+	}
+	return false
+}
+
+func (e Liquidity) String() string {
+	return string(e)
+}
+
+func (e *Liquidity) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = Liquidity(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid Liquidity", str)
+	}
+	return nil
+}
+
+func (e Liquidity) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderSide string
+
+const (
+	OrderSideBuy  OrderSide = "BUY"
+	OrderSideSell OrderSide = "SELL"
+)
+
+var AllOrderSide = []OrderSide{
+	OrderSideBuy,
+	OrderSideSell,
+}
+
+func (e OrderSide) IsValid() bool {
+	switch e {
+	case OrderSideBuy, OrderSideSell:
+		return true
+	}
+	return false
+}
+
+func (e OrderSide) String() string {
+	return string(e)
+}
+
+func (e *OrderSide) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderSide(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderSide", str)
+	}
+	return nil
+}
+
+func (e OrderSide) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderStatus string
+
+const (
+	OrderStatusNew             OrderStatus = "NEW"
+	OrderStatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED"
+	OrderStatusFilled          OrderStatus = "FILLED"
+	OrderStatusCancelled       OrderStatus = "CANCELLED"
+	OrderStatusRejected        OrderStatus = "REJECTED"
+	OrderStatusPendingApproval OrderStatus = "PENDING_APPROVAL"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusNew,
+	OrderStatusPartiallyFilled,
+	OrderStatusFilled,
+	OrderStatusCancelled,
+	OrderStatusRejected,
+	OrderStatusPendingApproval,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusNew, OrderStatusPartiallyFilled, OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected, OrderStatusPendingApproval:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrderType string
+
+const (
+	OrderTypeLimit  OrderType = "LIMIT"
+	OrderTypeMarket OrderType = "MARKET"
+)
+
+var AllOrderType = []OrderType{
+	OrderTypeLimit,
+	OrderTypeMarket,
+}
+
+func (e OrderType) IsValid() bool {
+	switch e {
+	case OrderTypeLimit, OrderTypeMarket:
+		return true
+	}
+	return false
+}
+
+func (e OrderType) String() string {
+	return string(e)
+}
+
+func (e *OrderType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderType", str)
+	}
+	return nil
+}
+
+func (e OrderType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type QuoteSource string
+
+const (
+	QuoteSourceBook      QuoteSource = "BOOK"
+	QuoteSourceSimulated QuoteSource = "SIMULATED"
+)
+
+var AllQuoteSource = []QuoteSource{
+	QuoteSourceBook,
+	QuoteSourceSimulated,
+}
+
+func (e QuoteSource) IsValid() bool {
+	switch e {
+	case QuoteSourceBook, QuoteSourceSimulated:
+		return true
+	}
+	return false
+}
+
+func (e QuoteSource) String() string {
+	return string(e)
+}
+
+func (e *QuoteSource) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = QuoteSource(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid QuoteSource", str)
+	}
+	return nil
+}
+
+func (e QuoteSource) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettlementStatus string
+
+const (
+	SettlementStatusRunning   SettlementStatus = "RUNNING"
+	SettlementStatusSucceeded SettlementStatus = "SUCCEEDED"
+	SettlementStatusFailed    SettlementStatus = "FAILED"
+)
+
+var AllSettlementStatus = []SettlementStatus{
+	SettlementStatusRunning,
+	SettlementStatusSucceeded,
+	SettlementStatusFailed,
+}
+
+func (e SettlementStatus) IsValid() bool {
+	switch e {
+	case SettlementStatusRunning, SettlementStatusSucceeded, SettlementStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e SettlementStatus) String() string {
+	return string(e)
+}
+
+func (e *SettlementStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettlementStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettlementStatus", str)
+	}
+	return nil
+}
+
+func (e SettlementStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SettlementTrigger string
+
+const (
+	SettlementTriggerScheduled SettlementTrigger = "SCHEDULED"
+	SettlementTriggerManual    SettlementTrigger = "MANUAL"
+)
+
+var AllSettlementTrigger = []SettlementTrigger{
+	SettlementTriggerScheduled,
+	SettlementTriggerManual,
+}
+
+func (e SettlementTrigger) IsValid() bool {
+	switch e {
+	case SettlementTriggerScheduled, SettlementTriggerManual:
+		return true
+	}
+	return false
+}
+
+func (e SettlementTrigger) String() string {
+	return string(e)
+}
+
+func (e *SettlementTrigger) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SettlementTrigger(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SettlementTrigger", str)
+	}
+	return nil
+}
+
+func (e SettlementTrigger) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type TradingStatus string
+
+const (
+	TradingStatusTrading   TradingStatus = "TRADING"
+	TradingStatusHalted    TradingStatus = "HALTED"
+	TradingStatusSuspended TradingStatus = "SUSPENDED"
+)
+
+var AllTradingStatus = []TradingStatus{
+	TradingStatusTrading,
+	TradingStatusHalted,
+	TradingStatusSuspended,
+}
+
+func (e TradingStatus) IsValid() bool {
+	switch e {
+	case TradingStatusTrading, TradingStatusHalted, TradingStatusSuspended:
+		return true
+	}
+	return false
+}
+
+func (e TradingStatus) String() string {
+	return string(e)
+}
+
+func (e *TradingStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = TradingStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid TradingStatus", str)
+	}
+	return nil
+}
+
+func (e TradingStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type UserStatus string
+
+const (
+	UserStatusActive      UserStatus = "ACTIVE"
+	UserStatusDeactivated UserStatus = "DEACTIVATED"
+)
+
+var AllUserStatus = []UserStatus{
+	UserStatusActive,
+	UserStatusDeactivated,
+}
+
+func (e UserStatus) IsValid() bool {
+	switch e {
+	case UserStatusActive, UserStatusDeactivated:
+		return true
+	}
+	return false
+}
+
+func (e UserStatus) String() string {
+	return string(e)
+}
+
+func (e *UserStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UserStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UserStatus", str)
+	}
+	return nil
+}
+
+func (e UserStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
@@ -1,9 +1,12 @@
 package model
 
 type Todo struct {
-	ID     string `json:"id"`
-	Text   string `json:"text"`
-	Done   bool   `json:"done"`
-	UserID string `json:"userId"`
-	User   *User  `json:"user"`
+	ID      string `json:"id"`
+	Text    string `json:"text"`
+	Done    bool   `json:"done"`
+	UserID  string `json:"userId"`
+	User    *User  `json:"user"`
+	Version int32  `json:"version"`
 }
+
+func (Todo) IsTodoResult() {}
@@ -0,0 +1,114 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderUpdated_Filter(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx, cancel := context.WithCancel(newTestAccount(t, r, "A1", "A2"))
+	defer cancel()
+
+	account := "A1"
+	updates, err := r.Subscription().OrderUpdated(ctx, nil, &account)
+	require.NoError(t, err)
+
+	_, err = r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A2"))
+	require.NoError(t, err)
+	placed, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600519.SH", "A1"))
+	require.NoError(t, err)
+
+	// 只推送订阅账户的订单
+	select {
+	case order := <-updates:
+		assert.Equal(t, placed.Id, order.Id)
+		assert.Equal(t, "A1", order.AccountId)
+	case <-time.After(time.Second):
+		t.Fatal("no order update received")
+	}
+	assert.Empty(t, updates)
+}
+
+func TestPlaceOrder_TradingClosed(t *testing.T) {
+	r := newTestResolver(t)
+	// 午间休市
+	r.now = func() time.Time { return tradingTime().Add(2 * time.Hour) }
+	ctx := newTestAccount(t, r, "A1")
+
+	_, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A1"))
+	assert.Equal(t, CodeTradingClosed, errcode.Code(err))
+	assert.ErrorIs(t, err, errTradingClosed)
+	assert.Empty(t, r.orders.List())
+
+	// 休市时拒绝的订单不占用下单额度
+	limit, err := r.Query().TradingLimits(ctx, "A1")
+	require.NoError(t, err)
+	assert.Zero(t, limit.OrderCount)
+}
+
+func TestOrder_AccountAccess(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx := newTestAccount(t, r, "A1")
+	other := newTestAccount(t, r, "A2")
+
+	// 不能为他人或未开户的账户下单
+	_, err := r.Mutation().PlaceOrder(other, *newOrderInput("600000.SH", "A1"))
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "X1"))
+	assert.ErrorIs(t, err, store.ErrAccountNotFound)
+	_, err = r.Mutation().PlaceOrder(context.Background(), *newOrderInput("600000.SH", "A1"))
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+
+	// 仅账户所有者或管理员可改单及撤单
+	order, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A1"))
+	require.NoError(t, err)
+	qty := int32(200)
+	_, err = r.Mutation().AmendOrder(other, order.Id, model.AmendOrder{Quantity: &qty, ExpectedVersion: order.Version})
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().CancelOrder(other, order.Id, order.Version)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().CancelOrder(middware.WithUserID(context.Background(), "admin"), order.Id, order.Version)
+	require.NoError(t, err)
+
+	// 仅账户所有者或管理员可查看及订阅订单
+	_, err = r.Query().Order(other, order.Id)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Query().Order(context.Background(), order.Id)
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+	found, err := r.Query().Order(ctx, order.Id)
+	require.NoError(t, err)
+	assert.Equal(t, order.Id, found.Id)
+	orders, err := r.Query().Orders(other)
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+	orders, err = r.Query().Orders(ctx)
+	require.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	account := "A1"
+	_, err = r.Subscription().OrderUpdated(other, nil, &account)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Subscription().OrderUpdated(ctx, nil, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	// 仅账户所有者或管理员可查看下单限额
+	_, err = r.Query().TradingLimits(other, "A1")
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Query().TradingLimits(context.Background(), "A1")
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+	limit, err := r.Query().TradingLimits(middware.WithUserID(context.Background(), "admin"), "A1")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), limit.OrderCount)
+}
@@ -0,0 +1,233 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/graph/scalar"
+	"gqlexample/graph/store"
+	"gqlexample/graph/subscriptions"
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/matching"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+const defaultBookDepth = 10
+
+var (
+	errLimitPriceRequired = errors.New("limit order requires a price")
+	errMarketOrderPrice   = errors.New("market order must not specify a price")
+	errQuantityFilled     = errors.New("quantity must exceed filled quantity")
+)
+
+// orderType 下单类型，未指定时为限价单
+func orderType(input model.NewOrder) model.OrderType {
+	if input.Type == nil {
+		return model.OrderTypeLimit
+	}
+	return *input.Type
+}
+
+// checkNewOrder 按订单类型校验下单参数
+func (r *Resolver) checkNewOrder(input model.NewOrder) (*model.Instrument, error) {
+	if orderType(input) == model.OrderTypeMarket {
+		if input.Price != nil {
+			return nil, fmt.Errorf("%w: %s", errMarketOrderPrice, input.InstrumentID)
+		}
+		return r.InstrumentCatalog.CheckMarketOrder(input.InstrumentID, input.Quantity)
+	}
+	if input.Price == nil {
+		return nil, fmt.Errorf("%w: %s", errLimitPriceRequired, input.InstrumentID)
+	}
+	return r.InstrumentCatalog.CheckOrder(input.InstrumentID, *input.Price, input.Quantity)
+}
+
+// matchingOrder 将订单剩余部分转换为撮合订单
+func matchingOrder(o *model.Order) matching.Order {
+	mo := matching.Order{
+		ID:       o.Id,
+		Side:     matching.Buy,
+		Market:   o.Type == model.OrderTypeMarket,
+		Quantity: o.Quantity - o.FilledQuantity,
+	}
+	if o.Side == model.OrderSideSell {
+		mo.Side = matching.Sell
+	}
+	if o.Price != nil {
+		mo.Price = o.Price.Amount
+	}
+	return mo
+}
+
+// bookChange 一次撮合涉及的订单及持仓变化，在订单簿锁外推送；订单簿增量在锁内推送
+type bookChange struct {
+	instrumentID string
+	orders       []*model.Order
+	positions    []*model.Position
+	result       matching.Result
+}
+
+// withBook 在订单簿锁内执行 fn，订单簿有变化时在锁内发布增量及行情并更新 K 线，保证顺序与订单簿一致
+func (r *Resolver) withBook(change *bookChange, fn func(*matching.Book) error) error {
+	return r.orderBooks.Do(change.instrumentID, func(b *matching.Book) error {
+		if err := fn(b); err != nil {
+			return err
+		}
+		if len(change.result.Changes) > 0 {
+			r.publishBookUpdate(change)
+			r.publishQuote(bookQuote(change.instrumentID, b, r.now()))
+		}
+		for _, f := range change.result.Fills {
+			r.candles.Add(change.instrumentID, f.Price, int64(f.Quantity), r.now())
+		}
+		return nil
+	})
+}
+
+// bookQuote 由订单簿买卖一档及最新成交价生成行情
+func bookQuote(instrumentID string, b *matching.Book, at time.Time) *model.Quote {
+	q := &model.Quote{InstrumentID: instrumentID, Timestamp: at, Source: model.QuoteSourceBook}
+	bids, asks := b.Depth(1)
+	if len(bids) > 0 {
+		size := int32(bids[0].Quantity)
+		q.Bid, q.BidSize = &bids[0].Price, &size
+	}
+	if len(asks) > 0 {
+		size := int32(asks[0].Quantity)
+		q.Ask, q.AskSize = &asks[0].Price, &size
+	}
+	if last, ok := b.LastPrice(); ok {
+		q.Last = &last
+	}
+	return q
+}
+
+// applyFills 按成交结果更新对手方订单，记录双方成交并更新持仓，返回主动方的成交数量
+// 资金已在撮合前由 settleFills 结算，需在订单簿锁内调用，保证订单状态与订单簿一致
+func (r *Resolver) applyFills(ctx context.Context, change *bookChange, taker *model.Order) int32 {
+	var filled int32
+	at := r.now()
+	for _, f := range change.result.Fills {
+		filled += f.Quantity
+
+		var before model.Order
+		maker, err := r.orders.Update(f.MakerID, store.AnyVersion, func(o *model.Order) error {
+			before = *o.Clone()
+			o.Fill(f.Quantity)
+			return nil
+		})
+		if err != nil {
+			zap.L().Error("Failed to apply fill to resting order", zap.String("order", f.MakerID), zap.Error(err))
+			continue
+		}
+		audit.Record(ctx, "Order", maker.Id, &before, maker)
+		change.orders = append(change.orders, maker)
+
+		r.recordFill(change, maker, f, model.LiquidityMaker, at)
+		r.recordFill(change, taker, f, model.LiquidityTaker, at)
+		r.releaseHold(maker)
+	}
+	return filled
+}
+
+// recordFill 保存订单一方的成交并更新账户持仓
+func (r *Resolver) recordFill(change *bookChange, order *model.Order, f matching.Fill, liquidity model.Liquidity, at time.Time) {
+	fill := r.fills.Add(&model.Fill{
+		OrderID:      order.Id,
+		InstrumentID: order.InstrumentId,
+		AccountID:    order.AccountId,
+		Side:         order.Side,
+		Price:        f.Price,
+		Quantity:     f.Quantity,
+		Liquidity:    liquidity,
+		ExecutedAt:   at,
+	})
+	change.positions = append(change.positions, r.positions.Apply(fill))
+}
+
+// fillTaker 更新主动方订单的成交数量，市价单未成交部分撤销
+func (r *Resolver) fillTaker(order *model.Order, filled int32, result matching.Result) *model.Order {
+	updated, err := r.orders.Update(order.Id, store.AnyVersion, func(o *model.Order) error {
+		if filled > 0 {
+			o.Fill(filled)
+		}
+		if result.Remaining > 0 {
+			o.Status = model.OrderStatusCancelled
+		}
+		return nil
+	})
+	if err != nil {
+		zap.L().Error("Failed to apply fill to order", zap.String("order", order.Id), zap.Error(err))
+		return order
+	}
+	return updated
+}
+
+// publishBookChange 推送撮合涉及的订单及持仓
+func (r *Resolver) publishBookChange(change *bookChange) {
+	for _, o := range change.orders {
+		r.publishOrder(o)
+	}
+	for _, p := range change.positions {
+		p = r.markPosition(p)
+		r.SubscriptionManager.PublishFiltered(subscriptions.TopicPositions, p, p.AccountID, p.InstrumentID)
+	}
+	if len(change.result.Fills) > 0 {
+		zap.L().Info("Orders matched", zap.String("instrument", change.instrumentID), zap.Int("fills", len(change.result.Fills)))
+	}
+}
+
+// publishBookUpdate 推送订单簿增量，需在订单簿锁内调用，保证增量按序号顺序发布。
+// 订单簿仅在有价位变化时递增序号，每个增量的序号连续，分发队列满时丢弃的增量可由序号缺口发现
+func (r *Resolver) publishBookUpdate(change *bookChange) {
+	update := &model.OrderBookUpdate{
+		InstrumentID: change.instrumentID,
+		Sequence:     int32(change.result.Sequence),
+		Bids:         []*model.OrderBookLevel{},
+		Asks:         []*model.OrderBookLevel{},
+	}
+	for _, l := range change.result.Changes {
+		if l.Side == matching.Buy {
+			update.Bids = append(update.Bids, orderBookLevel(l))
+		} else {
+			update.Asks = append(update.Asks, orderBookLevel(l))
+		}
+	}
+	r.SubscriptionManager.Publish(subscriptions.Event{
+		Topic:   subscriptions.TopicOrderBook,
+		Channel: change.instrumentID,
+		Payload: update,
+	})
+}
+
+// markPosition 按最新行情的成交价计算浮动盈亏，返回副本
+func (r *Resolver) markPosition(p *model.Position) *model.Position {
+	marked := *p
+	if quote, ok := r.QuoteHub.Latest(p.InstrumentID); ok && quote.Last != nil {
+		last := *quote.Last
+		pnl := scalar.PrecisionFor(p.InstrumentID).Round(last.Mul(decimal.NewFromInt32(p.Quantity)).Sub(p.Cost))
+		marked.LastPrice = &last
+		marked.UnrealizedPnl = &pnl
+	}
+	return &marked
+}
+
+func orderBookLevel(l matching.Level) *model.OrderBookLevel {
+	return &model.OrderBookLevel{
+		Price:    l.Price,
+		Quantity: int32(l.Quantity),
+		Orders:   int32(l.Orders),
+	}
+}
+
+func orderBookLevels(levels []matching.Level) []*model.OrderBookLevel {
+	result := make([]*model.OrderBookLevel, 0, len(levels))
+	for _, l := range levels {
+		result = append(result, orderBookLevel(l))
+	}
+	return result
+}
@@ -0,0 +1,229 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gqlexample/graph/instrument"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceOrder_Matching(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx := context.Background()
+	owners := make(map[string]context.Context)
+	for _, id := range []string{"M1", "M2", "M3", "M4", "M5", "T1", "T2"} {
+		owners[id] = newTestAccount(t, r, id)
+	}
+	m1, t1, t2 := owners["M1"], owners["T1"], owners["T2"]
+	place := func(accountID string, side model.OrderSide, price string, qty int32) *model.Order {
+		input := newOrderInput("600000.SH", accountID)
+		input.Side = side
+		input.Quantity = qty
+		if price == "" {
+			market := model.OrderTypeMarket
+			input.Type, input.Price = &market, nil
+		} else {
+			p := decimal.RequireFromString(price)
+			input.Price = &p
+		}
+		order, err := r.Mutation().PlaceOrder(owners[accountID], *input)
+		require.NoError(t, err)
+		return order
+	}
+
+	ask1 := place("M1", model.OrderSideSell, "10.01", 200)
+	ask2 := place("M2", model.OrderSideSell, "10.02", 100)
+	bid := place("M3", model.OrderSideBuy, "9.99", 300)
+
+	book, err := r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	require.Len(t, book.Asks, 2)
+	require.Len(t, book.Bids, 1)
+	assert.Equal(t, "10.01", book.Asks[0].Price.String())
+	assert.Equal(t, int32(300), book.Bids[0].Quantity)
+	assert.Nil(t, book.LastPrice)
+
+	// 限价买单吃掉 10.01 价位并部分成交 10.02
+	taker := place("T1", model.OrderSideBuy, "10.02", 300)
+	assert.Equal(t, model.OrderStatusFilled, taker.Status)
+	assert.Equal(t, int32(300), taker.FilledQuantity)
+	maker, _ := r.orders.Get(ask1.Id)
+	assert.Equal(t, model.OrderStatusFilled, maker.Status)
+	maker, _ = r.orders.Get(ask2.Id)
+	assert.Equal(t, model.OrderStatusFilled, maker.Status)
+
+	// 双方均记录成交，按对手方挂单价格成交
+	fills, err := r.Query().Fills(t1, taker.Id)
+	require.NoError(t, err)
+	require.Len(t, fills, 2)
+	assert.Equal(t, model.LiquidityTaker, fills[0].Liquidity)
+	assert.Equal(t, "10.01", fills[0].Price.String())
+	fills, err = r.Query().Fills(m1, ask1.Id)
+	require.NoError(t, err)
+	require.Len(t, fills, 1)
+	assert.Equal(t, model.LiquidityMaker, fills[0].Liquidity)
+
+	// 仅账户所有者可查看成交及持仓
+	_, err = r.Query().Fills(t1, ask1.Id)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Query().Positions(m1, "T1")
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	// 市价卖单剩余部分撤销
+	market := place("T2", model.OrderSideSell, "", 400)
+	assert.Equal(t, model.OrderStatusCancelled, market.Status)
+	assert.Equal(t, int32(300), market.FilledQuantity)
+	assert.Nil(t, market.Price)
+
+	book, err = r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	assert.Empty(t, book.Bids)
+	assert.Empty(t, book.Asks)
+	assert.Equal(t, "9.99", book.LastPrice.String())
+	maker, _ = r.orders.Get(bid.Id)
+	assert.Equal(t, model.OrderStatusFilled, maker.Status)
+
+	quote, err := r.Query().Quote(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	assert.Nil(t, quote.Bid)
+	assert.Nil(t, quote.Ask)
+	assert.Equal(t, "9.99", quote.Last.String())
+
+	// 持仓均价 (10.01*200 + 10.02*100) / 300，按最新成交价 9.99 计算浮动盈亏
+	positions, err := r.Query().Positions(t1, "T1")
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, int32(300), positions[0].Quantity)
+	assert.Equal(t, "10.01333333", positions[0].AverageCost.String())
+	assert.Equal(t, "-7", positions[0].UnrealizedPnl.String())
+	positions, _ = r.Query().Positions(t2, "T2")
+	assert.Equal(t, int32(-300), positions[0].Quantity)
+
+	// 撤单后价位移除
+	rest := place("M4", model.OrderSideBuy, "9.98", 100)
+	qty := int32(200)
+	amended, err := r.Mutation().AmendOrder(owners["M4"], rest.Id, model.AmendOrder{Quantity: &qty, ExpectedVersion: rest.Version})
+	require.NoError(t, err)
+	book, _ = r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.Len(t, book.Bids, 1)
+	assert.Equal(t, int32(200), book.Bids[0].Quantity)
+
+	_, err = r.Mutation().CancelOrder(owners["M4"], amended.Id, amended.Version)
+	require.NoError(t, err)
+	book, _ = r.Query().OrderBook(ctx, "600000.SH", nil)
+	assert.Empty(t, book.Bids)
+
+	// 限价单必须指定价格
+	input := newOrderInput("600000.SH", "M5")
+	input.Price = nil
+	_, err = r.Mutation().PlaceOrder(owners["M5"], *input)
+	assert.ErrorIs(t, err, errLimitPriceRequired)
+}
+
+func TestQuotes_Subscription(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	place := func(account string, qty int32) {
+		input := newOrderInput("600000.SH", account)
+		input.Quantity = qty
+		_, err := r.Mutation().PlaceOrder(newTestAccount(t, r, account), *input)
+		require.NoError(t, err)
+	}
+	place("Q1", 100)
+	place("Q2", 200)
+
+	// 订阅时先推送最新行情
+	quotes, err := r.Subscription().Quotes(ctx, []string{"600000.SH"}, nil)
+	require.NoError(t, err)
+	quote := <-quotes
+	assert.Equal(t, "10", quote.Bid.String())
+	assert.Equal(t, int32(300), *quote.BidSize)
+	assert.Nil(t, quote.Ask)
+
+	place("Q3", 300)
+	quote = <-quotes
+	assert.Equal(t, int32(600), *quote.BidSize)
+
+	_, err = r.Subscription().Quotes(ctx, []string{"404.SH"}, nil)
+	assert.ErrorIs(t, err, instrument.ErrUnknownInstrument)
+}
+
+func TestOrderBookUpdated_Sequence(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	snapshot, err := r.Query().OrderBook(ctx, "600000.SH", nil)
+	require.NoError(t, err)
+	updates, err := r.Subscription().OrderBookUpdated(ctx, "600000.SH")
+	require.NoError(t, err)
+
+	// 并发下单时增量按订单簿序号顺序推送，序号逐个递增，客户端据此发现丢失的增量
+	const n = 20
+	owners := make([]context.Context, n)
+	for i := range owners {
+		owners[i] = newTestAccount(t, r, fmt.Sprintf("S%d", i))
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := newOrderInput("600000.SH", fmt.Sprintf("S%d", i))
+			price := decimal.NewFromInt(int64(10 + i%5))
+			input.Price = &price
+			_, err := r.Mutation().PlaceOrder(owners[i], *input)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	last := snapshot.Sequence
+	for i := 0; i < n; i++ {
+		select {
+		case update := <-updates:
+			assert.Equal(t, last+1, update.Sequence)
+			last = update.Sequence
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of %d updates", i, n)
+		}
+	}
+}
+
+func TestPositionChanged_Access(t *testing.T) {
+	r := newTestResolver(t)
+	ctx, cancel := context.WithCancel(newTestAccount(t, r, "P1"))
+	defer cancel()
+	other := newTestAccount(t, r, "P2")
+	account := "P1"
+
+	_, err := r.Subscription().PositionChanged(ctx, &account, nil)
+	require.NoError(t, err)
+	_, err = r.Subscription().PositionChanged(other, &account, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Subscription().PositionChanged(context.Background(), &account, nil)
+	assert.Equal(t, CodeUnauthenticated, errcode.Code(err))
+
+	// 订阅全部账户需管理员权限
+	_, err = r.Subscription().PositionChanged(ctx, nil, nil)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	admin, cancelAdmin := context.WithCancel(middware.WithUserID(context.Background(), "admin"))
+	defer cancelAdmin()
+	_, err = r.Subscription().PositionChanged(admin, nil, nil)
+	require.NoError(t, err)
+}
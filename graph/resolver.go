@@ -2,21 +2,95 @@ package graph
 
 //go:generate go run github.com/99designs/gqlgen generate
 import (
+	"context"
+	"errors"
+	"fmt"
+	"gqlexample/graph/instrument"
+	"gqlexample/graph/loaders"
 	"gqlexample/graph/model"
+	"gqlexample/graph/scalar"
+	"gqlexample/graph/store"
 	"gqlexample/graph/subscriptions"
+	"gqlexample/pkg/alert"
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/calendar"
+	"gqlexample/pkg/candle"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/conflate"
+	"gqlexample/pkg/dataloader"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/event"
+	"gqlexample/pkg/idempotency"
+	"gqlexample/pkg/ledger"
+	"gqlexample/pkg/limits"
+	"gqlexample/pkg/matching"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/risk"
+	"gqlexample/pkg/settlement"
+	"gqlexample/pkg/simulator"
+	"gqlexample/pkg/task"
+	"gqlexample/pkg/utils"
+	"path/filepath"
+	"slices"
+	"sync"
 	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/shopspring/decimal"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
 )
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
+const CodeTradingClosed = "TRADING_CLOSED"
+
+var (
+	errCalendarUnavailable = errors.New("trading calendar is not available")
+	errTradingClosed       = errors.New("trading phase is closed")
+)
+
 type Resolver struct {
-	todos               []*model.Todo
+	cfg                 *config.Config
+	todos               *store.TodoStore
+	users               *store.UserStore
+	orders              *store.OrderStore
+	orderBooks          *matching.Engine
+	fills               *store.FillStore
+	positions           *store.PositionStore
+	QuoteHub            *conflate.Hub[*model.Quote]
+	Simulator           *simulator.Simulator
+	stopSimulatorFeed   func()
+	candles             *candle.Aggregator
+	alerts              *alert.Book
+	candleHub           *conflate.Hub[*model.Candle]
+	messages            *store.MessageStore
 	SubscriptionManager *subscriptions.Manager
+	InstrumentCatalog   *instrument.Catalog
+	calendarMu          sync.RWMutex // 保护交易日历、时段调度和下单限额，SIGHUP 时重新加载
+	TradingCalendar     *calendar.Calendar
+	phaseScheduler      *calendar.Scheduler
+	tradingLimits       *limits.Limiter
+	risk                *risk.Engine
+	accounts            *store.AccountStore
+	ledger              *ledger.Ledger
+	holds               *holdBook[hold]
+	notionals           *holdBook[notionalHold]
+	feeRate             decimal.Decimal
+	approvalThreshold   decimal.Decimal
+	approvalExpiry      time.Duration
+	approvalTasks       *task.TaskManager
+	settlements         *settlement.Registry
+	Audit               *audit.Log
+	Idempotency         *idempotency.Store
+	now                 func() time.Time
 }
 
-func NewResolver() *Resolver {
+// NewResolver 按配置创建解析器，数据文件的相对路径按配置目录解析
+func NewResolver(cfg *config.Config) *Resolver {
 	// 初始化订阅管理器，设置10秒超时
 	mgr := subscriptions.NewManager(10 * time.Second)
 
@@ -24,7 +98,471 @@ func NewResolver() *Resolver {
 	// mgr.AddMiddleware(&subscriptions.AuthMiddleware{})
 	// mgr.AddMiddleware(&subscriptions.LoggingMiddleware{})
 
-	return &Resolver{
+	if err := scalar.Load(cfg.Decimal); err != nil {
+		zap.L().Warn("Invalid decimal rounding, using default", zap.Error(err))
+	}
+
+	// 启动时导入合约目录并定时刷新
+	catalog := instrument.NewCatalog(config.ResolvePath(cfg.Instrument.CatalogPath))
+	if err := catalog.Load(); err != nil {
+		zap.L().Error("Failed to load instrument catalog", zap.Error(err))
+	}
+	catalog.StartRefresh(cfg.Instrument.RefreshInterval)
+
+	messages, err := store.NewMessageStore(config.ResolvePath(cfg.Message.HistoryDir))
+	if err != nil {
+		zap.L().Error("Failed to load message history, keeping messages in memory", zap.Error(err))
+		messages, _ = store.NewMessageStore("")
+	}
+
+	todos, orders, err := openStores(cfg.Store)
+	if err != nil {
+		zap.L().Error("Failed to load todos and orders, keeping them in memory", zap.Error(err))
+		todos, orders = store.NewTodoStore(), store.NewOrderStore()
+	}
+
+	auditLog, err := audit.Open(config.ResolvePath(cfg.Audit.Path), cfg.Audit.RedactFields)
+	if err != nil {
+		zap.L().Error("Failed to open audit log, keeping audit entries in memory", zap.Error(err))
+		auditLog, _ = audit.Open("", cfg.Audit.RedactFields)
+	}
+
+	r := &Resolver{
+		cfg:                 cfg,
+		todos:               todos,
+		users:               store.NewUserStore(),
+		orders:              orders,
+		orderBooks:          matching.NewEngine(),
+		fills:               store.NewFillStore(),
+		positions:           store.NewPositionStore(),
+		QuoteHub:            conflate.NewHub[*model.Quote](),
+		candleHub:           conflate.NewHub[*model.Candle](),
+		alerts:              alert.NewBook(),
+		risk:                newRiskEngine(cfg.Risk),
+		accounts:            store.NewAccountStore(),
+		ledger:              ledger.New(),
+		holds:               newHoldBook[hold](),
+		notionals:           newHoldBook[notionalHold](),
+		feeRate:             decimal.NewFromFloat(cfg.Account.FeeRate),
+		settlements:         newSettlements(cfg.Settlement),
+		messages:            messages,
 		SubscriptionManager: mgr,
+		InstrumentCatalog:   catalog,
+		Audit:               auditLog,
+		Idempotency:         idempotency.New(idempotencyTTL(cfg.Idempotency)),
+		now:                 time.Now,
+	}
+	r.candles = r.newCandles(cfg.Candle)
+	r.initApproval(cfg.Approval)
+
+	// 交易日历加载失败时不可查询交易时段，也不接受下单
+	if err := r.ReloadTradingCalendar(); err != nil {
+		zap.L().Error("Failed to load trading calendar", zap.Error(err))
+	}
+
+	if cfg.Simulator.Enabled {
+		if err := r.initSimulator(cfg.Simulator, event.Eb); err != nil {
+			zap.L().Error("Failed to create market simulator", zap.Error(err))
+		} else {
+			r.Simulator.Start()
+		}
+	}
+	return r
+}
+
+// openStores 打开待办及订单存储，dir 为空时仅保存在内存
+func openStores(cfg config.StoreConfig) (*store.TodoStore, *store.OrderStore, error) {
+	if cfg.Dir == "" {
+		return store.NewTodoStore(), store.NewOrderStore(), nil
+	}
+	dir := config.ResolvePath(cfg.Dir)
+	todos, err := store.OpenTodoStore(filepath.Join(dir, "todos.jsonl"))
+	if err != nil {
+		return nil, nil, err
+	}
+	orders, err := store.OpenOrderStore(filepath.Join(dir, "orders.jsonl"))
+	if err != nil {
+		return nil, nil, err
+	}
+	return todos, orders, nil
+}
+
+// ReloadTradingCalendar 重新读取交易配置，更新下单限额并重新调度时段切换事件
+func (r *Resolver) ReloadTradingCalendar() error {
+	cfg, err := config.LoadMidServerConfig(config.ResolvePath(r.cfg.MidServerConfigPath))
+	if err != nil {
+		return err
+	}
+
+	r.calendarMu.Lock()
+	defer r.calendarMu.Unlock()
+	interval := time.Duration(cfg.Obligation.TradingInterval) * time.Second
+	if r.tradingLimits != nil {
+		r.tradingLimits.Update(cfg.Obligation.MaxTradingCount, interval)
+	} else {
+		r.tradingLimits = limits.New(cfg.Obligation.MaxTradingCount, interval)
+	}
+
+	if r.TradingCalendar != nil {
+		if err := r.TradingCalendar.Update(cfg); err != nil {
+			return err
+		}
+		r.phaseScheduler.Reschedule()
+		return nil
+	}
+
+	cal, err := calendar.New(cfg)
+	if err != nil {
+		return err
+	}
+	r.TradingCalendar = cal
+	r.phaseScheduler = calendar.NewScheduler(cal, r.onPhase)
+	r.phaseScheduler.Start()
+	return nil
+}
+
+// Close 停止后台任务并关闭审计日志
+func (r *Resolver) Close() {
+	r.InstrumentCatalog.Stop()
+	r.calendarMu.RLock()
+	scheduler := r.phaseScheduler
+	r.calendarMu.RUnlock()
+	if scheduler != nil {
+		scheduler.Stop()
+	}
+	if r.Simulator != nil {
+		r.Simulator.Stop()
+		r.stopSimulatorFeed()
+	}
+	r.approvalTasks.CancelAll()
+	r.Idempotency.Stop()
+	r.SubscriptionManager.Close()
+	if err := r.Audit.Close(); err != nil {
+		zap.L().Error("Failed to close audit log", zap.Error(err))
+	}
+}
+
+// NewLoaders 创建请求级数据加载器，供 HTTP 中间件使用
+func (r *Resolver) NewLoaders() *loaders.Loaders {
+	cfg := r.cfg.Dataloader
+	var opts []dataloader.Option
+	if cfg.Wait > 0 {
+		opts = append(opts, dataloader.WithWait(cfg.Wait))
+	}
+	if cfg.MaxBatch > 0 {
+		opts = append(opts, dataloader.WithMaxBatch(cfg.MaxBatch))
+	}
+	return loaders.NewLoaders(r.users, r.InstrumentCatalog, opts...)
+}
+
+// loadersFor 获取当前请求的加载器，未经过中间件时创建临时加载器
+func (r *Resolver) loadersFor(ctx context.Context) *loaders.Loaders {
+	if l := loaders.For(ctx); l != nil {
+		return l
+	}
+	return r.NewLoaders()
+}
+
+// createOrder 保存已通过校验的新订单，送入撮合后推送，h 为下单前占用的资金，at 为占用额度的时刻
+// 按参考价估算的金额超过审批阈值的订单等待审批，不进入撮合；成交结算或保存失败时退回资金占用
+func (r *Resolver) createOrder(ctx context.Context, inst *model.Instrument, input model.NewOrder, h *hold, notional decimal.Decimal, at time.Time) (*model.Order, error) {
+	order := &model.Order{
+		InstrumentId: inst.ID,
+		AccountId:    input.AccountID,
+		OrderId:      newOrderCode(),
+		Status:       model.OrderStatusNew,
+		Side:         input.Side,
+		Type:         orderType(input),
+		Quantity:     input.Quantity,
+		CreatedAt:    at,
+	}
+	if input.Price != nil {
+		order.Price = &model.Money{Amount: *input.Price, Currency: inst.Currency}
+	}
+	if h != nil {
+		order.OrderId = h.orderCode
+	}
+	var pending bool
+	_ = r.orderBooks.Do(inst.ID, func(b *matching.Book) error {
+		pending = r.requiresApproval(b, order)
+		return nil
+	})
+	if pending {
+		return r.createPendingOrder(ctx, order, h, notional)
+	}
+
+	change := &bookChange{instrumentID: inst.ID}
+	err := r.withBook(change, func(b *matching.Book) error {
+		// 资金不足或记账失败时不创建订单，订单簿不变
+		makers := r.makerOrders(b, order.Side, order.Quantity)
+		undo, err := r.settleFills(order, h, b.Preview(matchingOrder(order)), makers)
+		if err != nil {
+			return err
+		}
+		created, err := r.orders.Create(order)
+		if err != nil {
+			undo()
+			return err
+		}
+		if h != nil {
+			r.holds.put(created.Id, h)
+		}
+		r.holdNotional(created, notional)
+		order = r.matchOrder(ctx, change, b, created)
+		return nil
+	})
+	if err != nil {
+		r.cancelHold(h)
+		return nil, err
+	}
+
+	audit.Record(ctx, "Order", order.Id, nil, order)
+	change.orders = append(change.orders, order)
+	r.publishBookChange(change)
+	return order, nil
+}
+
+// matchOrder 将订单送入订单簿撮合，返回撮合后的订单，需在订单簿锁内调用
+func (r *Resolver) matchOrder(ctx context.Context, change *bookChange, b *matching.Book, order *model.Order) *model.Order {
+	result, err := b.Submit(matchingOrder(order))
+	if err != nil {
+		zap.L().Error("Failed to submit order to book", zap.String("order", order.Id), zap.Error(err))
+		return order
+	}
+	change.result = result
+	if filled := r.applyFills(ctx, change, order); filled > 0 || result.Remaining > 0 {
+		order = r.fillTaker(order, filled, result)
+	}
+	r.releaseHold(order)
+	return order
+}
+
+// cancelOrder 撤销未结束或待审批的订单并释放资金占用
+func (r *Resolver) cancelOrder(ctx context.Context, id string, version int32) (*model.Order, error) {
+	current, ok := r.orders.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+
+	var before model.Order
+	var order *model.Order
+	change := &bookChange{instrumentID: current.InstrumentId}
+	err := r.withBook(change, func(b *matching.Book) error {
+		var err error
+		order, err = r.orders.Update(id, version, func(o *model.Order) error {
+			before = *o.Clone()
+			if !o.IsOpen() && o.Status != model.OrderStatusPendingApproval {
+				return fmt.Errorf("%w: %s is %s", store.ErrOrderClosed, o.Id, o.Status)
+			}
+			o.Status = model.OrderStatusCancelled
+			o.ApprovalExpiresAt = nil
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if before.Status == model.OrderStatusPendingApproval {
+			// 待审批订单未进入订单簿
+			r.approvalTasks.CancelTask(order.Id)
+		} else if change.result, err = b.Cancel(order.Id); err != nil {
+			zap.L().Error("Order book out of sync with order store", zap.String("order", order.Id), zap.Error(err))
+		}
+		r.releaseHold(order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "Order", order.Id, &before, order)
+	change.orders = append(change.orders, order)
+	r.publishBookChange(change)
+	if before.Status == model.OrderStatusPendingApproval {
+		r.publishApproval(order)
+	}
+	return order, nil
+}
+
+// publishOrder 推送订单状态变化，订阅按合约和账户过滤
+func (r *Resolver) publishOrder(order *model.Order) {
+	r.SubscriptionManager.PublishFiltered(subscriptions.TopicOrders, order, order.InstrumentId, order.AccountId)
+}
+
+// tradingCalendar 返回交易日历，配置加载失败时返回错误
+func (r *Resolver) tradingCalendar() (*calendar.Calendar, error) {
+	r.calendarMu.RLock()
+	defer r.calendarMu.RUnlock()
+	if r.TradingCalendar == nil {
+		return nil, errCalendarUnavailable
+	}
+	return r.TradingCalendar, nil
+}
+
+// limiter 返回下单限额，与交易日历同时加载
+func (r *Resolver) limiter() *limits.Limiter {
+	r.calendarMu.RLock()
+	defer r.calendarMu.RUnlock()
+	return r.tradingLimits
+}
+
+// streamContext 在订阅管理器中登记不经管理器推送的订阅，订阅被移除（如用户停用）时取消返回的 ctx
+func (r *Resolver) streamContext(ctx context.Context, topic subscriptions.SubscriptionTopic, channel string) (context.Context, error) {
+	sub, err := r.SubscriptionManager.Subscribe(ctx, topic, channel)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case _, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ctx, nil
+}
+
+// publishPhase 推送交易时段切换，订阅按产品过滤
+func (r *Resolver) publishPhase(e calendar.PhaseEvent) {
+	zap.L().Info("Trading phase changed", zap.String("product", e.ProductID), zap.Bool("open", e.Open))
+	r.SubscriptionManager.PublishFiltered(subscriptions.TopicPhases, &model.TradingPhaseEvent{
+		ProductID: e.ProductID,
+		Open:      e.Open,
+		At:        e.At,
+	}, e.ProductID)
+}
+
+// checkTradingOpen 校验合约所属产品当前处于交易时段
+func (r *Resolver) checkTradingOpen(inst *model.Instrument) error {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return err
+	}
+	open, err := cal.IsOpen(inst.Product, r.now())
+	if err != nil {
+		return err
+	}
+	if !open {
+		return errcode.New(CodeTradingClosed, fmt.Errorf("%w: %s (%s)", errTradingClosed, inst.ID, inst.Product))
+	}
+	return nil
+}
+
+// acquireTradingLimit 在 at 时刻占用账户在所属交易日的下单额度
+func (r *Resolver) acquireTradingLimit(accountID string, at time.Time) error {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return err
+	}
+	return r.limiter().Acquire(accountID, cal.TradingDayOf(at), at)
+}
+
+// acquireTradingLimits 为批量下单在 at 时刻按账户占用额度，返回各超限账户的错误
+func (r *Resolver) acquireTradingLimits(counts map[string]int, at time.Time, atomic bool) (map[string]error, error) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return nil, err
+	}
+	return r.limiter().AcquireBatch(counts, cal.TradingDayOf(at), at, atomic), nil
+}
+
+// releaseTradingLimits 下单失败时退回在 at 时刻占用的额度
+func (r *Resolver) releaseTradingLimits(counts map[string]int, at time.Time) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return
+	}
+	limiter := r.limiter()
+	for accountID, n := range counts {
+		limiter.Release(accountID, cal.TradingDayOf(at), at, n)
+	}
+}
+
+// tradingPhase 将产品的交易时段转换为 GraphQL 类型
+func tradingPhase(cal *calendar.Calendar, productID string) *model.TradingPhase {
+	sessions, _ := cal.Sessions(productID)
+	phase := &model.TradingPhase{
+		ProductID: productID,
+		Timezone:  cal.Location().String(),
+		Sessions:  make([]*model.TradingSession, 0, len(sessions)),
+	}
+	if day := cal.TradingDay(); day != "" {
+		phase.TradingDay = &day
+	}
+	for _, s := range sessions {
+		phase.Sessions = append(phase.Sessions, &model.TradingSession{
+			Start: utils.FormatSeconds(s.Start),
+			End:   utils.FormatSeconds(s.End),
+		})
+	}
+	return phase
+}
+
+// ActiveUserGuard 变更操作要求登录，拒绝已停用用户；配置的管理员不在用户存储中，直接放行
+func (r *Resolver) ActiveUserGuard(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	op := graphql.GetOperationContext(ctx)
+	if op.Operation != nil && op.Operation.Operation == ast.Mutation {
+		if err := r.checkActiveUser(ctx); err != nil {
+			return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{errcode.Presenter(ctx, err)}})
+		}
+	}
+	return next(ctx)
+}
+
+// ExportGuard 导出接口要求登录且用户未停用，查询同样校验
+func (r *Resolver) ExportGuard(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	if err := r.checkActiveUser(ctx); err != nil {
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{errcode.Presenter(ctx, err)}})
+	}
+	return next(ctx)
+}
+
+func (r *Resolver) checkActiveUser(ctx context.Context) error {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(r.cfg.Admin.Users, userID) {
+		return nil
+	}
+	if err := r.users.CheckActive(userID); err != nil {
+		return errcode.New(CodeForbidden, err)
+	}
+	return nil
+}
+
+// missingUser 已不存在的用户的占位信息，视为已停用
+func missingUser(id string) *model.User {
+	return &model.User{ID: id, Username: id, Name: "user " + id, Status: model.UserStatusDeactivated}
+}
+
+// idempotencyTTL 幂等键保存时长，未配置时为一天
+func idempotencyTTL(cfg config.IdempotencyConfig) time.Duration {
+	if cfg.TTL > 0 {
+		return cfg.TTL
+	}
+	return 24 * time.Hour
+}
+
+// idempotent 按 clientMutationId 对单个变更去重，未提供时直接执行
+func idempotent[T any](ctx context.Context, s *idempotency.Store, field string, clientMutationID *string, input any, fn func() (T, error)) (T, error) {
+	if clientMutationID == nil || *clientMutationID == "" {
+		return fn()
+	}
+
+	result, err := s.Do(ctx, middware.UserIDFromContext(ctx), field+":"+*clientMutationID, idempotency.Fingerprint(input), func() (any, error) {
+		v, err := fn()
+		return v, err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
 	}
+	return result.(T), nil
 }
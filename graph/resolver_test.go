@@ -0,0 +1,59 @@
+package graph
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/middware"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	// 测试日志不写入配置的日志文件
+	zap.ReplaceGlobals(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+// newTestResolver 创建数据文件位于临时目录的解析器，configure 可在创建前修改配置
+func newTestResolver(t *testing.T, configure ...func(*config.Config)) *Resolver {
+	t.Helper()
+	cfg := *config.GetConfig()
+	dir := t.TempDir()
+	cfg.Message.HistoryDir = filepath.Join(dir, "messages")
+	cfg.Audit.Path = filepath.Join(dir, "audit", "audit.jsonl")
+	cfg.Audit.ExportDir = filepath.Join(dir, "audit", "export")
+	cfg.Candle.ExportDir = filepath.Join(dir, "candles")
+	cfg.Risk.Path = filepath.Join(dir, "risk", "limits.json")
+	cfg.Settlement.Dir = filepath.Join(dir, "settlement")
+	for _, fn := range configure {
+		fn(&cfg)
+	}
+
+	r := NewResolver(&cfg)
+	t.Cleanup(r.Close)
+	return r
+}
+
+// newTestAccount 创建账户所有者及人民币账户并入金，more 为同一所有者的其他账户，返回所有者的上下文
+func newTestAccount(t *testing.T, r *Resolver, id string, more ...string) context.Context {
+	t.Helper()
+	admin := middware.WithUserID(context.Background(), "admin")
+	owner, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "owner_" + strings.ToLower(id), Name: id})
+	require.NoError(t, err)
+	ctx := middware.WithUserID(context.Background(), owner.ID)
+	for _, accountID := range append([]string{id}, more...) {
+		_, err = r.Mutation().CreateAccount(ctx, model.NewAccount{ID: accountID, OwnerID: owner.ID, Currency: "CNY"})
+		require.NoError(t, err)
+		_, err = r.Mutation().Deposit(admin, accountID, decimal.NewFromInt(1000000), nil)
+		require.NoError(t, err)
+	}
+	return ctx
+}
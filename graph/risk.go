@@ -0,0 +1,228 @@
+package graph
+
+import (
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/matching"
+	"gqlexample/pkg/risk"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// newRiskEngine 按配置创建风控引擎，已保存的限额优先；加载失败时使用配置中的限额且不保存修改
+func newRiskEngine(cfg config.RiskConfig) *risk.Engine {
+	defaults, err := riskLimits(cfg)
+	if err != nil {
+		zap.L().Error("Invalid risk config, risk checks disabled", zap.Error(err))
+		defaults = risk.Limits{}
+	}
+	engine, err := risk.New(defaults, config.ResolvePath(cfg.Path))
+	if err != nil {
+		zap.L().Error("Failed to load risk limits, changes will not be saved", zap.Error(err))
+		engine, _ = risk.New(defaults, "")
+	}
+	return engine
+}
+
+func riskLimits(cfg config.RiskConfig) (risk.Limits, error) {
+	parse := func(name, s string) (decimal.Decimal, error) {
+		if s == "" {
+			return decimal.Zero, nil
+		}
+		d, err := decimal.NewFromString(s)
+		if err != nil {
+			return decimal.Zero, fmt.Errorf("invalid risk %s %q: %w", name, s, err)
+		}
+		return d, nil
+	}
+
+	var limits risk.Limits
+	var err error
+	limits.Default.MaxQuantity = cfg.MaxQuantity
+	if limits.Default.MaxNotional, err = parse("max_notional", cfg.MaxNotional); err != nil {
+		return limits, err
+	}
+	if limits.DailyNotional, err = parse("daily_notional", cfg.DailyNotional); err != nil {
+		return limits, err
+	}
+	limits.CollarPercent = decimal.NewFromFloat(cfg.CollarPercent)
+	limits.FatFingerPercent = decimal.NewFromFloat(cfg.FatFingerPercent)
+	for id, inst := range cfg.Instruments {
+		notional, err := parse("max_notional of "+id, inst.MaxNotional)
+		if err != nil {
+			return limits, err
+		}
+		if limits.Instruments == nil {
+			limits.Instruments = make(map[string]risk.InstrumentLimit)
+		}
+		limits.Instruments[id] = risk.InstrumentLimit{MaxQuantity: inst.MaxQuantity, MaxNotional: notional}
+	}
+	return limits, nil
+}
+
+// riskMarket 由订单簿计算风控所需行情，price 为空表示市价单，需在订单簿锁内调用
+func riskMarket(b *matching.Book, side model.OrderSide, quantity int32, price *decimal.Decimal) risk.Market {
+	var m risk.Market
+	if last, ok := b.LastPrice(); ok {
+		m.Last = last
+	}
+	bids, asks := b.Depth(math.MaxInt)
+	opposite := asks
+	crosses := func(p decimal.Decimal) bool { return price == nil || p.LessThanOrEqual(*price) }
+	if side == model.OrderSideSell {
+		opposite = bids
+		crosses = func(p decimal.Decimal) bool { return price == nil || p.GreaterThanOrEqual(*price) }
+	}
+	if len(opposite) > 0 {
+		m.Best = opposite[0].Price
+	}
+
+	remaining := int64(quantity)
+	for _, l := range opposite {
+		if remaining <= 0 || !crosses(l.Price) {
+			break
+		}
+		m.Sweep = l.Price
+		remaining -= l.Quantity
+	}
+	return m
+}
+
+// checkOrderRisk 对新订单做下单前风控检查，返回估算的委托金额
+func (r *Resolver) checkOrderRisk(input model.NewOrder) (decimal.Decimal, error) {
+	o := risk.Order{
+		AccountID:    input.AccountID,
+		InstrumentID: input.InstrumentID,
+		Quantity:     input.Quantity,
+	}
+	if input.Price != nil {
+		o.Price = *input.Price
+	}
+	_ = r.orderBooks.Do(input.InstrumentID, func(b *matching.Book) error {
+		o.Market = riskMarket(b, input.Side, input.Quantity, input.Price)
+		return nil
+	})
+	return r.risk.Check(o)
+}
+
+// riskOrder 由已保存的订单构造风控检查的委托，需在订单簿锁内调用
+func riskOrder(b *matching.Book, o *model.Order) risk.Order {
+	ro := risk.Order{
+		AccountID:    o.AccountId,
+		InstrumentID: o.InstrumentId,
+		Quantity:     o.Quantity,
+	}
+	var price *decimal.Decimal
+	if o.Price != nil {
+		ro.Price = o.Price.Amount
+		price = &ro.Price
+	}
+	ro.Market = riskMarket(b, o.Side, o.Quantity-o.FilledQuantity, price)
+	return ro
+}
+
+// checkAmendRisk 对改单后的订单做风控检查，只占用增加的委托金额并返回，需在订单簿锁内调用
+func (r *Resolver) checkAmendRisk(b *matching.Book, before, after *model.Order) (decimal.Decimal, error) {
+	notional, err := r.risk.Check(riskOrder(b, after))
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if delta := notional.Sub(before.Price.Amount.Mul(decimal.NewFromInt32(before.Quantity))); delta.IsPositive() {
+		if err := r.reserveNotional(after.AccountId, delta, r.now()); err != nil {
+			return decimal.Zero, err
+		}
+		return delta, nil
+	}
+	return decimal.Zero, nil
+}
+
+// addNotional 改单成功后将新增的委托金额计入订单占用
+func (r *Resolver) addNotional(orderID string, delta decimal.Decimal) {
+	if h := r.notionals.get(orderID); h != nil && delta.IsPositive() {
+		h.amount = h.amount.Add(delta)
+	}
+}
+
+// notionalHold 订单占用的当日委托金额，订单结束时退回未成交部分
+type notionalHold struct {
+	accountID  string
+	tradingDay string
+	amount     decimal.Decimal
+}
+
+// reserveNotional 占用账户在 at 所属交易日的委托金额
+func (r *Resolver) reserveNotional(accountID string, amount decimal.Decimal, at time.Time) error {
+	errs, err := r.reserveNotionals(map[string]decimal.Decimal{accountID: amount}, at, false)
+	if err != nil {
+		return err
+	}
+	return errs[accountID]
+}
+
+// reserveNotionals 为批量下单按账户占用 at 所属交易日的委托金额，返回各超限账户的错误
+func (r *Resolver) reserveNotionals(amounts map[string]decimal.Decimal, at time.Time, atomic bool) (map[string]error, error) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return nil, err
+	}
+	return r.risk.Reserve(amounts, cal.TradingDayOf(at), atomic), nil
+}
+
+// releaseNotionals 下单失败时退回 at 所属交易日占用的委托金额
+func (r *Resolver) releaseNotionals(amounts map[string]decimal.Decimal, at time.Time) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return
+	}
+	for accountID, amount := range amounts {
+		r.risk.Release(accountID, cal.TradingDayOf(at), amount)
+	}
+}
+
+// holdNotional 记录新订单占用的委托金额，订单结束时由 releaseHold 退回未成交部分
+func (r *Resolver) holdNotional(o *model.Order, amount decimal.Decimal) {
+	cal, err := r.tradingCalendar()
+	if err != nil || !amount.IsPositive() {
+		return
+	}
+	r.notionals.put(o.Id, &notionalHold{accountID: o.AccountId, tradingDay: cal.TradingDayOf(o.CreatedAt), amount: amount})
+}
+
+// releaseNotional 退回已结束订单未成交部分占用的当日委托金额
+func (r *Resolver) releaseNotional(o *model.Order) {
+	h := r.notionals.take(o.Id)
+	if h == nil || o.Quantity <= 0 {
+		return
+	}
+	unfilled := decimal.NewFromInt32(o.Quantity - o.FilledQuantity)
+	r.risk.Release(h.accountID, h.tradingDay, h.amount.Mul(unfilled).Div(decimal.NewFromInt32(o.Quantity)))
+}
+
+// riskLimitsModel 将风控限额转换为 GraphQL 类型，合约按 ID 排序
+func riskLimitsModel(l risk.Limits) *model.RiskLimits {
+	result := &model.RiskLimits{
+		MaxQuantity:      l.Default.MaxQuantity,
+		MaxNotional:      l.Default.MaxNotional,
+		Instruments:      make([]*model.InstrumentRiskLimit, 0, len(l.Instruments)),
+		DailyNotional:    l.DailyNotional,
+		CollarPercent:    l.CollarPercent,
+		FatFingerPercent: l.FatFingerPercent,
+		KilledAccounts:   append([]string{}, l.KilledAccounts...),
+	}
+	for id, limit := range l.Instruments {
+		result.Instruments = append(result.Instruments, &model.InstrumentRiskLimit{
+			InstrumentID: id,
+			MaxQuantity:  limit.MaxQuantity,
+			MaxNotional:  limit.MaxNotional,
+		})
+	}
+	sort.Slice(result.Instruments, func(i, j int) bool {
+		return result.Instruments[i].InstrumentID < result.Instruments[j].InstrumentID
+	})
+	return result
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/risk"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRisk_PreTradeChecks(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	engine, err := risk.New(risk.Limits{CollarPercent: decimal.NewFromInt(10), FatFingerPercent: decimal.NewFromInt(5)}, "")
+	require.NoError(t, err)
+	r.risk = engine
+	ctx := context.Background()
+	admin := middware.WithUserID(ctx, "admin")
+	owners := make(map[string]context.Context)
+	for _, id := range []string{"A1", "A2", "A3", "A4", "A5", "M1", "M2"} {
+		owners[id] = newTestAccount(t, r, id)
+	}
+	place := func(accountID string, side model.OrderSide, price string, qty int32) (*model.Order, error) {
+		input := newOrderInput("600000.SH", accountID)
+		input.Side = side
+		input.Quantity = qty
+		if price == "" {
+			market := model.OrderTypeMarket
+			input.Type, input.Price = &market, nil
+		} else {
+			p := decimal.RequireFromString(price)
+			input.Price = &p
+		}
+		return r.Mutation().PlaceOrder(owners[accountID], *input)
+	}
+
+	_, err = r.Mutation().SetKillSwitch(middware.WithUserID(ctx, "U1"), "A1", true)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	// 单笔数量限额
+	maxQuantity, amended := int32(500), int32(200)
+	limits, err := r.Mutation().SetInstrumentRiskLimit(admin, "600000.SH", &maxQuantity, nil)
+	require.NoError(t, err)
+	require.Len(t, limits.Instruments, 1)
+	_, err = place("A1", model.OrderSideBuy, "10.00", 600)
+	assert.Equal(t, risk.CodeMaxQuantity, errcode.Code(err))
+
+	// 按对手方挂单估算的最差成交价偏离过大
+	_, err = place("M1", model.OrderSideSell, "10.00", 100)
+	require.NoError(t, err)
+	_, err = place("M2", model.OrderSideSell, "10.80", 100)
+	require.NoError(t, err)
+	_, err = place("A2", model.OrderSideBuy, "", 200)
+	assert.Equal(t, risk.CodeFatFinger, errcode.Code(err))
+
+	// 成交后按最新价限制委托价偏离
+	_, err = place("A3", model.OrderSideBuy, "10.00", 100)
+	require.NoError(t, err)
+	_, err = place("A4", model.OrderSideBuy, "11.10", 100)
+	assert.Equal(t, risk.CodePriceCollar, errcode.Code(err))
+
+	// 当日委托金额
+	daily := decimal.NewFromInt(1500)
+	_, err = r.Mutation().UpdateRiskLimits(admin, model.RiskLimitsInput{DailyNotional: &daily})
+	require.NoError(t, err)
+	order, err := place("A5", model.OrderSideBuy, "9.90", 100)
+	require.NoError(t, err)
+	_, err = r.Mutation().AmendOrder(owners["A5"], order.Id, model.AmendOrder{Quantity: &amended, ExpectedVersion: order.Version})
+	assert.Equal(t, risk.CodeDailyNotional, errcode.Code(err))
+
+	// 开启暂停后拒绝下单和改单
+	limits, err = r.Mutation().SetKillSwitch(admin, "A5", true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"A5"}, limits.KilledAccounts)
+	_, err = place("A5", model.OrderSideBuy, "9.90", 100)
+	assert.Equal(t, risk.CodeKillSwitch, errcode.Code(err))
+	amended = 300
+	_, err = r.Mutation().AmendOrder(owners["A5"], order.Id, model.AmendOrder{Quantity: &amended, ExpectedVersion: order.Version})
+	assert.Equal(t, risk.CodeKillSwitch, errcode.Code(err))
+
+	current, err := r.Query().RiskLimits(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1500", current.DailyNotional.String())
+	assert.Equal(t, int32(500), current.Instruments[0].MaxQuantity)
+}
+
+func TestRisk_NotionalRelease(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	admin := middware.WithUserID(context.Background(), "admin")
+	ctx := newTestAccount(t, r, "R1")
+	cal, err := r.tradingCalendar()
+	require.NoError(t, err)
+	day := cal.TradingDayOf(now)
+
+	// 改价上调只占用增加的委托金额，改单前的订单不受影响
+	order, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "R1"))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", r.risk.Used("R1", day).String())
+	price := decimal.RequireFromString("10.50")
+	amended, err := r.Mutation().AmendOrder(ctx, order.Id, model.AmendOrder{Price: &price, ExpectedVersion: order.Version})
+	require.NoError(t, err)
+	assert.Equal(t, "10.5", amended.Price.Amount.String())
+	assert.Equal(t, "10", order.Price.Amount.String())
+	assert.Equal(t, "1050", r.risk.Used("R1", day).String())
+
+	// 撤单退回未成交部分
+	_, err = r.Mutation().CancelOrder(ctx, amended.Id, amended.Version)
+	require.NoError(t, err)
+	assert.True(t, r.risk.Used("R1", day).IsZero())
+
+	// 资金不足时退回委托金额及下单额度
+	owner, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "poor", Name: "Poor"})
+	require.NoError(t, err)
+	poor := middware.WithUserID(context.Background(), owner.ID)
+	_, err = r.Mutation().CreateAccount(poor, model.NewAccount{ID: "R2", OwnerID: owner.ID, Currency: "CNY"})
+	require.NoError(t, err)
+	_, err = r.Mutation().Deposit(admin, "R2", decimal.NewFromInt(100), nil)
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = r.Mutation().PlaceOrder(poor, *newOrderInput("600000.SH", "R2"))
+	assert.Equal(t, CodeInsufficientFunds, errcode.Code(err))
+	assert.True(t, r.risk.Used("R2", day).IsZero())
+	assert.Zero(t, r.limiter().Usage("R2", day).Count)
+
+	// 批量下单中失败的条目同样退回
+	now = now.Add(time.Minute)
+	results, err := r.Mutation().PlaceOrders(admin, []*model.NewOrder{
+		newOrderInput("600000.SH", "R1"),
+		newOrderInput("600000.SH", "R2"),
+	}, nil)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.IsType(t, &model.Order{}, results[0])
+	assert.True(t, r.risk.Used("R2", day).IsZero())
+	assert.Zero(t, r.limiter().Usage("R2", day).Count)
+	assert.Equal(t, "1000", r.risk.Used("R1", day).String())
+}
@@ -4,11 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"strconv"
+	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/shopspring/decimal"
 )
 
+// float64 能够无损表示的最大有效数字位数
+const maxFloatSignificantDigits = 15
+
 // MarshalDecimal 将 decimal.Decimal 转换为 GraphQL 标量
 func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
 	return graphql.WriterFunc(func(w io.Writer) {
@@ -17,17 +23,45 @@ func MarshalDecimal(d decimal.Decimal) graphql.Marshaler {
 }
 
 // UnmarshalDecimal 将输入值转换为 decimal.Decimal
+// 推荐以字符串传入，float64 输入超过 15 位有效数字时直接拒绝，避免静默丢失精度
 func UnmarshalDecimal(v interface{}) (decimal.Decimal, error) {
 	switch v := v.(type) {
 	case string:
 		return decimal.NewFromString(v)
 	case float64:
-		return decimal.NewFromFloat(v), nil
+		return decimalFromFloat(v)
 	case int:
 		return decimal.NewFromInt(int64(v)), nil
+	case int64:
+		return decimal.NewFromInt(v), nil
 	case json.Number:
 		return decimal.NewFromString(v.String())
 	default:
 		return decimal.Zero, fmt.Errorf("无法将 %T 转换为 Decimal", v)
 	}
 }
+
+// decimalFromFloat 使用 float64 的最短表示构造 Decimal，超出可精确表示范围时返回错误
+func decimalFromFloat(f float64) (decimal.Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return decimal.Zero, fmt.Errorf("无效的 Decimal 值: %v", f)
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if n := significantDigits(s); n > maxFloatSignificantDigits {
+		return decimal.Zero, fmt.Errorf("数值 %s 有 %d 位有效数字，超过 float 可精确表示的范围，请以字符串传入", s, n)
+	}
+
+	return decimal.NewFromString(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// significantDigits 统计 strconv 'g' 格式数字串中的有效数字位数
+func significantDigits(s string) int {
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		s = s[:i]
+	}
+	s = strings.TrimLeft(s, "-+")
+	s = strings.Replace(s, ".", "", 1)
+	s = strings.TrimLeft(s, "0")
+	return len(s)
+}
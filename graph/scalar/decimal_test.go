@@ -0,0 +1,90 @@
+package scalar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gqlexample/pkg/config"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalDecimal(t *testing.T) {
+	d, err := UnmarshalDecimal("12345678901234567890.123456789")
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567890.123456789", d.String())
+
+	d, err = UnmarshalDecimal(json.Number("0.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1", d.String())
+
+	d, err = UnmarshalDecimal(12.34)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.34", d.String())
+
+	// float64 无法精确表示的数值应被拒绝
+	_, err = UnmarshalDecimal(1234567890.12345678)
+	assert.Error(t, err)
+
+	_, err = UnmarshalDecimal(true)
+	assert.Error(t, err)
+}
+
+func TestPrecision(t *testing.T) {
+	p := Precision{Scale: 2, Rounding: RoundHalfEven}
+	assert.Equal(t, "1.22", p.Round(decimal.RequireFromString("1.225")).String())
+	assert.NoError(t, p.Check(decimal.RequireFromString("1.2300")))
+	assert.Error(t, p.Check(decimal.RequireFromString("1.225")))
+
+	p.Rounding = RoundHalfUp
+	assert.Equal(t, "1.23", p.Round(decimal.RequireFromString("1.225")).String())
+
+	mode, err := ParseRoundingMode("FLOOR")
+	assert.NoError(t, err)
+	assert.Equal(t, RoundFloor, mode)
+
+	t.Cleanup(func() { precisions.Delete("TEST") })
+	SetPrecision("TEST", Precision{Scale: 0, Rounding: RoundDown})
+	assert.Equal(t, int32(0), PrecisionFor("TEST").Scale)
+	assert.Equal(t, int32(4), PrecisionFor("UNKNOWN").Scale)
+}
+
+func TestLoad(t *testing.T) {
+	saved := PrecisionFor("")
+	t.Cleanup(func() {
+		SetDefaultPrecision(saved)
+		for _, id := range []string{"LOAD1", "LOAD2", "LOAD3", "LOAD4"} {
+			precisions.Delete(id)
+		}
+	})
+	scale := func(n int32) *int32 { return &n }
+
+	// 未配置舍入模式时仍使用配置的小数位数，合约沿用默认舍入模式
+	assert.NoError(t, Load(config.DecimalConfig{
+		Scale:       scale(2),
+		Instruments: map[string]config.DecimalPrecision{"LOAD1": {Scale: scale(1)}},
+	}))
+	assert.Equal(t, Precision{Scale: 2, Rounding: saved.Rounding}, PrecisionFor(""))
+	assert.Equal(t, "1.2", PrecisionFor("LOAD1").Round(decimal.RequireFromString("1.25")).String())
+
+	// 配置的舍入模式在 Round 中生效
+	assert.NoError(t, Load(config.DecimalConfig{
+		Scale:       scale(2),
+		Rounding:    "floor",
+		Instruments: map[string]config.DecimalPrecision{"LOAD2": {Scale: scale(1), Rounding: "up"}},
+	}))
+	assert.Equal(t, "-1.23", PrecisionFor("").Round(decimal.RequireFromString("-1.221")).String())
+	assert.Equal(t, "1.3", PrecisionFor("LOAD2").Round(decimal.RequireFromString("1.21")).String())
+
+	assert.Error(t, Load(config.DecimalConfig{Instruments: map[string]config.DecimalPrecision{"LOAD3": {Rounding: "bogus"}}}))
+	assert.Equal(t, PrecisionFor(""), PrecisionFor("LOAD3"))
+
+	// 只配置舍入模式时沿用默认小数位数
+	assert.NoError(t, Load(config.DecimalConfig{
+		Rounding:    "half_up",
+		Instruments: map[string]config.DecimalPrecision{"LOAD4": {Rounding: "down"}},
+	}))
+	assert.Equal(t, Precision{Scale: 2, Rounding: RoundHalfUp}, PrecisionFor(""))
+	assert.Equal(t, Precision{Scale: 2, Rounding: RoundDown}, PrecisionFor("LOAD4"))
+}
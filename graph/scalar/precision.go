@@ -0,0 +1,136 @@
+package scalar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"gqlexample/pkg/cache"
+	"gqlexample/pkg/config"
+
+	"github.com/shopspring/decimal"
+)
+
+// RoundingMode 舍入模式
+type RoundingMode int
+
+const (
+	RoundHalfUp   RoundingMode = iota // 四舍五入（远离零）
+	RoundHalfEven                     // 银行家舍入
+	RoundDown                         // 向零截断
+	RoundUp                           // 远离零进位
+	RoundCeil                         // 向正无穷
+	RoundFloor                        // 向负无穷
+)
+
+var roundingModeNames = map[string]RoundingMode{
+	"half_up":   RoundHalfUp,
+	"half_even": RoundHalfEven,
+	"down":      RoundDown,
+	"up":        RoundUp,
+	"ceil":      RoundCeil,
+	"floor":     RoundFloor,
+}
+
+// ParseRoundingMode 解析配置中的舍入模式名称，如 half_even
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	if mode, ok := roundingModeNames[strings.ToLower(strings.TrimSpace(s))]; ok {
+		return mode, nil
+	}
+	return RoundHalfUp, fmt.Errorf("未知的舍入模式: %s", s)
+}
+
+// Precision 小数精度及舍入规则
+type Precision struct {
+	Scale    int32
+	Rounding RoundingMode
+}
+
+// Round 按精度和舍入模式对数值取整
+func (p Precision) Round(d decimal.Decimal) decimal.Decimal {
+	switch p.Rounding {
+	case RoundHalfEven:
+		return d.RoundBank(p.Scale)
+	case RoundDown:
+		return d.RoundDown(p.Scale)
+	case RoundUp:
+		return d.RoundUp(p.Scale)
+	case RoundCeil:
+		return d.RoundCeil(p.Scale)
+	case RoundFloor:
+		return d.RoundFloor(p.Scale)
+	default:
+		return d.Round(p.Scale)
+	}
+}
+
+// Check 校验数值在该精度下不会丢失精度
+func (p Precision) Check(d decimal.Decimal) error {
+	if !p.Round(d).Equal(d) {
+		return fmt.Errorf("数值 %s 超出允许的小数位数 %d", d.String(), p.Scale)
+	}
+	return nil
+}
+
+var (
+	precisionMu      sync.RWMutex
+	defaultPrecision = Precision{Scale: 4, Rounding: RoundHalfEven}
+	precisions       = cache.NewCache[string, Precision]()
+)
+
+// SetDefaultPrecision 设置未单独配置的合约使用的默认精度
+func SetDefaultPrecision(p Precision) {
+	precisionMu.Lock()
+	defer precisionMu.Unlock()
+	defaultPrecision = p
+}
+
+// SetPrecision 设置指定合约的精度
+func SetPrecision(instrumentID string, p Precision) {
+	precisions.Set(instrumentID, p)
+}
+
+// PrecisionFor 获取指定合约的精度，未配置时返回默认精度
+func PrecisionFor(instrumentID string) Precision {
+	if p, ok := precisions.Get(instrumentID); ok {
+		return p
+	}
+	precisionMu.RLock()
+	defer precisionMu.RUnlock()
+	return defaultPrecision
+}
+
+// Load 按配置设置默认精度及各合约精度，未配置小数位数或舍入模式时沿用默认值，返回无效的舍入模式
+func Load(cfg config.DecimalConfig) error {
+	var errs []error
+	def := PrecisionFor("")
+	if cfg.Scale != nil {
+		def.Scale = *cfg.Scale
+	}
+	if cfg.Rounding != "" {
+		if mode, err := ParseRoundingMode(cfg.Rounding); err != nil {
+			errs = append(errs, err)
+		} else {
+			def.Rounding = mode
+		}
+	}
+	SetDefaultPrecision(def)
+
+	for id, p := range cfg.Instruments {
+		precision := def
+		if p.Scale != nil {
+			precision.Scale = *p.Scale
+		}
+		if p.Rounding != "" {
+			mode, err := ParseRoundingMode(p.Rounding)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", id, err))
+				continue
+			}
+			precision.Rounding = mode
+		}
+		SetPrecision(id, precision)
+	}
+	return errors.Join(errs...)
+}
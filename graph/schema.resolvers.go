@@ -6,74 +6,1208 @@ package graph
 
 import (
 	"context"
-	"crypto/rand"
+	"errors"
 	"fmt"
+	"gqlexample/graph/instrument"
 	"gqlexample/graph/model"
+	"gqlexample/graph/scalar"
+	"gqlexample/graph/store"
 	"gqlexample/graph/subscriptions"
-	"math/big"
+	"gqlexample/pkg/audit"
+	"gqlexample/pkg/calendar"
+	"gqlexample/pkg/candle"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/ledger"
+	"gqlexample/pkg/matching"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/risk"
+	"gqlexample/pkg/settlement"
+	"gqlexample/pkg/utils"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"go.uber.org/zap"
 )
 
+// Owner is the resolver for the owner field.
+func (r *accountResolver) Owner(ctx context.Context, obj *model.Account) (*model.User, error) {
+	return r.loadersFor(ctx).User.Load(ctx, obj.OwnerID)
+}
+
+// Balance is the resolver for the balance field.
+func (r *accountResolver) Balance(ctx context.Context, obj *model.Account) (*model.AccountBalance, error) {
+	return r.accountBalance(obj), nil
+}
+
 // CreateTodo is the resolver for the createTodo field.
 func (r *mutationResolver) CreateTodo(ctx context.Context, input model.NewTodo) (*model.Todo, error) {
-	randNumber, _ := rand.Int(rand.Reader, big.NewInt(100))
-	todo := &model.Todo{
+	if err := r.users.CheckActive(input.UserID); err != nil {
+		return nil, err
+	}
+
+	todo, err := r.todos.Create(&model.Todo{
 		Text:   input.Text,
-		ID:     fmt.Sprintf("T%d", randNumber),
 		UserID: input.UserID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, "Todo", todo.ID, nil, todo)
+	return todo, nil
+}
+
+// UpdateTodo is the resolver for the updateTodo field.
+func (r *mutationResolver) UpdateTodo(ctx context.Context, id string, input model.UpdateTodo) (*model.Todo, error) {
+	var before model.Todo
+	todo, err := r.todos.Update(id, input.ExpectedVersion, func(t *model.Todo) error {
+		before = *t
+		if input.Text != nil {
+			t.Text = *input.Text
+		}
+		if input.Done != nil {
+			t.Done = *input.Done
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "Todo", todo.ID, &before, todo)
+	return todo, nil
+}
+
+// CreateTodos is the resolver for the createTodos field.
+func (r *mutationResolver) CreateTodos(ctx context.Context, inputs []*model.NewTodo, atomic *bool) ([]model.TodoResult, error) {
+	if err := r.checkBatchSize(len(inputs)); err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, len(inputs))
+	for i, input := range inputs {
+		errs[i] = r.users.CheckActive(input.UserID)
+	}
+
+	results := make([]model.TodoResult, len(inputs))
+	if atomic != nil && *atomic && batchFailed(errs) {
+		for i, e := range abortBatch(errs) {
+			results[i] = e
+		}
+		return results, nil
+	}
+
+	for i, input := range inputs {
+		if errs[i] != nil {
+			results[i] = batchError(i, errs[i])
+			continue
+		}
+		todo, err := r.todos.Create(&model.Todo{
+			Text:   input.Text,
+			UserID: input.UserID,
+		})
+		if err != nil {
+			results[i] = batchError(i, err)
+			continue
+		}
+		audit.Record(ctx, "Todo", todo.ID, nil, todo)
+		results[i] = todo
+	}
+	return results, nil
+}
+
+// AddMessage is the resolver for the addMessage field.
+func (r *mutationResolver) AddMessage(ctx context.Context, input model.NewMessage) (*model.Message, error) {
+	return idempotent(ctx, r.Idempotency, "addMessage", input.ClientMutationID, input, func() (*model.Message, error) {
+		msg := &model.Message{
+			Channel:   "sse",
+			Text:      input.Text,
+			CreatedBy: input.CreatedBy,
+		}
+		if input.Channel != nil && *input.Channel != "" {
+			msg.Channel = *input.Channel
+		}
+		if input.Price != nil {
+			if err := scalar.PrecisionFor("").Check(*input.Price); err != nil {
+				return nil, err
+			}
+			msg.Price = *input.Price
+		}
+
+		msg, err := r.messages.Append(msg)
+		if err != nil {
+			return nil, err
+		}
+		audit.Record(ctx, "Message", msg.Channel+"/"+msg.ID, nil, msg)
+
+		r.SubscriptionManager.Publish(subscriptions.Event{
+			Topic:   subscriptions.TopicMessages,
+			Channel: msg.Channel,
+			Payload: msg,
+		})
+		return msg, nil
+	})
+}
+
+// PlaceOrder is the resolver for the placeOrder field.
+func (r *mutationResolver) PlaceOrder(ctx context.Context, input model.NewOrder) (*model.Order, error) {
+	return idempotent(ctx, r.Idempotency, "placeOrder", input.ClientMutationID, input, func() (*model.Order, error) {
+		// 仅账户所有者或管理员可下单，未开户的账户不能下单
+		if _, err := r.accessAccount(ctx, input.AccountID); err != nil {
+			return nil, err
+		}
+		inst, err := r.checkNewOrder(input)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.checkTradingOpen(inst); err != nil {
+			return nil, err
+		}
+		notional, err := r.checkOrderRisk(input)
+		if err != nil {
+			return nil, err
+		}
+		at := r.now()
+		if err := r.acquireTradingLimit(input.AccountID, at); err != nil {
+			return nil, err
+		}
+		// 后续步骤失败时按相反顺序退回委托金额及下单额度，资金占用由 createOrder 退回
+		if err := r.reserveNotional(input.AccountID, notional, at); err != nil {
+			r.releaseTradingLimits(map[string]int{input.AccountID: 1}, at)
+			return nil, err
+		}
+		rollback := func() {
+			r.releaseNotionals(map[string]decimal.Decimal{input.AccountID: notional}, at)
+			r.releaseTradingLimits(map[string]int{input.AccountID: 1}, at)
+		}
+		h, err := r.reserveBuyingPower(input, inst, notional)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+
+		order, err := r.createOrder(ctx, inst, input, h, notional, at)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		zap.L().Info("Order placed", zap.String("id", order.Id), zap.String("instrument", inst.ID))
+		return order, nil
+	})
+}
+
+// PlaceOrders is the resolver for the placeOrders field.
+func (r *mutationResolver) PlaceOrders(ctx context.Context, inputs []*model.NewOrder, atomic *bool) ([]model.OrderResult, error) {
+	if err := r.checkBatchSize(len(inputs)); err != nil {
+		return nil, err
+	}
+	all := atomic != nil && *atomic
+
+	// 已成功下单的 clientMutationId 直接返回首次结果，不再占用额度和资金
+	results := make([]model.OrderResult, len(inputs))
+	claims := r.claimOrders(ctx, inputs)
+	defer claims.done(results)
+	pending := func(i int, errs []error) bool {
+		return errs[i] == nil && claims.replayed[i] == nil
+	}
+
+	errs := claims.errs
+	insts := make([]*model.Instrument, len(inputs))
+	notionals := make([]decimal.Decimal, len(inputs))
+	for i, input := range inputs {
+		if !pending(i, errs) {
+			continue
+		}
+		_, errs[i] = r.accessAccount(ctx, input.AccountID)
+		if errs[i] == nil {
+			insts[i], errs[i] = r.checkNewOrder(*input)
+		}
+		if errs[i] == nil {
+			errs[i] = r.checkTradingOpen(insts[i])
+		}
+		if errs[i] == nil {
+			notionals[i], errs[i] = r.checkOrderRisk(*input)
+		}
+	}
+
+	// 下单额度、委托金额及资金依次占用，条目失败或整体中止时按相反顺序退回
+	at := r.now()
+	limited := make([]bool, len(inputs))
+	reserved := make([]bool, len(inputs))
+	holds := make([]*hold, len(inputs))
+	unwind := func(i int) {
+		if reserved[i] {
+			r.releaseNotionals(map[string]decimal.Decimal{inputs[i].AccountID: notionals[i]}, at)
+			reserved[i] = false
+		}
+		if limited[i] {
+			r.releaseTradingLimits(map[string]int{inputs[i].AccountID: 1}, at)
+			limited[i] = false
+		}
+	}
+	unwindAll := func() {
+		for i := range inputs {
+			r.cancelHold(holds[i])
+			holds[i] = nil
+			unwind(i)
+		}
+	}
+
+	// 下单额度按账户整体占用，同一账户的订单一起成功或失败
+	if !all || !batchFailed(errs) {
+		counts := make(map[string]int)
+		for i, input := range inputs {
+			if pending(i, errs) {
+				counts[input.AccountID]++
+			}
+		}
+		if len(counts) > 0 {
+			limitErrs, err := r.acquireTradingLimits(counts, at, all)
+			if err != nil {
+				return nil, err
+			}
+			for i, input := range inputs {
+				if pending(i, errs) {
+					errs[i] = limitErrs[input.AccountID]
+					limited[i] = errs[i] == nil
+				}
+			}
+		}
+	}
+
+	// 委托金额同样按账户整体占用
+	if !all || !batchFailed(errs) {
+		amounts := make(map[string]decimal.Decimal)
+		for i, input := range inputs {
+			if pending(i, errs) {
+				amounts[input.AccountID] = amounts[input.AccountID].Add(notionals[i])
+			}
+		}
+		if len(amounts) > 0 {
+			riskErrs, err := r.reserveNotionals(amounts, at, all)
+			if err != nil {
+				unwindAll()
+				return nil, err
+			}
+			for i, input := range inputs {
+				if pending(i, errs) {
+					errs[i] = riskErrs[input.AccountID]
+					reserved[i] = errs[i] == nil
+				}
+			}
+		}
+	}
+
+	// 资金按订单逐笔占用
+	if !all || !batchFailed(errs) {
+		for i, input := range inputs {
+			if pending(i, errs) {
+				holds[i], errs[i] = r.reserveBuyingPower(*input, insts[i], notionals[i])
+			}
+		}
+	}
+
+	if all && batchFailed(errs) {
+		unwindAll()
+		for i, e := range abortBatch(errs) {
+			results[i] = e
+			if claims.replayed[i] != nil {
+				results[i] = claims.replayed[i]
+			}
+		}
+		return results, nil
+	}
+
+	placed := 0
+	for i, input := range inputs {
+		if claims.replayed[i] != nil {
+			results[i] = claims.replayed[i]
+			continue
+		}
+		if errs[i] != nil {
+			unwind(i)
+			results[i] = batchError(i, errs[i])
+			continue
+		}
+		// 保存失败时 createOrder 退回资金占用
+		order, err := r.createOrder(ctx, insts[i], *input, holds[i], notionals[i], at)
+		if err != nil {
+			unwind(i)
+			results[i] = batchError(i, err)
+			continue
+		}
+		results[i] = order
+		placed++
+	}
+	zap.L().Info("Orders placed", zap.Int("placed", placed), zap.Int("total", len(inputs)))
+	return results, nil
+}
+
+// AmendOrder is the resolver for the amendOrder field.
+func (r *mutationResolver) AmendOrder(ctx context.Context, id string, input model.AmendOrder) (*model.Order, error) {
+	current, ok := r.orders.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+	if _, err := r.accessAccount(ctx, current.AccountId); err != nil {
+		return nil, err
+	}
+
+	var before model.Order
+	var order *model.Order
+	var reserved decimal.Decimal
+	var at time.Time
+	var undo func()
+	change := &bookChange{instrumentID: current.InstrumentId}
+	err := r.withBook(change, func(b *matching.Book) error {
+		// 改价或加量后可能立即成交，在订单存储锁外查询可能成交的对手方订单
+		var makers map[string]*model.Order
+		if latest, ok := r.orders.Get(id); ok {
+			quantity := latest.Quantity
+			if input.Quantity != nil {
+				quantity = max(quantity, *input.Quantity)
+			}
+			makers = r.makerOrders(b, latest.Side, quantity)
+		}
+		var err error
+		order, err = r.orders.Update(id, input.ExpectedVersion, func(o *model.Order) error {
+			before = *o.Clone()
+			if !o.IsOpen() {
+				return fmt.Errorf("%w: %s is %s", store.ErrOrderClosed, o.Id, o.Status)
+			}
+			if input.Price != nil {
+				o.Price = &model.Money{Amount: *input.Price, Currency: o.Price.Currency}
+			}
+			if input.Quantity != nil {
+				if *input.Quantity <= o.FilledQuantity {
+					return fmt.Errorf("%w: %d <= %d", errQuantityFilled, *input.Quantity, o.FilledQuantity)
+				}
+				o.Quantity = *input.Quantity
+			}
+			if _, err := r.InstrumentCatalog.CheckOrder(o.InstrumentId, o.Price.Amount, o.Quantity); err != nil {
+				return err
+			}
+			if err := r.checkAmendApproval(b, &before, o); err != nil {
+				return err
+			}
+			delta, err := r.checkAmendRisk(b, &before, o)
+			if err != nil {
+				return err
+			}
+			reserved, at = delta, r.now()
+			if err := r.adjustHold(o); err != nil {
+				return err
+			}
+			// 资金不足或记账失败时放弃改单并恢复资金占用
+			undo, err = r.settleFills(o, r.holds.get(o.Id), b.Preview(matchingOrder(o)), makers)
+			if err != nil {
+				if err := r.adjustHold(&before); err != nil {
+					zap.L().Error("Failed to restore buying power", zap.String("order", o.Id), zap.Error(err))
+				}
+			}
+			return err
+		})
+		if err != nil {
+			if undo != nil {
+				undo()
+			}
+			// 改单失败时退回已占用的新增委托金额
+			if reserved.IsPositive() {
+				r.releaseNotionals(map[string]decimal.Decimal{current.AccountId: reserved}, at)
+			}
+			return err
+		}
+		r.addNotional(order.Id, reserved)
+
+		// 改价或加量后失去时间优先级，并可能立即成交
+		result, err := b.Amend(order.Id, order.Price.Amount, order.Quantity-order.FilledQuantity)
+		if err != nil {
+			zap.L().Error("Order book out of sync with order store", zap.String("order", order.Id), zap.Error(err))
+			return nil
+		}
+		change.result = result
+		if filled := r.applyFills(ctx, change, order); filled > 0 {
+			order = r.fillTaker(order, filled, result)
+		}
+		r.releaseHold(order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "Order", order.Id, &before, order)
+	change.orders = append(change.orders, order)
+	r.publishBookChange(change)
+	return order, nil
+}
+
+// CancelOrder is the resolver for the cancelOrder field.
+func (r *mutationResolver) CancelOrder(ctx context.Context, id string, expectedVersion int32) (*model.Order, error) {
+	current, ok := r.orders.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, id)
+	}
+	if _, err := r.accessAccount(ctx, current.AccountId); err != nil {
+		return nil, err
+	}
+	return r.cancelOrder(ctx, id, expectedVersion)
+}
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*model.User, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	user, err := r.users.Create(input.Username, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "User", user.ID, nil, user)
+	return user, nil
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUser) (*model.User, error) {
+	// 用户只能修改自己的资料，管理员可修改任意用户
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if userID != id {
+		if err := r.requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+	before, _ := r.users.Get(id)
+	user, err := r.users.Update(id, input.Username, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	audit.Record(ctx, "User", user.ID, before, user)
+	return user, nil
+}
+
+// DeactivateUser is the resolver for the deactivateUser field.
+func (r *mutationResolver) DeactivateUser(ctx context.Context, id string) (*model.User, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	before, _ := r.users.Get(id)
+	user, err := r.users.Deactivate(id)
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, "User", user.ID, before, user)
+
+	// 终止该用户的全部订阅
+	n := r.SubscriptionManager.UnsubscribeUser(id)
+	zap.L().Info("User deactivated", zap.String("id", id), zap.Int("subscriptions", n))
+	return user, nil
+}
+
+// ExportAuditLog is the resolver for the exportAuditLog field.
+func (r *mutationResolver) ExportAuditLog(ctx context.Context, entityType *string, entityID *string, from *time.Time, to *time.Time) (string, error) {
+	if err := r.requireAuditor(ctx); err != nil {
+		return "", err
+	}
+	entries := r.Audit.Query(auditFilter(entityType, entityID, from, to))
+	if len(entries) == 0 {
+		return "", errNoAuditEntries
+	}
+
+	dir := config.ResolvePath(r.cfg.Audit.ExportDir)
+	if err := utils.MkdirAll(dir); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("audit_%s.csv", time.Now().Format("20060102150405.000"))
+	path := filepath.Join(dir, name)
+	if err := audit.WriteCsv(entries, path); err != nil {
+		return "", err
+	}
+
+	zap.L().Info("Audit log exported", zap.String("path", path), zap.Int("entries", len(entries)))
+	return name, nil
+}
+
+// FlushCandles is the resolver for the flushCandles field.
+func (r *mutationResolver) FlushCandles(ctx context.Context, instrumentID *string) (string, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return "", err
+	}
+	id := ""
+	if instrumentID != nil {
+		id = *instrumentID
+	}
+	bars := r.candles.Snapshot(id)
+	if len(bars) == 0 {
+		return "", errNoCandles
+	}
+
+	dir := config.ResolvePath(r.cfg.Candle.ExportDir)
+	if err := utils.MkdirAll(dir); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("candles_%s.csv", time.Now().Format("20060102150405.000"))
+	path := filepath.Join(dir, name)
+	if err := candle.WriteCsv(bars, path); err != nil {
+		return "", err
+	}
+
+	zap.L().Info("Candles exported", zap.String("path", path), zap.Int("bars", len(bars)))
+	return name, nil
+}
+
+// CreatePriceAlert is the resolver for the createPriceAlert field.
+func (r *mutationResolver) CreatePriceAlert(ctx context.Context, instrumentID string, condition model.AlertCondition, price decimal.Decimal, rearm *bool) (*model.PriceAlert, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("%w: %s", errInvalidAlertPrice, price)
+	}
+
+	a := r.alerts.Add(userID, instrumentID, alertCondition(condition), price, rearm != nil && *rearm, r.now())
+	zap.L().Info("Price alert created", zap.String("user", userID), zap.String("alert", a.ID), zap.String("instrument", instrumentID))
+	return priceAlert(a), nil
+}
+
+// DeleteAlert is the resolver for the deleteAlert field.
+func (r *mutationResolver) DeleteAlert(ctx context.Context, id string) (bool, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := r.alerts.Delete(userID, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// StartSimulator is the resolver for the startSimulator field.
+func (r *mutationResolver) StartSimulator(ctx context.Context) (*model.SimulatorStatus, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	sim, err := r.simulator()
+	if err != nil {
+		return nil, err
+	}
+	sim.Start()
+	return simulatorStatus(sim.Status()), nil
+}
+
+// StopSimulator is the resolver for the stopSimulator field.
+func (r *mutationResolver) StopSimulator(ctx context.Context) (*model.SimulatorStatus, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	sim, err := r.simulator()
+	if err != nil {
+		return nil, err
+	}
+	sim.Stop()
+	return simulatorStatus(sim.Status()), nil
+}
+
+// SetSimulatorVolatility is the resolver for the setSimulatorVolatility field.
+func (r *mutationResolver) SetSimulatorVolatility(ctx context.Context, volatility float64, instrumentID *string) (*model.SimulatorStatus, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	sim, err := r.simulator()
+	if err != nil {
+		return nil, err
+	}
+	id := ""
+	if instrumentID != nil {
+		id = *instrumentID
+	}
+	if err := sim.SetVolatility(id, volatility); err != nil {
+		return nil, err
+	}
+	zap.L().Info("Simulator volatility changed", zap.String("instrument", id), zap.Float64("volatility", volatility))
+	return simulatorStatus(sim.Status()), nil
+}
+
+// InjectPriceJump is the resolver for the injectPriceJump field.
+func (r *mutationResolver) InjectPriceJump(ctx context.Context, instrumentID string, percent float64) (*model.Quote, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	sim, err := r.simulator()
+	if err != nil {
+		return nil, err
+	}
+	q, err := sim.Jump(instrumentID, percent)
+	if err != nil {
+		return nil, err
+	}
+	return simulatedQuote(q), nil
+}
+
+// ApproveOrder is the resolver for the approveOrder field.
+func (r *mutationResolver) ApproveOrder(ctx context.Context, id string) (*model.Order, error) {
+	reviewer, err := r.requireApprover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.approveOrder(ctx, id, reviewer)
+}
+
+// RejectOrder is the resolver for the rejectOrder field.
+func (r *mutationResolver) RejectOrder(ctx context.Context, id string, reason *string) (*model.Order, error) {
+	reviewer, err := r.requireApprover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var text string
+	if reason != nil {
+		text = *reason
+	}
+	return r.rejectOrder(ctx, id, reviewer, text)
+}
+
+// CreateAccount is the resolver for the createAccount field.
+func (r *mutationResolver) CreateAccount(ctx context.Context, input model.NewAccount) (*model.Account, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if input.OwnerID != userID {
+		if err := r.requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.users.CheckActive(input.OwnerID); err != nil {
+		return nil, err
+	}
+
+	account, err := r.accounts.Create(&model.Account{
+		ID:        input.ID,
+		OwnerID:   input.OwnerID,
+		Currency:  strings.ToUpper(input.Currency),
+		CreatedAt: r.now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	audit.Record(ctx, "Account", account.ID, nil, account)
+	zap.L().Info("Account created", zap.String("account", account.ID), zap.String("owner", account.OwnerID))
+	return account, nil
+}
+
+// Deposit is the resolver for the deposit field.
+func (r *mutationResolver) Deposit(ctx context.Context, accountID string, amount decimal.Decimal, reference *string) (*model.Account, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	account, ok := r.accounts.Get(accountID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrAccountNotFound, accountID)
+	}
+	if err := checkAmount(amount); err != nil {
+		return nil, err
+	}
+
+	ref := utils.GenerateCode("DEP")
+	if reference != nil {
+		ref = *reference
+	}
+	if err := r.post(ledger.Deposit, account.Currency, ref, []ledger.Posting{
+		{Account: externalBook, Amount: amount.Neg()},
+		{Account: cashBook(account.ID), Amount: amount},
+	}); err != nil {
+		return nil, err
+	}
+	zap.L().Info("Deposit posted", zap.String("account", account.ID), zap.String("amount", amount.String()))
+	return account, nil
+}
+
+// Withdraw is the resolver for the withdraw field.
+func (r *mutationResolver) Withdraw(ctx context.Context, accountID string, amount decimal.Decimal, reference *string) (*model.Account, error) {
+	account, err := r.accessAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAmount(amount); err != nil {
+		return nil, err
+	}
+
+	ref := utils.GenerateCode("WDR")
+	if reference != nil {
+		ref = *reference
+	}
+	if err := r.post(ledger.Withdrawal, account.Currency, ref, []ledger.Posting{
+		{Account: cashBook(account.ID), Amount: amount.Neg()},
+		{Account: externalBook, Amount: amount},
+	}, ledger.Guard{Account: cashBook(account.ID)}); err != nil {
+		return nil, err
+	}
+	zap.L().Info("Withdrawal posted", zap.String("account", account.ID), zap.String("amount", amount.String()))
+	return account, nil
+}
+
+// UpdateRiskLimits is the resolver for the updateRiskLimits field.
+func (r *mutationResolver) UpdateRiskLimits(ctx context.Context, input model.RiskLimitsInput) (*model.RiskLimits, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	limits, err := r.risk.Update(func(l *risk.Limits) {
+		if input.MaxQuantity != nil {
+			l.Default.MaxQuantity = *input.MaxQuantity
+		}
+		if input.MaxNotional != nil {
+			l.Default.MaxNotional = *input.MaxNotional
+		}
+		if input.DailyNotional != nil {
+			l.DailyNotional = *input.DailyNotional
+		}
+		if input.CollarPercent != nil {
+			l.CollarPercent = *input.CollarPercent
+		}
+		if input.FatFingerPercent != nil {
+			l.FatFingerPercent = *input.FatFingerPercent
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Info("Risk limits updated", zap.String("user", middware.UserIDFromContext(ctx)))
+	return riskLimitsModel(limits), nil
+}
+
+// SetInstrumentRiskLimit is the resolver for the setInstrumentRiskLimit field.
+func (r *mutationResolver) SetInstrumentRiskLimit(ctx context.Context, instrumentID string, maxQuantity *int32, maxNotional *decimal.Decimal) (*model.RiskLimits, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	limits, err := r.risk.Update(func(l *risk.Limits) {
+		if maxQuantity == nil && maxNotional == nil {
+			delete(l.Instruments, instrumentID)
+			return
+		}
+		limit := l.Instrument(instrumentID)
+		if maxQuantity != nil {
+			limit.MaxQuantity = *maxQuantity
+		}
+		if maxNotional != nil {
+			limit.MaxNotional = *maxNotional
+		}
+		if l.Instruments == nil {
+			l.Instruments = make(map[string]risk.InstrumentLimit)
+		}
+		l.Instruments[instrumentID] = limit
+	})
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Info("Instrument risk limit updated", zap.String("user", middware.UserIDFromContext(ctx)), zap.String("instrument", instrumentID))
+	return riskLimitsModel(limits), nil
+}
+
+// SetKillSwitch is the resolver for the setKillSwitch field.
+func (r *mutationResolver) SetKillSwitch(ctx context.Context, accountID string, enabled bool) (*model.RiskLimits, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	limits, err := r.risk.Update(func(l *risk.Limits) {
+		l.SetKilled(accountID, enabled)
+	})
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Warn("Kill switch changed", zap.String("user", middware.UserIDFromContext(ctx)), zap.String("account", accountID), zap.Bool("enabled", enabled))
+	return riskLimitsModel(limits), nil
+}
+
+// RunSettlement is the resolver for the runSettlement field.
+func (r *mutationResolver) RunSettlement(ctx context.Context, tradingDay string) (*model.SettlementRun, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	if err := r.checkSettlementDay(tradingDay); err != nil {
+		return nil, err
+	}
+	run, err := r.settle(ctx, tradingDay, settlement.Manual)
+	if err != nil {
+		return nil, err
+	}
+	return settlementRun(run), nil
+}
+
+// Instrument is the resolver for the instrument field.
+func (r *orderResolver) Instrument(ctx context.Context, obj *model.Order) (*model.Instrument, error) {
+	return r.loadersFor(ctx).Instrument.Load(ctx, obj.InstrumentId)
+}
+
+// Todos is the resolver for the todos field.
+func (r *queryResolver) Todos(ctx context.Context) ([]*model.Todo, error) {
+	return r.todos.List(), nil
+}
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, id string) (*model.Order, error) {
+	if _, err := requireUser(ctx); err != nil {
+		return nil, err
+	}
+	order, ok := r.orders.Get(id)
+	if !ok || r.requireAdmin(ctx) == nil {
+		return order, nil
+	}
+	if _, err := r.accessAccount(ctx, order.AccountId); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Orders is the resolver for the orders field.
+func (r *queryResolver) Orders(ctx context.Context) ([]*model.Order, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// 管理员查看全部订单，其他用户只能查看自己账户的订单
+	if r.requireAdmin(ctx) == nil {
+		return r.orders.List(), nil
+	}
+	owned := make(map[string]bool)
+	for _, a := range r.accounts.List(userID) {
+		owned[a.ID] = true
+	}
+	orders := []*model.Order{}
+	for _, o := range r.orders.List() {
+		if owned[o.AccountId] {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+// Instrument is the resolver for the instrument field.
+func (r *queryResolver) Instrument(ctx context.Context, id string) (*model.Instrument, error) {
+	inst, _ := r.InstrumentCatalog.Get(id)
+	return inst, nil
+}
+
+// Instruments is the resolver for the instruments field.
+func (r *queryResolver) Instruments(ctx context.Context, filter *model.InstrumentFilter) ([]*model.Instrument, error) {
+	return r.InstrumentCatalog.List(filter), nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id string) (*model.User, error) {
+	user, _ := r.users.Get(id)
+	return user, nil
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context) ([]*model.User, error) {
+	return r.users.List(), nil
+}
+
+// Messages is the resolver for the messages field.
+func (r *queryResolver) Messages(ctx context.Context, channel string, after *string, first *int32) ([]*model.Message, error) {
+	var afterSeq int64
+	if after != nil {
+		afterSeq = store.MessageSeq(*after)
+	}
+	limit := 0
+	if first != nil {
+		limit = int(*first)
+	}
+	return r.messages.After(channel, afterSeq, limit), nil
+}
+
+// TradingPhases is the resolver for the tradingPhases field.
+func (r *queryResolver) TradingPhases(ctx context.Context, productID *string) ([]*model.TradingPhase, error) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return nil, err
+	}
+
+	products := cal.Products()
+	if productID != nil {
+		if _, ok := cal.Sessions(*productID); !ok {
+			return nil, fmt.Errorf("%w: %s", calendar.ErrUnknownProduct, *productID)
+		}
+		products = []string{*productID}
+	}
+
+	phases := make([]*model.TradingPhase, 0, len(products))
+	for _, id := range products {
+		phases = append(phases, tradingPhase(cal, id))
+	}
+	return phases, nil
+}
+
+// IsTradingOpen is the resolver for the isTradingOpen field.
+func (r *queryResolver) IsTradingOpen(ctx context.Context, productID string, at *time.Time) (bool, error) {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return false, err
+	}
+
+	now := r.now()
+	if at != nil {
+		now = *at
+	}
+	return cal.IsOpen(productID, now)
+}
+
+// TradingLimits is the resolver for the tradingLimits field.
+func (r *queryResolver) TradingLimits(ctx context.Context, accountID string) (*model.TradingLimits, error) {
+	if _, err := r.accessAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return nil, err
+	}
+
+	u := r.limiter().Usage(accountID, cal.TradingDayOf(r.now()))
+	result := &model.TradingLimits{
+		AccountID:         u.AccountID,
+		TradingDay:        u.TradingDay,
+		OrderCount:        int32(u.Count),
+		MaxTradingCount:   int32(u.MaxCount),
+		Remaining:         int32(u.Remaining()),
+		TradingIntervalMs: int32(u.Interval.Milliseconds()),
+	}
+	if !u.LastOrderAt.IsZero() {
+		result.LastOrderAt = &u.LastOrderAt
+	}
+	if !u.NextAllowedAt.IsZero() {
+		result.NextOrderAt = &u.NextAllowedAt
+	}
+	return result, nil
+}
+
+// OrderBook is the resolver for the orderBook field.
+func (r *queryResolver) OrderBook(ctx context.Context, instrumentID string, depth *int32) (*model.OrderBook, error) {
+	n := defaultBookDepth
+	if depth != nil {
+		n = int(*depth)
+	}
+	if n <= 0 {
+		return nil, fmt.Errorf("depth must be positive: %d", n)
+	}
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+
+	book := &model.OrderBook{InstrumentID: instrumentID}
+	_ = r.orderBooks.Do(instrumentID, func(b *matching.Book) error {
+		bids, asks := b.Depth(n)
+		book.Bids = orderBookLevels(bids)
+		book.Asks = orderBookLevels(asks)
+		book.Sequence = int32(b.Sequence())
+		if last, ok := b.LastPrice(); ok {
+			book.LastPrice = &last
+		}
+		return nil
+	})
+	return book, nil
+}
+
+// Positions is the resolver for the positions field.
+func (r *queryResolver) Positions(ctx context.Context, accountID string) ([]*model.Position, error) {
+	if _, err := r.accessAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	positions := r.positions.List(accountID)
+	result := make([]*model.Position, 0, len(positions))
+	for _, p := range positions {
+		result = append(result, r.markPosition(p))
+	}
+	return result, nil
+}
+
+// Fills is the resolver for the fills field.
+func (r *queryResolver) Fills(ctx context.Context, orderID string) ([]*model.Fill, error) {
+	order, ok := r.orders.Get(orderID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", store.ErrOrderNotFound, orderID)
+	}
+	if _, err := r.accessAccount(ctx, order.AccountId); err != nil {
+		return nil, err
+	}
+	return r.fills.ByOrder(orderID), nil
+}
+
+// Quote is the resolver for the quote field.
+func (r *queryResolver) Quote(ctx context.Context, instrumentID string, source *model.QuoteSource) (*model.Quote, error) {
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	quote, _ := r.QuoteHub.Latest(quoteKey(instrumentID, quoteSource(source)))
+	return quote, nil
+}
+
+// SimulatorStatus is the resolver for the simulatorStatus field.
+func (r *queryResolver) SimulatorStatus(ctx context.Context) (*model.SimulatorStatus, error) {
+	if r.Simulator == nil {
+		return nil, nil
+	}
+	return simulatorStatus(r.Simulator.Status()), nil
+}
+
+// Candles is the resolver for the candles field.
+func (r *queryResolver) Candles(ctx context.Context, instrumentID string, interval string, from *time.Time, to *time.Time) ([]*model.Candle, error) {
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	d, err := candle.ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	var start, end time.Time
+	if from != nil {
+		start = *from
+	}
+	if to != nil {
+		end = *to
+	}
+
+	bars, err := r.candles.Bars(instrumentID, d, start, end)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*model.Candle, 0, len(bars))
+	for _, b := range bars {
+		result = append(result, candleBar(b))
+	}
+	return result, nil
+}
+
+// Alerts is the resolver for the alerts field.
+func (r *queryResolver) Alerts(ctx context.Context) ([]*model.PriceAlert, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	alerts := r.alerts.List(userID)
+	result := make([]*model.PriceAlert, 0, len(alerts))
+	for _, a := range alerts {
+		result = append(result, priceAlert(a))
+	}
+	return result, nil
+}
+
+// RiskLimits is the resolver for the riskLimits field.
+func (r *queryResolver) RiskLimits(ctx context.Context) (*model.RiskLimits, error) {
+	return riskLimitsModel(r.risk.Limits()), nil
+}
+
+// PendingApprovals is the resolver for the pendingApprovals field.
+func (r *queryResolver) PendingApprovals(ctx context.Context) ([]*model.Order, error) {
+	if _, err := r.requireApprover(ctx); err != nil {
+		return nil, err
+	}
+	orders := []*model.Order{}
+	for _, o := range r.orders.List() {
+		if o.Status == model.OrderStatusPendingApproval {
+			orders = append(orders, o)
+		}
+	}
+	return orders, nil
+}
+
+// Account is the resolver for the account field.
+func (r *queryResolver) Account(ctx context.Context, id string) (*model.Account, error) {
+	account, err := r.accessAccount(ctx, id)
+	if errors.Is(err, store.ErrAccountNotFound) {
+		return nil, nil
+	}
+	return account, err
+}
+
+// Accounts is the resolver for the accounts field.
+func (r *queryResolver) Accounts(ctx context.Context, ownerID *string) ([]*model.Account, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
 	}
-	r.todos = append(r.todos, todo)
-	return todo, nil
+	if ownerID != nil && *ownerID != userID {
+		if err := r.requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+		userID = *ownerID
+	}
+	return r.accounts.List(userID), nil
 }
 
-// AddMessage is the resolver for the addMessage field.
-func (r *mutationResolver) AddMessage(ctx context.Context, input model.NewMessage) (*model.Message, error) {
-	r.SubscriptionManager.Publish(subscriptions.Event{
-		Topic:   subscriptions.TopicMessages,
-		Channel: "sse",
-		Payload: &model.Message{
-			Text: input.Text,
-		},
-	})
-	return &model.Message{
-		Text: input.Text,
-	}, nil
+// LedgerEntries is the resolver for the ledgerEntries field.
+func (r *queryResolver) LedgerEntries(ctx context.Context, accountID string) ([]*model.LedgerEntry, error) {
+	account, err := r.accessAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	entries := r.ledger.Entries(cashBook(account.ID), reservedBook(account.ID))
+	result := make([]*model.LedgerEntry, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, ledgerEntry(e))
+	}
+	return result, nil
 }
 
-// Todos is the resolver for the todos field.
-func (r *queryResolver) Todos(ctx context.Context) ([]*model.Todo, error) {
-	return r.todos, nil
+// SettlementRuns is the resolver for the settlementRuns field.
+func (r *queryResolver) SettlementRuns(ctx context.Context) ([]*model.SettlementRun, error) {
+	if err := r.requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	runs := r.settlements.List()
+	result := make([]*model.SettlementRun, 0, len(runs))
+	for _, run := range runs {
+		result = append(result, settlementRun(run))
+	}
+	return result, nil
 }
 
-// Order is the resolver for the order field.
-func (r *queryResolver) Order(ctx context.Context, id string) (*model.Order, error) {
-	return &model.Order{
-		Id:           id,
-		OrderId:      "order-" + id,
-		InstrumentId: "instrument-" + id,
-	}, nil
-}
+// AuditLog is the resolver for the auditLog field.
+func (r *queryResolver) AuditLog(ctx context.Context, entityType *string, entityID *string, from *time.Time, to *time.Time, first *int32, after *string) (*model.AuditEntryConnection, error) {
+	if err := r.requireAuditor(ctx); err != nil {
+		return nil, err
+	}
+	entries := r.Audit.Query(auditFilter(entityType, entityID, from, to))
+	page, hasNext := store.Paginate(entries, func(e *audit.Entry) string { return strconv.FormatInt(e.Seq, 10) }, first, after)
 
-// Orders is the resolver for the orders field.
-func (r *queryResolver) Orders(ctx context.Context) ([]*model.Order, error) {
-	return []*model.Order{
-		{
-			Id:           "1",
-			OrderId:      "order-1",
-			InstrumentId: "instrument-1",
-		},
-		{
-			Id:           "2",
-			OrderId:      "order-2",
-			InstrumentId: "instrument-2",
-		},
-	}, nil
+	conn := &model.AuditEntryConnection{
+		Edges:      make([]*model.AuditEntryEdge, 0, len(page)),
+		PageInfo:   &model.PageInfo{HasNextPage: hasNext},
+		TotalCount: int32(len(entries)),
+	}
+	for _, e := range page {
+		node := auditEntry(e)
+		conn.Edges = append(conn.Edges, &model.AuditEntryEdge{Cursor: node.ID, Node: node})
+	}
+	if len(page) > 0 {
+		conn.PageInfo.EndCursor = &conn.Edges[len(conn.Edges)-1].Cursor
+	}
+	return conn, nil
 }
 
 // MessageAdded is the resolver for the messageAdded field.
-func (r *subscriptionResolver) MessageAdded(ctx context.Context, channel string) (<-chan *model.Message, error) {
+func (r *subscriptionResolver) MessageAdded(ctx context.Context, channel string, since *string) (<-chan *model.Message, error) {
 	zap.L().Info("Subscribe to messageAdded", zap.String("channel", channel))
+
+	// 未指定 since 时只推送订阅之后的新消息
+	lastSeq := r.messages.LastSeq(channel)
+	if since != nil {
+		lastSeq = store.MessageSeq(*since)
+	}
+
 	sub, err := r.SubscriptionManager.Subscribe(ctx, subscriptions.TopicMessages, channel)
 	if err != nil {
 		zap.L().Error("Subscribe failed", zap.Error(err))
@@ -88,19 +1222,49 @@ func (r *subscriptionResolver) MessageAdded(ctx context.Context, channel string)
 			close(msgChan)
 		}()
 
+		// 按序号推送：跳过已推送的消息，序号出现缺口时先从历史补齐
+		send := func(msg *model.Message) bool {
+			seq := store.MessageSeq(msg.ID)
+			if seq <= lastSeq {
+				return true
+			}
+			pending := []*model.Message{msg}
+			if seq > lastSeq+1 {
+				pending = r.messages.After(channel, lastSeq, int(seq-lastSeq))
+			}
+			for _, m := range pending {
+				select {
+				case msgChan <- m:
+					lastSeq = store.MessageSeq(m.ID)
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+
+		// 先回放历史消息，再切换到实时推送
+		for _, msg := range r.messages.After(channel, lastSeq, 0) {
+			if !send(msg) {
+				return
+			}
+		}
+
 		for {
 			select {
-			case payload := <-sub.Output:
-				if msg, ok := payload.(*model.Message); ok {
-					select {
-					case msgChan <- msg:
-					case <-ctx.Done():
-						return
-					}
-				} else {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					// 订阅已被服务端终止
+					return
+				}
+				msg, ok := payload.(*model.Message)
+				if !ok {
 					zap.L().Error("Payload is not a message")
 					return
 				}
+				if !send(msg) {
+					return
+				}
 			case <-ctx.Done():
 				return
 			}
@@ -110,14 +1274,392 @@ func (r *subscriptionResolver) MessageAdded(ctx context.Context, channel string)
 	return msgChan, nil
 }
 
+// OrderUpdated is the resolver for the orderUpdated field.
+func (r *subscriptionResolver) OrderUpdated(ctx context.Context, instrumentID *string, accountID *string) (<-chan *model.Order, error) {
+	// 未指定账户时订阅全部账户，仅管理员可用
+	if accountID == nil {
+		if err := r.requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	} else if _, err := r.accessAccount(ctx, *accountID); err != nil {
+		return nil, err
+	}
+	sub, err := r.SubscriptionManager.SubscribeFiltered(ctx, subscriptions.TopicOrders, subscriptions.Filters(instrumentID, accountID))
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	orderChan := make(chan *model.Order, 1)
+
+	go func() {
+		defer close(orderChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				order, ok := payload.(*model.Order)
+				if !ok {
+					zap.L().Error("Payload is not an order")
+					return
+				}
+				select {
+				case orderChan <- order:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return orderChan, nil
+}
+
+// TradingPhaseChanged is the resolver for the tradingPhaseChanged field.
+func (r *subscriptionResolver) TradingPhaseChanged(ctx context.Context, productID *string) (<-chan *model.TradingPhaseEvent, error) {
+	sub, err := r.SubscriptionManager.SubscribeFiltered(ctx, subscriptions.TopicPhases, subscriptions.Filters(productID))
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	eventChan := make(chan *model.TradingPhaseEvent, 1)
+
+	go func() {
+		defer close(eventChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				event, ok := payload.(*model.TradingPhaseEvent)
+				if !ok {
+					zap.L().Error("Payload is not a trading phase event")
+					return
+				}
+				select {
+				case eventChan <- event:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return eventChan, nil
+}
+
+// OrderBookUpdated is the resolver for the orderBookUpdated field.
+func (r *subscriptionResolver) OrderBookUpdated(ctx context.Context, instrumentID string) (<-chan *model.OrderBookUpdate, error) {
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	sub, err := r.SubscriptionManager.Subscribe(ctx, subscriptions.TopicOrderBook, instrumentID)
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	updateChan := make(chan *model.OrderBookUpdate, 1)
+
+	go func() {
+		defer close(updateChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				update, ok := payload.(*model.OrderBookUpdate)
+				if !ok {
+					zap.L().Error("Payload is not an order book update")
+					return
+				}
+				select {
+				case updateChan <- update:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return updateChan, nil
+}
+
+// PositionChanged is the resolver for the positionChanged field.
+func (r *subscriptionResolver) PositionChanged(ctx context.Context, accountID *string, instrumentID *string) (<-chan *model.Position, error) {
+	// 未指定账户时订阅全部账户，仅管理员可用
+	if accountID == nil {
+		if err := r.requireAdmin(ctx); err != nil {
+			return nil, err
+		}
+	} else if _, err := r.accessAccount(ctx, *accountID); err != nil {
+		return nil, err
+	}
+	sub, err := r.SubscriptionManager.SubscribeFiltered(ctx, subscriptions.TopicPositions, subscriptions.Filters(accountID, instrumentID))
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	positionChan := make(chan *model.Position, 1)
+
+	go func() {
+		defer close(positionChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				position, ok := payload.(*model.Position)
+				if !ok {
+					zap.L().Error("Payload is not a position")
+					return
+				}
+				select {
+				case positionChan <- position:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return positionChan, nil
+}
+
+// Quotes is the resolver for the quotes field.
+func (r *subscriptionResolver) Quotes(ctx context.Context, instrumentIds []string, source *model.QuoteSource) (<-chan *model.Quote, error) {
+	if len(instrumentIds) == 0 {
+		return nil, fmt.Errorf("instrumentIds must not be empty")
+	}
+	keys := make([]string, len(instrumentIds))
+	for i, id := range instrumentIds {
+		if _, ok := r.InstrumentCatalog.Get(id); !ok {
+			return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, id)
+		}
+		keys[i] = quoteKey(id, quoteSource(source))
+	}
+	ctx, err := r.streamContext(ctx, subscriptions.TopicQuotes, strings.Join(keys, ","))
+	if err != nil {
+		return nil, err
+	}
+	sub := r.QuoteHub.Subscribe(keys)
+
+	quoteChan := make(chan *model.Quote, 1)
+
+	go func() {
+		defer close(quoteChan)
+		defer sub.Close()
+
+		for {
+			quotes, ok := sub.Next(ctx)
+			if !ok {
+				return
+			}
+			for _, quote := range quotes {
+				select {
+				case quoteChan <- quote:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return quoteChan, nil
+}
+
+// CandleUpdated is the resolver for the candleUpdated field.
+func (r *subscriptionResolver) CandleUpdated(ctx context.Context, instrumentID string, interval string) (<-chan *model.Candle, error) {
+	if _, ok := r.InstrumentCatalog.Get(instrumentID); !ok {
+		return nil, fmt.Errorf("%w: %s", instrument.ErrUnknownInstrument, instrumentID)
+	}
+	d, err := candle.ParseInterval(interval)
+	if err != nil {
+		return nil, err
+	}
+	if !r.candles.Supports(d) {
+		return nil, fmt.Errorf("%w: %s", candle.ErrUnsupportedInterval, interval)
+	}
+	key := candleKey(instrumentID, d)
+	ctx, err = r.streamContext(ctx, subscriptions.TopicCandles, key)
+	if err != nil {
+		return nil, err
+	}
+	sub := r.candleHub.Subscribe([]string{key})
+
+	candleChan := make(chan *model.Candle, 1)
+
+	go func() {
+		defer close(candleChan)
+		defer sub.Close()
+
+		for {
+			bars, ok := sub.Next(ctx)
+			if !ok {
+				return
+			}
+			for _, bar := range bars {
+				select {
+				case candleChan <- bar:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return candleChan, nil
+}
+
+// AlertTriggered is the resolver for the alertTriggered field.
+func (r *subscriptionResolver) AlertTriggered(ctx context.Context) (<-chan *model.PriceAlert, error) {
+	userID, err := requireUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := r.SubscriptionManager.Subscribe(ctx, subscriptions.TopicAlerts, userID)
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	alertChan := make(chan *model.PriceAlert, 1)
+
+	go func() {
+		defer close(alertChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				a, ok := payload.(*model.PriceAlert)
+				if !ok {
+					zap.L().Error("Payload is not a price alert")
+					return
+				}
+				select {
+				case alertChan <- a:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return alertChan, nil
+}
+
+// ApprovalUpdated is the resolver for the approvalUpdated field.
+func (r *subscriptionResolver) ApprovalUpdated(ctx context.Context) (<-chan *model.Order, error) {
+	if _, err := r.requireApprover(ctx); err != nil {
+		return nil, err
+	}
+	sub, err := r.SubscriptionManager.Subscribe(ctx, subscriptions.TopicApprovals, subscriptions.AnyChannel)
+	if err != nil {
+		zap.L().Error("Subscribe failed", zap.Error(err))
+		return nil, err
+	}
+
+	orderChan := make(chan *model.Order, 1)
+
+	go func() {
+		defer close(orderChan)
+
+		for {
+			select {
+			case payload, ok := <-sub.Output:
+				if !ok {
+					return
+				}
+				order, ok := payload.(*model.Order)
+				if !ok {
+					zap.L().Error("Payload is not an order")
+					return
+				}
+				select {
+				case orderChan <- order:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return orderChan, nil
+}
+
 // User is the resolver for the user field.
 func (r *todoResolver) User(ctx context.Context, obj *model.Todo) (*model.User, error) {
-	return &model.User{ID: obj.UserID, Name: "user " + obj.UserID}, nil
+	user, err := r.loadersFor(ctx).User.Load(ctx, obj.UserID)
+	if errors.Is(err, store.ErrUserNotFound) {
+		// 用户只保存在内存中，重启后已有待办的用户可能不存在，返回占位用户以免整个列表报错
+		return missingUser(obj.UserID), nil
+	}
+	return user, err
+}
+
+// DisplayName is the resolver for the displayName field.
+func (r *userResolver) DisplayName(ctx context.Context, obj *model.User, federationRequires map[string]any) (string, error) {
+	// 经网关解析实体时 email 由身份子图通过 representation 传入
+	if email, ok := federationRequires["email"].(string); ok && email != "" {
+		return fmt.Sprintf("%s <%s>", obj.Name, email), nil
+	}
+	return obj.Name, nil
+}
+
+// Todos is the resolver for the todos field.
+func (r *userResolver) Todos(ctx context.Context, obj *model.User, first *int32, after *string) (*model.TodoConnection, error) {
+	todos := r.todos.ListByUser(obj.ID)
+	page, hasNext := store.Paginate(todos, func(t *model.Todo) string { return t.ID }, first, after)
+
+	conn := &model.TodoConnection{
+		Edges:      make([]*model.TodoEdge, 0, len(page)),
+		PageInfo:   &model.PageInfo{HasNextPage: hasNext},
+		TotalCount: int32(len(todos)),
+	}
+	for _, todo := range page {
+		conn.Edges = append(conn.Edges, &model.TodoEdge{Cursor: todo.ID, Node: todo})
+	}
+	if len(page) > 0 {
+		conn.PageInfo.EndCursor = &page[len(page)-1].ID
+	}
+	return conn, nil
 }
 
+// Account returns AccountResolver implementation.
+func (r *Resolver) Account() AccountResolver { return &accountResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Order returns OrderResolver implementation.
+func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
@@ -127,7 +1669,13 @@ func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionRes
 // Todo returns TodoResolver implementation.
 func (r *Resolver) Todo() TodoResolver { return &todoResolver{r} }
 
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type accountResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
 type todoResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
@@ -0,0 +1,377 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/pkg/calendar"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/settlement"
+	"gqlexample/pkg/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	errInvalidTradingDay = errors.New("invalid trading day")
+	errFutureTradingDay  = errors.New("trading day has not started")
+	errTradingDayOpen    = errors.New("trading day has not closed")
+)
+
+// newSettlements 创建结算记录，保存在报表目录下，加载失败时仅保存在内存
+func newSettlements(cfg config.SettlementConfig) *settlement.Registry {
+	path := ""
+	if cfg.Dir != "" {
+		path = filepath.Join(config.ResolvePath(cfg.Dir), "runs.json")
+	}
+	registry, err := settlement.Open(path)
+	if err != nil {
+		zap.L().Error("Failed to load settlement runs, keeping runs in memory", zap.Error(err))
+		registry, _ = settlement.Open("")
+	}
+	return registry
+}
+
+// onPhase 推送交易时段切换，当日最后一次收盘后执行日终结算
+func (r *Resolver) onPhase(e calendar.PhaseEvent) {
+	r.publishPhase(e)
+	if !r.cfg.Settlement.Schedule {
+		return
+	}
+	cal, err := r.tradingCalendar()
+	if err != nil || !cal.IsLastClose(e) {
+		return
+	}
+	tradingDay := cal.TradingDayOf(e.At)
+	go func() {
+		// 多个产品同时收盘时会重复触发，已成功或进行中的结算直接跳过
+		_, err := r.settle(context.Background(), tradingDay, settlement.Scheduled)
+		if err != nil && !errors.Is(err, settlement.ErrAlreadySettled) && !errors.Is(err, settlement.ErrInProgress) {
+			zap.L().Error("Failed to start scheduled settlement", zap.String("tradingDay", tradingDay), zap.Error(err))
+		}
+	}()
+}
+
+// checkSettlementDay 校验交易日格式，且当日最后一次收盘已过
+func (r *Resolver) checkSettlementDay(tradingDay string) error {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return err
+	}
+	day, err := time.ParseInLocation(config.TradingDayLayout, tradingDay, cal.Location())
+	if err != nil {
+		return fmt.Errorf("%w: %q", errInvalidTradingDay, tradingDay)
+	}
+	now := r.now()
+	if tradingDay > cal.TradingDayOf(now) {
+		return fmt.Errorf("%w: %s", errFutureTradingDay, tradingDay)
+	}
+	for _, e := range cal.Transitions(day, day.AddDate(0, 0, 1)) {
+		if cal.IsLastClose(e) && now.Before(e.At) {
+			return fmt.Errorf("%w: %s closes at %s", errTradingDayOpen, tradingDay, e.At.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// settle 执行交易日的结算并保存结果，仅在无法开始结算时返回错误，结算失败记录在结果中
+func (r *Resolver) settle(ctx context.Context, tradingDay string, trigger settlement.Trigger) (settlement.Run, error) {
+	run, err := r.settlements.Begin(tradingDay, trigger, r.now())
+	if errors.Is(err, settlement.ErrInProgress) || errors.Is(err, settlement.ErrAlreadySettled) {
+		return run, errcode.New(store.CodeConflict, err)
+	}
+	if err != nil {
+		return run, err
+	}
+
+	zap.L().Info("Settlement started", zap.String("tradingDay", tradingDay), zap.String("trigger", string(trigger)))
+	err = r.runSettlement(ctx, &run)
+	run, saveErr := r.settlements.Finish(run, r.now(), err)
+	if saveErr != nil {
+		zap.L().Error("Failed to save settlement run", zap.String("tradingDay", tradingDay), zap.Error(saveErr))
+	}
+	if err != nil {
+		zap.L().Error("Settlement failed", zap.String("tradingDay", tradingDay), zap.Error(err))
+		return run, nil
+	}
+	zap.L().Info("Settlement finished", zap.String("tradingDay", tradingDay),
+		zap.Int("cancelled", run.CancelledOrders), zap.String("reports", run.ReportDir))
+	return run, nil
+}
+
+// runSettlement 撤销交易日剩余订单，并将报表写入交易日目录
+// 持仓及账户余额取首次结算时保存的快照，重跑时报表内容不随之后的交易变化
+func (r *Resolver) runSettlement(ctx context.Context, run *settlement.Run) error {
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return err
+	}
+	ofDay := func(at time.Time) bool { return cal.TradingDayOf(at) == run.TradingDay }
+
+	for _, o := range r.orders.List() {
+		if !ofDay(o.CreatedAt) || !(o.IsOpen() || o.Status == model.OrderStatusPendingApproval) {
+			continue
+		}
+		if _, err := r.cancelOrder(ctx, o.Id, store.AnyVersion); err != nil {
+			// 撤单前已成交或被撤销
+			if errors.Is(err, store.ErrOrderClosed) {
+				continue
+			}
+			return err
+		}
+		run.CancelledOrders++
+	}
+
+	var orders []*model.Order
+	var fills []*model.Fill
+	for _, o := range r.orders.List() {
+		if !ofDay(o.CreatedAt) {
+			continue
+		}
+		orders = append(orders, o)
+		for _, f := range r.fills.ByOrder(o.Id) {
+			if ofDay(f.ExecutedAt) {
+				fills = append(fills, f)
+			}
+		}
+	}
+	snapshot, err := r.settlementSnapshot(run)
+	if err != nil {
+		return err
+	}
+
+	// 报表先写入临时目录，全部写完后替换上一次的报表
+	root := config.ResolvePath(r.cfg.Settlement.Dir)
+	if err := utils.MkdirAll(root); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(root, "."+run.TradingDay+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := utils.WriteToCsv(orderRecords(orders), filepath.Join(tmp, "orders.csv")); err != nil {
+		return err
+	}
+	if err := utils.WriteToCsv(fillRecords(fills), filepath.Join(tmp, "fills.csv")); err != nil {
+		return err
+	}
+	if err := utils.WriteToCsv(snapshot.Positions, filepath.Join(tmp, "positions.csv")); err != nil {
+		return err
+	}
+	if err := utils.WriteToCsv(snapshot.Balances, filepath.Join(tmp, "balances.csv")); err != nil {
+		return err
+	}
+	dir := filepath.Join(root, run.TradingDay)
+	if err := replaceDir(tmp, dir); err != nil {
+		return err
+	}
+
+	run.Orders, run.Fills = len(orders), len(fills)
+	run.Positions, run.Accounts = len(snapshot.Positions), len(snapshot.Balances)
+	run.ReportDir = dir
+	return nil
+}
+
+// settlementSnapshot 收盘后的持仓及账户余额快照，随结算记录保存
+type settlementSnapshot struct {
+	Positions []positionRecord `json:"positions"`
+	Balances  []balanceRecord  `json:"balances"`
+}
+
+// settlementSnapshot 返回结算使用的快照，首次结算时在撤销剩余订单后生成并记入 run，重跑时沿用
+func (r *Resolver) settlementSnapshot(run *settlement.Run) (settlementSnapshot, error) {
+	var snapshot settlementSnapshot
+	if !run.SnapshotAt.IsZero() {
+		if err := json.Unmarshal(run.Snapshot, &snapshot); err != nil {
+			return snapshot, fmt.Errorf("invalid settlement snapshot for %s: %w", run.TradingDay, err)
+		}
+		return snapshot, nil
+	}
+
+	snapshot.Positions = r.positionRecords(r.positions.All())
+	snapshot.Balances = r.balanceRecords(r.accounts.List(""))
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return snapshot, err
+	}
+	run.SnapshotAt, run.Snapshot = r.now(), data
+	return snapshot, nil
+}
+
+// replaceDir 将写好的临时目录替换为 dir，原目录在替换成功后删除
+func replaceDir(tmp, dir string) error {
+	old := tmp + ".old"
+	if err := os.Rename(dir, old); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		// 恢复上一次的报表
+		_ = os.Rename(old, dir)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// orderRecord 订单报表，每个订单一行
+type orderRecord struct {
+	ID             string `csv:"id"`
+	OrderID        string `csv:"order_id"`
+	AccountID      string `csv:"account_id"`
+	InstrumentID   string `csv:"instrument_id"`
+	Side           string `csv:"side"`
+	Type           string `csv:"type"`
+	Price          string `csv:"price"`
+	Quantity       string `csv:"quantity"`
+	FilledQuantity string `csv:"filled_quantity"`
+	Status         string `csv:"status"`
+	CreatedAt      string `csv:"created_at"`
+}
+
+func orderRecords(orders []*model.Order) []orderRecord {
+	records := make([]orderRecord, 0, len(orders))
+	for _, o := range orders {
+		rec := orderRecord{
+			ID:             o.Id,
+			OrderID:        o.OrderId,
+			AccountID:      o.AccountId,
+			InstrumentID:   o.InstrumentId,
+			Side:           string(o.Side),
+			Type:           string(o.Type),
+			Quantity:       strconv.Itoa(int(o.Quantity)),
+			FilledQuantity: strconv.Itoa(int(o.FilledQuantity)),
+			Status:         string(o.Status),
+			CreatedAt:      o.CreatedAt.Format(time.RFC3339),
+		}
+		if o.Price != nil {
+			rec.Price = o.Price.Amount.String()
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// fillRecord 成交报表，订单每一方的成交一行
+type fillRecord struct {
+	ID           string `csv:"id"`
+	OrderID      string `csv:"order_id"`
+	AccountID    string `csv:"account_id"`
+	InstrumentID string `csv:"instrument_id"`
+	Side         string `csv:"side"`
+	Price        string `csv:"price"`
+	Quantity     string `csv:"quantity"`
+	Liquidity    string `csv:"liquidity"`
+	ExecutedAt   string `csv:"executed_at"`
+}
+
+func fillRecords(fills []*model.Fill) []fillRecord {
+	records := make([]fillRecord, 0, len(fills))
+	for _, f := range fills {
+		records = append(records, fillRecord{
+			ID:           f.ID,
+			OrderID:      f.OrderID,
+			AccountID:    f.AccountID,
+			InstrumentID: f.InstrumentID,
+			Side:         string(f.Side),
+			Price:        f.Price.String(),
+			Quantity:     strconv.Itoa(int(f.Quantity)),
+			Liquidity:    string(f.Liquidity),
+			ExecutedAt:   f.ExecutedAt.Format(time.RFC3339),
+		})
+	}
+	return records
+}
+
+// positionRecord 持仓快照，按最新成交价计算浮动盈亏
+type positionRecord struct {
+	AccountID     string `csv:"account_id"`
+	InstrumentID  string `csv:"instrument_id"`
+	Quantity      string `csv:"quantity"`
+	AverageCost   string `csv:"average_cost"`
+	RealizedPnl   string `csv:"realized_pnl"`
+	LastPrice     string `csv:"last_price"`
+	UnrealizedPnl string `csv:"unrealized_pnl"`
+}
+
+func (r *Resolver) positionRecords(positions []*model.Position) []positionRecord {
+	records := make([]positionRecord, 0, len(positions))
+	for _, p := range positions {
+		p = r.markPosition(p)
+		rec := positionRecord{
+			AccountID:    p.AccountID,
+			InstrumentID: p.InstrumentID,
+			Quantity:     strconv.Itoa(int(p.Quantity)),
+			AverageCost:  p.AverageCost.String(),
+			RealizedPnl:  p.RealizedPnl.String(),
+		}
+		if p.LastPrice != nil {
+			rec.LastPrice, rec.UnrealizedPnl = p.LastPrice.String(), p.UnrealizedPnl.String()
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+// balanceRecord 账户余额快照
+type balanceRecord struct {
+	AccountID string `csv:"account_id"`
+	OwnerID   string `csv:"owner_id"`
+	Currency  string `csv:"currency"`
+	Available string `csv:"available"`
+	Reserved  string `csv:"reserved"`
+	Total     string `csv:"total"`
+}
+
+func (r *Resolver) balanceRecords(accounts []*model.Account) []balanceRecord {
+	records := make([]balanceRecord, 0, len(accounts))
+	for _, a := range accounts {
+		b := r.accountBalance(a)
+		records = append(records, balanceRecord{
+			AccountID: a.ID,
+			OwnerID:   a.OwnerID,
+			Currency:  a.Currency,
+			Available: b.Available.String(),
+			Reserved:  b.Reserved.String(),
+			Total:     b.Total.String(),
+		})
+	}
+	return records
+}
+
+func settlementRun(run settlement.Run) *model.SettlementRun {
+	result := &model.SettlementRun{
+		TradingDay:      run.TradingDay,
+		Trigger:         model.SettlementTrigger(run.Trigger),
+		Status:          model.SettlementStatus(run.Status),
+		Attempts:        int32(run.Attempts),
+		StartedAt:       run.StartedAt,
+		CancelledOrders: int32(run.CancelledOrders),
+		Orders:          int32(run.Orders),
+		Fills:           int32(run.Fills),
+		Positions:       int32(run.Positions),
+		Accounts:        int32(run.Accounts),
+	}
+	if !run.FinishedAt.IsZero() {
+		finishedAt := run.FinishedAt
+		result.FinishedAt = &finishedAt
+	}
+	if run.ReportDir != "" {
+		result.ReportDir = &run.ReportDir
+	}
+	if run.Error != "" {
+		result.Error = &run.Error
+	}
+	if !run.SnapshotAt.IsZero() {
+		snapshotAt := run.SnapshotAt
+		result.SnapshotAt = &snapshotAt
+	}
+	return result
+}
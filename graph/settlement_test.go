@@ -0,0 +1,103 @@
+package graph
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/settlement"
+	"gqlexample/pkg/utils"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettlement_RunAndRerun(t *testing.T) {
+	r := newTestResolver(t)
+	now := tradingTime()
+	r.now = func() time.Time { return now }
+	admin := middware.WithUserID(context.Background(), "admin")
+	ctx := newTestAccount(t, r, "CASH1", "A1", "A2")
+
+	// 一笔成交及一笔挂单
+	_, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "A1"))
+	require.NoError(t, err)
+	sell := newOrderInput("600000.SH", "A2")
+	sell.Side = model.OrderSideSell
+	_, err = r.Mutation().PlaceOrder(ctx, *sell)
+	require.NoError(t, err)
+	resting, err := r.Mutation().PlaceOrder(ctx, *newOrderInput("600000.SH", "CASH1"))
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusNew, resting.Status)
+
+	_, err = r.Mutation().RunSettlement(ctx, "20240102")
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().RunSettlement(admin, "2024-01-02")
+	assert.ErrorIs(t, err, errInvalidTradingDay)
+	_, err = r.Mutation().RunSettlement(admin, "20240103")
+	assert.ErrorIs(t, err, errFutureTradingDay)
+	_, err = r.Mutation().RunSettlement(admin, "20240102")
+	assert.ErrorIs(t, err, errTradingDayOpen)
+
+	// 收盘后撤销剩余订单并释放资金占用，写入报表
+	now = now.Add(5 * time.Hour)
+	run, err := r.Mutation().RunSettlement(admin, "20240102")
+	require.NoError(t, err)
+	assert.Equal(t, model.SettlementStatusSucceeded, run.Status)
+	assert.Equal(t, model.SettlementTriggerManual, run.Trigger)
+	require.NotNil(t, run.SnapshotAt)
+	assert.Equal(t, int32(1), run.CancelledOrders)
+	assert.Equal(t, int32(3), run.Orders)
+	assert.Equal(t, int32(2), run.Fills)
+	assert.Equal(t, int32(2), run.Positions)
+	assert.Equal(t, int32(3), run.Accounts)
+	require.NotNil(t, run.ReportDir)
+
+	order, _ := r.orders.Get(resting.Id)
+	assert.Equal(t, model.OrderStatusCancelled, order.Status)
+	balances, err := utils.ReadFromCsv[balanceRecord](filepath.Join(*run.ReportDir, "balances.csv"))
+	require.NoError(t, err)
+	require.Len(t, balances, 3)
+	for _, b := range balances {
+		assert.Equal(t, "0", b.Reserved)
+	}
+	orders, err := utils.ReadFromCsv[orderRecord](filepath.Join(*run.ReportDir, "orders.csv"))
+	require.NoError(t, err)
+	require.Len(t, orders, 3)
+	assert.Equal(t, string(model.OrderStatusCancelled), orders[2].Status)
+
+	// 定时结算不重复执行，手工结算可重跑
+	_, err = r.settle(context.Background(), "20240102", settlement.Scheduled)
+	assert.ErrorIs(t, err, settlement.ErrAlreadySettled)
+
+	// 重跑沿用首次结算的快照，不含之后的资金变动
+	_, err = r.Mutation().Deposit(admin, "CASH1", decimal.NewFromInt(100), nil)
+	require.NoError(t, err)
+	run, err = r.Mutation().RunSettlement(admin, "20240102")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), run.Attempts)
+	assert.Zero(t, run.CancelledOrders)
+	assert.Equal(t, int32(3), run.Orders)
+	rerun, err := utils.ReadFromCsv[balanceRecord](filepath.Join(*run.ReportDir, "balances.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, balances, rerun)
+
+	// 报表写完后替换，不留下临时目录
+	entries, err := os.ReadDir(filepath.Dir(*run.ReportDir))
+	require.NoError(t, err)
+	for _, e := range entries {
+		assert.False(t, strings.HasPrefix(e.Name(), "."), e.Name())
+	}
+
+	runs, err := r.Query().SettlementRuns(admin)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "20240102", runs[0].TradingDay)
+}
@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/event"
+	"gqlexample/pkg/simulator"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+var errSimulatorDisabled = errors.New("market simulator is not enabled")
+
+// initSimulator 按配置创建行情模拟器，模拟行情经事件总线转发到 SIMULATED 来源的行情订阅
+// 模拟行情不更新 K 线、持仓盈亏及价格提醒；仅在交易时段内生成行情，创建后需调用 Start 启动
+func (r *Resolver) initSimulator(cfg config.SimulatorConfig, bus *event.EventBus) error {
+	ids := cfg.Instruments
+	if len(ids) == 0 {
+		for _, inst := range r.InstrumentCatalog.List(nil) {
+			ids = append(ids, inst.ID)
+		}
+	}
+
+	instruments := make([]simulator.Instrument, 0, len(ids))
+	for _, id := range ids {
+		inst, ok := r.InstrumentCatalog.Get(id)
+		if !ok {
+			return fmt.Errorf("simulated instrument %s is not in catalog", id)
+		}
+		price, ok := cfg.Prices[id]
+		if !ok {
+			price = cfg.DefaultPrice
+		}
+		initial, err := decimal.NewFromString(price)
+		if err != nil {
+			return fmt.Errorf("invalid simulator price for %s: %w", id, err)
+		}
+		interval, ok := cfg.Intervals[id]
+		if !ok {
+			interval = cfg.Interval
+		}
+		instruments = append(instruments, simulator.Instrument{
+			ID:       id,
+			TickSize: inst.TickSize,
+			LotSize:  inst.LotSize,
+			Price:    initial,
+			Interval: interval,
+		})
+	}
+
+	// 取消订阅时关闭 quotes，转发协程随之退出
+	quotes := make(event.DataChannel, 64)
+	subID := bus.Subscribe(simulator.TopicQuotes, quotes)
+	r.stopSimulatorFeed = func() { bus.Unsubscribe(simulator.TopicQuotes, subID) }
+	go func() {
+		for e := range quotes {
+			if q, ok := e.Data.(simulator.Quote); ok {
+				r.QuoteHub.Publish(quoteKey(q.InstrumentID, model.QuoteSourceSimulated), simulatedQuote(q))
+			}
+		}
+	}()
+
+	r.Simulator = simulator.New(instruments, simulator.Options{
+		Seed:       cfg.Seed,
+		Volatility: cfg.Volatility,
+		Bus:        bus,
+		IsOpen:     r.isInstrumentOpen,
+		Now:        func() time.Time { return r.now() },
+	})
+	return nil
+}
+
+// isInstrumentOpen 合约可交易且所属产品处于交易时段
+func (r *Resolver) isInstrumentOpen(instrumentID string, at time.Time) bool {
+	inst, ok := r.InstrumentCatalog.Get(instrumentID)
+	if !ok || inst.TradingStatus != model.TradingStatusTrading {
+		return false
+	}
+	cal, err := r.tradingCalendar()
+	if err != nil {
+		return false
+	}
+	open, err := cal.IsOpen(inst.Product, at)
+	if err != nil {
+		zap.L().Warn("Failed to check trading phase", zap.String("instrument", instrumentID), zap.Error(err))
+	}
+	return open
+}
+
+// simulator 返回行情模拟器，未启用时返回错误
+func (r *Resolver) simulator() (*simulator.Simulator, error) {
+	if r.Simulator == nil {
+		return nil, errSimulatorDisabled
+	}
+	return r.Simulator, nil
+}
+
+func simulatedQuote(q simulator.Quote) *model.Quote {
+	return &model.Quote{
+		InstrumentID: q.InstrumentID,
+		Bid:          &q.Bid,
+		BidSize:      &q.BidSize,
+		Ask:          &q.Ask,
+		AskSize:      &q.AskSize,
+		Last:         &q.Last,
+		Timestamp:    q.At,
+		Source:       model.QuoteSourceSimulated,
+	}
+}
+
+// quoteKey 行情在 QuoteHub 中的键，模拟行情与订单簿行情分开保存
+func quoteKey(instrumentID string, source model.QuoteSource) string {
+	if source == model.QuoteSourceSimulated {
+		return "simulated:" + instrumentID
+	}
+	return instrumentID
+}
+
+// quoteSource 未指定行情来源时为订单簿行情
+func quoteSource(source *model.QuoteSource) model.QuoteSource {
+	if source == nil {
+		return model.QuoteSourceBook
+	}
+	return *source
+}
+
+func simulatorStatus(s simulator.Status) *model.SimulatorStatus {
+	return &model.SimulatorStatus{
+		Running:     s.Running,
+		Seed:        s.Seed,
+		Volatility:  s.Volatility,
+		Instruments: s.Instruments,
+	}
+}
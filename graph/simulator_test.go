@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/event"
+	"gqlexample/pkg/middware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulator_AdminMutations(t *testing.T) {
+	r := newTestResolver(t)
+	r.now = tradingTime
+	admin := middware.WithUserID(context.Background(), "admin")
+
+	_, err := r.Mutation().StartSimulator(admin)
+	assert.ErrorIs(t, err, errSimulatorDisabled)
+
+	require.NoError(t, r.initSimulator(config.SimulatorConfig{
+		Seed:         1,
+		Instruments:  []string{"600000.SH"},
+		Prices:       map[string]string{"600000.SH": "10.00"},
+		DefaultPrice: "10.00",
+	}, event.NewEventBus()))
+
+	// 非管理员不可操作
+	_, err = r.Mutation().InjectPriceJump(middware.WithUserID(context.Background(), "U1"), "600000.SH", 10)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+
+	quote, err := r.Mutation().InjectPriceJump(admin, "600000.SH", 10)
+	require.NoError(t, err)
+	assert.Equal(t, "11", quote.Last.String())
+	assert.Equal(t, "10.99", quote.Bid.String())
+
+	// 模拟行情经事件总线进入模拟来源的行情订阅，不影响订单簿行情及 K 线
+	simulated := model.QuoteSourceSimulated
+	require.Eventually(t, func() bool {
+		q, _ := r.Query().Quote(admin, "600000.SH", &simulated)
+		return q != nil && q.Last.String() == "11"
+	}, time.Second, 10*time.Millisecond)
+	q, err := r.Query().Quote(admin, "600000.SH", nil)
+	require.NoError(t, err)
+	assert.Nil(t, q)
+	candles, err := r.Query().Candles(admin, "600000.SH", "1m", nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, candles)
+
+	status, err := r.Mutation().SetSimulatorVolatility(admin, 0.02, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0.02, status.Volatility)
+	assert.False(t, status.Running)
+	assert.Equal(t, []string{"600000.SH"}, status.Instruments)
+	assert.Equal(t, int64(1), status.Seed)
+
+	// 交易时段外不生成行情
+	assert.Len(t, r.Simulator.Tick(tradingTime()), 1)
+	assert.Empty(t, r.Simulator.Tick(tradingTime().Add(6*time.Hour)))
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"sync"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountExists   = errors.New("account already exists")
+)
+
+// AccountStore 内存资金账户存储
+type AccountStore struct {
+	mu       sync.RWMutex
+	accounts map[string]*model.Account
+	ids      []string
+}
+
+func NewAccountStore() *AccountStore {
+	return &AccountStore{accounts: make(map[string]*model.Account)}
+}
+
+// Create 保存账户，账户 ID 已存在时返回错误
+func (s *AccountStore) Create(account *model.Account) (*model.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[account.ID]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrAccountExists, account.ID)
+	}
+	created := *account
+	s.accounts[account.ID] = &created
+	s.ids = append(s.ids, account.ID)
+	return account, nil
+}
+
+// Get 按 ID 查询账户
+func (s *AccountStore) Get(id string) (*model.Account, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	account, ok := s.accounts[id]
+	return account, ok
+}
+
+// List 按创建顺序返回账户，ownerID 为空时返回全部账户
+func (s *AccountStore) List(ownerID string) []*model.Account {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []*model.Account{}
+	for _, id := range s.ids {
+		if a := s.accounts[id]; ownerID == "" || a.OwnerID == ownerID {
+			result = append(result, a)
+		}
+	}
+	return result
+}
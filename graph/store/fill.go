@@ -0,0 +1,42 @@
+package store
+
+import (
+	"gqlexample/graph/model"
+	"strconv"
+	"sync"
+)
+
+// FillStore 内存成交记录，按订单索引
+type FillStore struct {
+	mu      sync.RWMutex
+	byOrder map[string][]*model.Fill
+	seq     int64
+}
+
+func NewFillStore() *FillStore {
+	return &FillStore{
+		byOrder: make(map[string][]*model.Fill),
+	}
+}
+
+// Add 分配 ID 并保存成交
+func (s *FillStore) Add(fill *model.Fill) *model.Fill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	fill.ID = strconv.FormatInt(s.seq, 10)
+	s.byOrder[fill.OrderID] = append(s.byOrder[fill.OrderID], fill)
+	return fill
+}
+
+// ByOrder 按成交顺序返回订单的全部成交
+func (s *FillStore) ByOrder(orderID string) []*model.Fill {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fills := s.byOrder[orderID]
+	result := make([]*model.Fill, len(fills))
+	copy(result, fills)
+	return result
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"gqlexample/pkg/utils"
+	"os"
+	"path/filepath"
+)
+
+// journal 以 JSON Lines 追加写入实体快照，重新加载时同一实体以最后一行为准
+// path 为空时不持久化
+type journal[T any] struct {
+	path string
+}
+
+// openJournal 按写入顺序回放日志中的全部快照
+func openJournal[T any](path string, replay func(*T)) (*journal[T], error) {
+	j := &journal[T]{path: path}
+	if path == "" || utils.NotExistFile(path) {
+		return j, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		v := new(T)
+		if err := json.Unmarshal(scanner.Bytes(), v); err != nil {
+			return nil, err
+		}
+		replay(v)
+	}
+	return j, scanner.Err()
+}
+
+// append 写入一条快照，调用方在写入成功后才替换内存中的实体
+func (j *journal[T]) append(v *T) error {
+	if j.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := utils.MkdirAll(filepath.Dir(j.path)); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
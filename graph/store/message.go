@@ -0,0 +1,144 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"gqlexample/graph/model"
+	"gqlexample/pkg/utils"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MessageStore 按频道保存消息历史，每个频道的序号从 1 开始单调递增
+// dir 非空时以 JSON Lines 追加写入 <dir>/<channel>.jsonl，启动时重新加载
+type MessageStore struct {
+	mu       sync.RWMutex
+	dir      string
+	channels map[string][]*model.Message
+}
+
+func NewMessageStore(dir string) (*MessageStore, error) {
+	s := &MessageStore{
+		dir:      dir,
+		channels: make(map[string][]*model.Message),
+	}
+	if dir == "" {
+		return s, nil
+	}
+
+	if err := utils.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := s.load(file); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Append 分配序号并保存消息
+func (s *MessageStore) Append(msg *model.Message) (*model.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.channels[msg.Channel]
+	msg.ID = strconv.Itoa(len(history) + 1)
+
+	if s.dir != "" {
+		if err := s.persist(msg); err != nil {
+			return nil, err
+		}
+	}
+
+	s.channels[msg.Channel] = append(history, msg)
+	return msg, nil
+}
+
+// After 返回频道内序号大于 after 的消息，limit <= 0 表示不限制
+func (s *MessageStore) After(channel string, after int64, limit int) []*model.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.channels[channel]
+	if after < 0 {
+		after = 0
+	}
+	if after >= int64(len(history)) {
+		return nil
+	}
+
+	page := history[after:]
+	if limit > 0 && limit < len(page) {
+		page = page[:limit]
+	}
+	result := make([]*model.Message, len(page))
+	copy(result, page)
+	return result
+}
+
+// LastSeq 返回频道最新消息的序号
+func (s *MessageStore) LastSeq(channel string) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return int64(len(s.channels[channel]))
+}
+
+// MessageSeq 解析消息 ID 中的序号，无效 ID 返回 0
+func MessageSeq(id string) int64 {
+	seq, _ := strconv.ParseInt(id, 10, 64)
+	return seq
+}
+
+func (s *MessageStore) persist(msg *model.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(s.channelFile(msg.Channel), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+func (s *MessageStore) load(file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	channel, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(file), ".jsonl"))
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var msg model.Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			return err
+		}
+		msg.Channel = channel
+		s.channels[channel] = append(s.channels[channel], &msg)
+	}
+	return scanner.Err()
+}
+
+func (s *MessageStore) channelFile(channel string) string {
+	return filepath.Join(s.dir, url.PathEscape(channel)+".jsonl")
+}
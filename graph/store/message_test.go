@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+
+	"gqlexample/graph/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageStore_Reload(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := NewMessageStore(dir)
+	assert.NoError(t, err)
+	for _, text := range []string{"a", "b", "c"} {
+		_, err := s.Append(&model.Message{Channel: "room/1", Text: text})
+		assert.NoError(t, err)
+	}
+	_, err = s.Append(&model.Message{Channel: "other", Text: "x"})
+	assert.NoError(t, err)
+
+	// 重新加载后序号和内容保持不变
+	s, err = NewMessageStore(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), s.LastSeq("room/1"))
+	assert.Equal(t, int64(1), s.LastSeq("other"))
+
+	page := s.After("room/1", 1, 1)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "2", page[0].ID)
+	assert.Equal(t, "b", page[0].Text)
+	assert.Empty(t, s.After("room/1", 3, 0))
+
+	msg, err := s.Append(&model.Message{Channel: "room/1", Text: "d"})
+	assert.NoError(t, err)
+	assert.Equal(t, "4", msg.ID)
+}
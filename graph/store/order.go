@@ -0,0 +1,131 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"strconv"
+	"sync"
+)
+
+var (
+	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderClosed   = errors.New("order is no longer open")
+)
+
+// OrderStore 订单存储，按创建顺序保存，可选持久化到日志文件
+// 保存的订单视为不可变快照，修改通过 Update 复制后替换
+type OrderStore struct {
+	mu      sync.RWMutex
+	orders  map[string]*model.Order
+	ids     []string
+	seq     int64
+	journal *journal[model.Order]
+}
+
+func NewOrderStore() *OrderStore {
+	s, _ := OpenOrderStore("")
+	return s
+}
+
+// OpenOrderStore 打开持久化的订单存储并加载已保存的订单，path 为空时仅保存在内存
+// 订单簿及资金占用不持久化，重新加载时未结束的订单视为已撤销
+func OpenOrderStore(path string) (*OrderStore, error) {
+	s := &OrderStore{
+		orders: make(map[string]*model.Order),
+	}
+	j, err := openJournal(path, s.replay)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = j
+
+	for _, id := range s.ids {
+		order := s.orders[id]
+		if !order.IsOpen() && order.Status != model.OrderStatusPendingApproval {
+			continue
+		}
+		cancelled := *order
+		cancelled.Status = model.OrderStatusCancelled
+		cancelled.ApprovalExpiresAt = nil
+		cancelled.Version++
+		if err := j.append(&cancelled); err != nil {
+			return nil, err
+		}
+		s.orders[id] = &cancelled
+	}
+	return s, nil
+}
+
+func (s *OrderStore) replay(order *model.Order) {
+	if _, ok := s.orders[order.Id]; !ok {
+		s.ids = append(s.ids, order.Id)
+	}
+	s.orders[order.Id] = order
+	if seq, err := strconv.ParseInt(order.Id, 10, 64); err == nil && seq > s.seq {
+		s.seq = seq
+	}
+}
+
+// Create 分配 ID 并保存订单，初始版本为 1
+func (s *OrderStore) Create(order *model.Order) (*model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order.Id = strconv.FormatInt(s.seq+1, 10)
+	order.Version = 1
+	if err := s.journal.append(order); err != nil {
+		return nil, err
+	}
+	s.seq++
+	s.orders[order.Id] = order
+	s.ids = append(s.ids, order.Id)
+	return order, nil
+}
+
+// Update 复制订单并应用修改，fn 返回错误时放弃修改
+// expectedVersion 与当前版本不一致时返回冲突错误，AnyVersion 表示不校验；修改成功后版本加一
+// 校验版本、写入日志及替换快照在同一锁内完成，写入失败时不修改
+func (s *OrderStore) Update(id string, expectedVersion int32, fn func(*model.Order) error) (*model.Order, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
+	}
+	if err := checkVersion("order", id, expectedVersion, current.Version, current); err != nil {
+		return nil, err
+	}
+
+	updated := current.Clone()
+	if err := fn(updated); err != nil {
+		return nil, err
+	}
+	updated.Version = current.Version + 1
+	if err := s.journal.append(updated); err != nil {
+		return nil, err
+	}
+	s.orders[id] = updated
+	return updated, nil
+}
+
+// Get 按 ID 查询订单
+func (s *OrderStore) Get(id string) (*model.Order, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	order, ok := s.orders[id]
+	return order, ok
+}
+
+// List 按创建顺序返回全部订单
+func (s *OrderStore) List() []*model.Order {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Order, 0, len(s.ids))
+	for _, id := range s.ids {
+		result = append(result, s.orders[id])
+	}
+	return result
+}
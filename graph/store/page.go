@@ -0,0 +1,24 @@
+package store
+
+// Paginate 按游标分页，after 为上一页最后一项的游标，first 为空时返回剩余全部
+func Paginate[T any](items []T, cursor func(T) string, first *int32, after *string) (page []T, hasNext bool) {
+	start := 0
+	if after != nil && *after != "" {
+		for i, item := range items {
+			if cursor(item) == *after {
+				start = i + 1
+				break
+			}
+		}
+	}
+
+	end := len(items)
+	if first != nil && *first >= 0 && start+int(*first) < end {
+		end = start + int(*first)
+	}
+	if start > end {
+		start = end
+	}
+
+	return items[start:end], end < len(items)
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"gqlexample/graph/model"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// averageCostScale 持仓均价保留的小数位数
+	averageCostScale = 8
+	// costScale 部分平仓结转成本时保留的小数位数
+	costScale = 16
+)
+
+// PositionStore 按账户和合约维护净持仓
+// 保存的持仓视为不可变快照，每次成交复制后替换
+type PositionStore struct {
+	mu        sync.RWMutex
+	positions map[string]map[string]*model.Position // accountID -> instrumentID -> 持仓
+}
+
+func NewPositionStore() *PositionStore {
+	return &PositionStore{
+		positions: make(map[string]map[string]*model.Position),
+	}
+}
+
+// Apply 按成交更新持仓并返回新快照
+// 同向成交累加持仓成本，反向成交按比例结转成本并计算已实现盈亏，反手部分以成交价开仓
+func (s *PositionStore) Apply(fill *model.Fill) *model.Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	accounts, ok := s.positions[fill.AccountID]
+	if !ok {
+		accounts = make(map[string]*model.Position)
+		s.positions[fill.AccountID] = accounts
+	}
+	p := model.Position{AccountID: fill.AccountID, InstrumentID: fill.InstrumentID}
+	if current, ok := accounts[fill.InstrumentID]; ok {
+		p = *current
+	}
+
+	qty := fill.Quantity
+	if fill.Side == model.OrderSideSell {
+		qty = -qty
+	}
+
+	if p.Quantity == 0 || (p.Quantity > 0) == (qty > 0) {
+		p.Cost = p.Cost.Add(fill.Price.Mul(decimal.NewFromInt32(qty)))
+		p.Quantity += qty
+	} else {
+		// 平仓部分按比例结转成本
+		closed := min(abs32(p.Quantity), abs32(qty))
+		closedCost := p.Cost.Mul(decimal.NewFromInt32(closed)).DivRound(decimal.NewFromInt32(abs32(p.Quantity)), costScale)
+		proceeds := fill.Price.Mul(decimal.NewFromInt32(closed))
+		if p.Quantity < 0 {
+			proceeds = proceeds.Neg()
+		}
+		p.RealizedPnl = p.RealizedPnl.Add(proceeds.Sub(closedCost))
+
+		flipped := abs32(qty) > abs32(p.Quantity)
+		p.Quantity += qty
+		p.Cost = p.Cost.Sub(closedCost)
+		switch {
+		case p.Quantity == 0:
+			p.Cost = decimal.Zero
+		case flipped:
+			p.Cost = fill.Price.Mul(decimal.NewFromInt32(p.Quantity))
+		}
+	}
+
+	p.AverageCost = decimal.Zero
+	if p.Quantity != 0 {
+		p.AverageCost = p.Cost.DivRound(decimal.NewFromInt32(p.Quantity), averageCostScale)
+	}
+	p.UpdatedAt = fill.ExecutedAt
+
+	accounts[fill.InstrumentID] = &p
+	return &p
+}
+
+// Get 查询账户在合约上的持仓
+func (s *PositionStore) Get(accountID, instrumentID string) (*model.Position, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	p, ok := s.positions[accountID][instrumentID]
+	return p, ok
+}
+
+// List 返回账户的全部持仓（含已平仓），按合约排序
+func (s *PositionStore) List(accountID string) []*model.Position {
+	s.mu.RLock()
+	result := make([]*model.Position, 0, len(s.positions[accountID]))
+	for _, p := range s.positions[accountID] {
+		result = append(result, p)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].InstrumentID < result[j].InstrumentID })
+	return result
+}
+
+// All 返回全部账户的持仓，按账户及合约排序
+func (s *PositionStore) All() []*model.Position {
+	s.mu.RLock()
+	var result []*model.Position
+	for _, accounts := range s.positions {
+		for _, p := range accounts {
+			result = append(result, p)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AccountID != result[j].AccountID {
+			return result[i].AccountID < result[j].AccountID
+		}
+		return result[i].InstrumentID < result[j].InstrumentID
+	})
+	return result
+}
+
+func abs32(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"testing"
+
+	"gqlexample/graph/model"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func fill(side model.OrderSide, price string, qty int32) *model.Fill {
+	return &model.Fill{
+		AccountID:    "A1",
+		InstrumentID: "600000.SH",
+		Side:         side,
+		Price:        decimal.RequireFromString(price),
+		Quantity:     qty,
+	}
+}
+
+func TestPositionStore_Apply(t *testing.T) {
+	s := NewPositionStore()
+
+	// 加仓按加权平均计算均价
+	s.Apply(fill(model.OrderSideBuy, "10.00", 100))
+	p := s.Apply(fill(model.OrderSideBuy, "10.30", 200))
+	assert.Equal(t, int32(300), p.Quantity)
+	assert.Equal(t, "10.2", p.AverageCost.String())
+
+	// 减仓按均价实现盈亏，均价不变
+	p = s.Apply(fill(model.OrderSideSell, "10.50", 100))
+	assert.Equal(t, int32(200), p.Quantity)
+	assert.Equal(t, "10.2", p.AverageCost.String())
+	assert.Equal(t, "30", p.RealizedPnl.String())
+
+	// 反手后剩余部分以成交价开空
+	p = s.Apply(fill(model.OrderSideSell, "10.00", 300))
+	assert.Equal(t, int32(-100), p.Quantity)
+	assert.Equal(t, "10", p.AverageCost.String())
+	assert.Equal(t, "-10", p.RealizedPnl.String())
+
+	// 空头平仓
+	p = s.Apply(fill(model.OrderSideBuy, "9.50", 100))
+	assert.Zero(t, p.Quantity)
+	assert.True(t, p.AverageCost.IsZero())
+	assert.Equal(t, "40", p.RealizedPnl.String())
+
+	list := s.List("A1")
+	assert.Len(t, list, 1)
+	assert.Empty(t, s.List("A2"))
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var ErrTodoNotFound = errors.New("todo not found")
+
+// TodoStore 待办存储，按创建顺序保存，可选持久化到日志文件
+// 保存的待办视为不可变快照，修改通过 Update 复制后替换
+type TodoStore struct {
+	mu      sync.RWMutex
+	todos   []*model.Todo
+	index   map[string]int // ID 到 todos 下标
+	seq     int64
+	journal *journal[model.Todo]
+}
+
+func NewTodoStore() *TodoStore {
+	s, _ := OpenTodoStore("")
+	return s
+}
+
+// OpenTodoStore 打开持久化的待办存储并加载已保存的待办，path 为空时仅保存在内存
+func OpenTodoStore(path string) (*TodoStore, error) {
+	s := &TodoStore{
+		index: make(map[string]int),
+	}
+	j, err := openJournal(path, s.replay)
+	if err != nil {
+		return nil, err
+	}
+	s.journal = j
+	return s, nil
+}
+
+func (s *TodoStore) replay(todo *model.Todo) {
+	if i, ok := s.index[todo.ID]; ok {
+		s.todos[i] = todo
+		return
+	}
+	s.index[todo.ID] = len(s.todos)
+	s.todos = append(s.todos, todo)
+	if seq, err := strconv.ParseInt(strings.TrimPrefix(todo.ID, "T"), 10, 64); err == nil && seq > s.seq {
+		s.seq = seq
+	}
+}
+
+// Create 分配 ID 并保存待办，初始版本为 1
+func (s *TodoStore) Create(todo *model.Todo) (*model.Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	todo.ID = "T" + strconv.FormatInt(s.seq+1, 10)
+	todo.Version = 1
+	if err := s.journal.append(todo); err != nil {
+		return nil, err
+	}
+	s.seq++
+	s.index[todo.ID] = len(s.todos)
+	s.todos = append(s.todos, todo)
+	return todo, nil
+}
+
+// Get 按 ID 查询待办
+func (s *TodoStore) Get(id string) (*model.Todo, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i, ok := s.index[id]
+	if !ok {
+		return nil, false
+	}
+	return s.todos[i], true
+}
+
+// Update 复制待办并应用修改，版本校验及持久化规则同 OrderStore.Update
+func (s *TodoStore) Update(id string, expectedVersion int32, fn func(*model.Todo) error) (*model.Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.index[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTodoNotFound, id)
+	}
+	current := s.todos[i]
+	if err := checkVersion("todo", id, expectedVersion, current.Version, current); err != nil {
+		return nil, err
+	}
+
+	updated := *current
+	if err := fn(&updated); err != nil {
+		return nil, err
+	}
+	updated.Version = current.Version + 1
+	if err := s.journal.append(&updated); err != nil {
+		return nil, err
+	}
+	s.todos[i] = &updated
+	return &updated, nil
+}
+
+// List 按创建顺序返回全部待办
+func (s *TodoStore) List() []*model.Todo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.Todo, len(s.todos))
+	copy(result, s.todos)
+	return result
+}
+
+// ListByUser 按创建顺序返回指定用户的待办
+func (s *TodoStore) ListByUser(userID string) []*model.Todo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*model.Todo
+	for _, todo := range s.todos {
+		if todo.UserID == userID {
+			result = append(result, todo)
+		}
+	}
+	return result
+}
@@ -0,0 +1,165 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/graph/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrUsernameTaken   = errors.New("username already taken")
+	ErrUserDeactivated = errors.New("user is deactivated")
+	ErrInvalidUsername = errors.New("username must be 3-32 characters of letters, digits or underscore")
+	ErrInvalidName     = errors.New("name must be 1-64 characters")
+)
+
+const maxNameLength = 64
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,32}$`)
+
+// UserStore 内存用户存储，用户名唯一（不区分大小写）
+type UserStore struct {
+	mu         sync.RWMutex
+	users      map[string]*model.User
+	byUsername map[string]string
+	ids        []string
+	seq        int64
+}
+
+func NewUserStore() *UserStore {
+	return &UserStore{
+		users:      make(map[string]*model.User),
+		byUsername: make(map[string]string),
+	}
+}
+
+// Create 校验并创建用户
+func (s *UserStore) Create(username, name string) (*model.User, error) {
+	if err := validateUser(username, name); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.ToLower(username)
+	if _, ok := s.byUsername[key]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrUsernameTaken, username)
+	}
+
+	s.seq++
+	user := &model.User{
+		ID:       "U" + strconv.FormatInt(s.seq, 10),
+		Username: username,
+		Name:     name,
+		Status:   model.UserStatusActive,
+	}
+	s.users[user.ID] = user
+	s.byUsername[key] = user.ID
+	s.ids = append(s.ids, user.ID)
+	return copyUser(user), nil
+}
+
+// Update 修改用户名或姓名，nil 表示不修改
+func (s *UserStore) Update(id string, username, name *string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, id)
+	}
+
+	newUsername, newName := user.Username, user.Name
+	if username != nil {
+		newUsername = *username
+	}
+	if name != nil {
+		newName = *name
+	}
+	if err := validateUser(newUsername, newName); err != nil {
+		return nil, err
+	}
+
+	oldKey, newKey := strings.ToLower(user.Username), strings.ToLower(newUsername)
+	if newKey != oldKey {
+		if _, ok := s.byUsername[newKey]; ok {
+			return nil, fmt.Errorf("%w: %s", ErrUsernameTaken, newUsername)
+		}
+		delete(s.byUsername, oldKey)
+		s.byUsername[newKey] = id
+	}
+
+	user.Username = newUsername
+	user.Name = newName
+	return copyUser(user), nil
+}
+
+// Deactivate 停用用户
+func (s *UserStore) Deactivate(id string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, id)
+	}
+	user.Status = model.UserStatusDeactivated
+	return copyUser(user), nil
+}
+
+// Get 按 ID 查询用户
+func (s *UserStore) Get(id string) (*model.User, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, false
+	}
+	return copyUser(user), true
+}
+
+// List 按创建顺序返回全部用户
+func (s *UserStore) List() []*model.User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*model.User, 0, len(s.ids))
+	for _, id := range s.ids {
+		result = append(result, copyUser(s.users[id]))
+	}
+	return result
+}
+
+// CheckActive 校验用户存在且未停用
+func (s *UserStore) CheckActive(id string) error {
+	user, ok := s.Get(id)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, id)
+	}
+	if user.Status != model.UserStatusActive {
+		return fmt.Errorf("%w: %s", ErrUserDeactivated, id)
+	}
+	return nil
+}
+
+func validateUser(username, name string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	if n := len([]rune(strings.TrimSpace(name))); n == 0 || n > maxNameLength {
+		return ErrInvalidName
+	}
+	return nil
+}
+
+func copyUser(u *model.User) *model.User {
+	c := *u
+	return &c
+}
@@ -0,0 +1,42 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"gqlexample/graph/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserStore(t *testing.T) {
+	s := NewUserStore()
+
+	alice, err := s.Create("alice", "Alice")
+	assert.NoError(t, err)
+	assert.Equal(t, model.UserStatusActive, alice.Status)
+
+	_, err = s.Create("Alice", "Another Alice")
+	assert.True(t, errors.Is(err, ErrUsernameTaken))
+
+	_, err = s.Create("a!", "Bad")
+	assert.True(t, errors.Is(err, ErrInvalidUsername))
+
+	bob, err := s.Create("bob", "Bob")
+	assert.NoError(t, err)
+
+	// 改名为已存在的用户名
+	_, err = s.Update(bob.ID, &alice.Username, nil)
+	assert.True(t, errors.Is(err, ErrUsernameTaken))
+
+	name := "Bobby"
+	bob, err = s.Update(bob.ID, nil, &name)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bobby", bob.Name)
+
+	_, err = s.Deactivate(alice.ID)
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(s.CheckActive(alice.ID), ErrUserDeactivated))
+	assert.True(t, errors.Is(s.CheckActive("U404"), ErrUserNotFound))
+	assert.Len(t, s.List(), 2)
+}
@@ -0,0 +1,25 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/pkg/errcode"
+)
+
+const CodeConflict = "CONFLICT"
+
+var ErrVersionConflict = errors.New("version conflict")
+
+// AnyVersion 不校验版本
+const AnyVersion int32 = -1
+
+// checkVersion 校验期望版本与当前版本一致，不一致时返回带当前版本及状态的 CONFLICT 错误
+func checkVersion(entity, id string, expected, current int32, state any) error {
+	if expected == AnyVersion || expected == current {
+		return nil
+	}
+	return errcode.New(CodeConflict,
+		fmt.Errorf("%w: %s %s is at version %d, expected %d", ErrVersionConflict, entity, id, current, expected)).
+		With("currentVersion", current).
+		With("current", state)
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gqlexample/graph/model"
+	"gqlexample/pkg/errcode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderStore_UpdateVersion(t *testing.T) {
+	s := NewOrderStore()
+	order, err := s.Create(&model.Order{Status: model.OrderStatusNew, Quantity: 100})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), order.Version)
+
+	// 并发修改同一版本，只有一个成功
+	var wg sync.WaitGroup
+	var succeeded, conflicted int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(qty int32) {
+			defer wg.Done()
+			_, err := s.Update(order.Id, 1, func(o *model.Order) error {
+				o.Quantity = qty
+				return nil
+			})
+			if err == nil {
+				atomic.AddInt32(&succeeded, 1)
+				return
+			}
+			assert.ErrorIs(t, err, ErrVersionConflict)
+			assert.Equal(t, CodeConflict, errcode.Code(err))
+			atomic.AddInt32(&conflicted, 1)
+		}(int32(i))
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), succeeded)
+	assert.Equal(t, int32(9), conflicted)
+
+	current, _ := s.Get(order.Id)
+	assert.Equal(t, int32(2), current.Version)
+
+	// AnyVersion 不校验版本
+	updated, err := s.Update(order.Id, AnyVersion, func(o *model.Order) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), updated.Version)
+}
+
+func TestTodoStore_UpdateVersion(t *testing.T) {
+	s := NewTodoStore()
+	todo, err := s.Create(&model.Todo{Text: "draft"})
+	require.NoError(t, err)
+
+	updated, err := s.Update(todo.ID, 1, func(t *model.Todo) error {
+		t.Done = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), updated.Version)
+	assert.False(t, todo.Done, "已保存的快照不应被修改")
+
+	_, err = s.Update(todo.ID, 1, func(t *model.Todo) error { return nil })
+	var coded *errcode.Error
+	require.ErrorAs(t, err, &coded)
+	assert.Equal(t, int32(2), coded.Extensions["currentVersion"])
+	assert.Equal(t, updated, coded.Extensions["current"])
+
+	_, err = s.Update("T404", 1, func(t *model.Todo) error { return nil })
+	assert.ErrorIs(t, err, ErrTodoNotFound)
+}
+
+func TestOrderStore_Persistent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.jsonl")
+	s, err := OpenOrderStore(path)
+	require.NoError(t, err)
+	filled, err := s.Create(&model.Order{Status: model.OrderStatusNew, Quantity: 100})
+	require.NoError(t, err)
+	open, err := s.Create(&model.Order{Status: model.OrderStatusNew, Quantity: 100})
+	require.NoError(t, err)
+	_, err = s.Update(filled.Id, 1, func(o *model.Order) error {
+		o.Fill(100)
+		return nil
+	})
+	require.NoError(t, err)
+
+	// 版本冲突的修改不写入日志
+	_, err = s.Update(filled.Id, 1, func(o *model.Order) error { return nil })
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	// 重新加载后版本保持，未结束的订单视为已撤销，新订单继续编号
+	s, err = OpenOrderStore(path)
+	require.NoError(t, err)
+	require.Len(t, s.List(), 2)
+	current, _ := s.Get(filled.Id)
+	assert.Equal(t, model.OrderStatusFilled, current.Status)
+	assert.Equal(t, int32(2), current.Version)
+	current, _ = s.Get(open.Id)
+	assert.Equal(t, model.OrderStatusCancelled, current.Status)
+	assert.Equal(t, int32(2), current.Version)
+	_, err = s.Update(open.Id, 1, func(o *model.Order) error { return nil })
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	created, err := s.Create(&model.Order{Status: model.OrderStatusNew})
+	require.NoError(t, err)
+	assert.Equal(t, "3", created.Id)
+}
+
+func TestTodoStore_PersistFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.jsonl")
+	s, err := OpenTodoStore(path)
+	require.NoError(t, err)
+	todo, err := s.Create(&model.Todo{Text: "draft"})
+	require.NoError(t, err)
+
+	// 日志无法写入时修改失败，内存中的待办不变
+	require.NoError(t, os.Remove(path))
+	require.NoError(t, os.Mkdir(path, 0755))
+	_, err = s.Update(todo.ID, 1, func(t *model.Todo) error {
+		t.Done = true
+		return nil
+	})
+	assert.Error(t, err)
+	current, _ := s.Get(todo.ID)
+	assert.Equal(t, int32(1), current.Version)
+	assert.False(t, current.Done)
+
+	_, err = s.Create(&model.Todo{Text: "next"})
+	assert.Error(t, err)
+	assert.Len(t, s.List(), 1)
+}
@@ -0,0 +1,25 @@
+package subscriptions
+
+// Filters 将可选过滤参数转换为订阅的过滤条件，未指定的条件为空字符串
+func Filters(filters ...*string) []string {
+	result := make([]string, len(filters))
+	for i, f := range filters {
+		if f != nil {
+			result[i] = *f
+		}
+	}
+	return result
+}
+
+// matches 订阅是否接收事件：频道相同，且每个非空过滤条件与事件同位置的值相等
+func (s *Subscription) matches(event Event) bool {
+	if s.Topic != event.Topic || s.Channel != event.Channel {
+		return false
+	}
+	for i, f := range s.Filters {
+		if f != "" && (i >= len(event.Values) || event.Values[i] != f) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,6 +2,7 @@ package subscriptions
 
 import (
 	"context"
+	"gqlexample/pkg/middware"
 	"log"
 	"math/rand"
 	"sync"
@@ -12,6 +13,8 @@ type Manager struct {
 	mu            sync.RWMutex
 	subscriptions map[string]*Subscription
 	eventChan     chan Event
+	done          chan struct{}
+	closeOnce     sync.Once
 	timeout       time.Duration
 	middlewares   []Middleware
 }
@@ -20,6 +23,7 @@ func NewManager(timeout time.Duration) *Manager {
 	m := &Manager{
 		subscriptions: make(map[string]*Subscription),
 		eventChan:     make(chan Event, 100),
+		done:          make(chan struct{}),
 		timeout:       timeout,
 	}
 
@@ -34,10 +38,21 @@ func (m *Manager) AddMiddleware(mw Middleware) {
 
 // Subscribe 创建新订阅
 func (m *Manager) Subscribe(ctx context.Context, topic SubscriptionTopic, channel string) (*Subscription, error) {
+	return m.subscribe(ctx, topic, channel, nil)
+}
+
+// SubscribeFiltered 创建带过滤条件的订阅，接收 PublishFiltered 发布且满足全部非空条件的事件
+func (m *Manager) SubscribeFiltered(ctx context.Context, topic SubscriptionTopic, filters []string) (*Subscription, error) {
+	return m.subscribe(ctx, topic, "", filters)
+}
+
+func (m *Manager) subscribe(ctx context.Context, topic SubscriptionTopic, channel string, filters []string) (*Subscription, error) {
 	sub := &Subscription{
 		ID:      generateID(),
 		Topic:   topic,
 		Channel: channel,
+		Filters: filters,
+		UserID:  middware.UserIDFromContext(ctx),
 		Output:  make(chan interface{}, 1),
 		Context: ctx,
 	}
@@ -72,6 +87,25 @@ func (m *Manager) Unsubscribe(id string) {
 	}
 }
 
+// UnsubscribeUser 终止指定用户的全部订阅，返回终止数量
+func (m *Manager) UnsubscribeUser(userID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for id, sub := range m.subscriptions {
+		if sub.UserID == userID {
+			close(sub.Output)
+			delete(m.subscriptions, id)
+			count++
+		}
+	}
+	if count > 0 {
+		log.Printf("Subscriptions of user %s removed: %d", userID, count)
+	}
+	return count
+}
+
 // Publish 发布事件
 func (m *Manager) Publish(event Event) {
 	select {
@@ -81,13 +115,33 @@ func (m *Manager) Publish(event Event) {
 	}
 }
 
+// PublishFiltered 发布一次事件，由分发器按订阅的过滤条件筛选接收者
+func (m *Manager) PublishFiltered(topic SubscriptionTopic, payload any, values ...string) {
+	m.Publish(Event{
+		Topic:   topic,
+		Values:  values,
+		Payload: payload,
+	})
+}
+
+// Close 停止事件分发，之后发布的事件不再推送
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() { close(m.done) })
+}
+
 // 事件分发器
 func (m *Manager) eventDispatcher() {
-	for event := range m.eventChan {
+	for {
+		var event Event
+		select {
+		case event = <-m.eventChan:
+		case <-m.done:
+			return
+		}
 		m.mu.RLock()
 
 		for _, sub := range m.subscriptions {
-			if sub.Topic == event.Topic && sub.Channel == event.Channel {
+			if sub.matches(event) {
 				select {
 				case sub.Output <- event.Payload:
 				case <-time.After(m.timeout):
@@ -0,0 +1,72 @@
+package subscriptions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/pkg/middware"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) any {
+	t.Helper()
+	select {
+	case payload := <-sub.Output:
+		return payload
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return nil
+	}
+}
+
+func TestManager_PublishFiltered(t *testing.T) {
+	m := NewManager(time.Second)
+	t.Cleanup(m.Close)
+	ctx := context.Background()
+	inst, account := "600000.SH", "A1"
+
+	all, err := m.SubscribeFiltered(ctx, TopicOrders, Filters(nil, nil))
+	require.NoError(t, err)
+	byAccount, err := m.SubscribeFiltered(ctx, TopicOrders, Filters(nil, &account))
+	require.NoError(t, err)
+	both, err := m.SubscribeFiltered(ctx, TopicOrders, Filters(&inst, &account))
+	require.NoError(t, err)
+	other, err := m.SubscribeFiltered(ctx, TopicOrders, []string{"", "A2"})
+	require.NoError(t, err)
+
+	// 每次变化只发布一个事件，由分发器匹配过滤条件
+	m.PublishFiltered(TopicOrders, 1, "600519.SH", "A1")
+	m.PublishFiltered(TopicOrders, 2, "600000.SH", "A1")
+	assert.Equal(t, 1, receive(t, all))
+	assert.Equal(t, 2, receive(t, all))
+	assert.Equal(t, 1, receive(t, byAccount))
+	assert.Equal(t, 2, receive(t, byAccount))
+	assert.Equal(t, 2, receive(t, both))
+	assert.Empty(t, other.Output)
+}
+
+func TestManager_UnsubscribeUser(t *testing.T) {
+	m := NewManager(time.Second)
+	t.Cleanup(m.Close)
+
+	alice := middware.WithUserID(context.Background(), "alice")
+	first, err := m.Subscribe(alice, TopicMessages, "room")
+	require.NoError(t, err)
+	second, err := m.SubscribeFiltered(alice, TopicOrders, nil)
+	require.NoError(t, err)
+	bob, err := m.Subscribe(middware.WithUserID(context.Background(), "bob"), TopicMessages, "room")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, m.UnsubscribeUser("alice"))
+	for _, sub := range []*Subscription{first, second} {
+		_, open := <-sub.Output
+		assert.False(t, open)
+	}
+
+	// 其他用户的订阅继续接收事件
+	m.Publish(Event{Topic: TopicMessages, Channel: "room", Payload: "hi"})
+	assert.Equal(t, "hi", receive(t, bob))
+}
@@ -8,15 +8,29 @@ import (
 type SubscriptionTopic string
 
 const (
-	TopicMessages SubscriptionTopic = "messages"
-	TopicUsers    SubscriptionTopic = "users"
+	TopicMessages  SubscriptionTopic = "messages"
+	TopicUsers     SubscriptionTopic = "users"
+	TopicOrders    SubscriptionTopic = "orders"
+	TopicPhases    SubscriptionTopic = "trading_phases"
+	TopicOrderBook SubscriptionTopic = "order_book"
+	TopicPositions SubscriptionTopic = "positions"
+	TopicAlerts    SubscriptionTopic = "alerts"
+	TopicApprovals SubscriptionTopic = "approvals"
+	// 行情及 K 线经 conflate.Hub 推送，管理器中的订阅仅用于随用户停用终止推送
+	TopicQuotes  SubscriptionTopic = "quotes"
+	TopicCandles SubscriptionTopic = "candles"
 )
 
+// AnyChannel 不区分频道的主题使用的频道
+const AnyChannel = "*"
+
 // Subscription 表示一个活跃的订阅
 type Subscription struct {
 	ID      string
 	Topic   SubscriptionTopic
 	Channel string
+	Filters []string // 按位置匹配事件 Values 的过滤条件，空字符串匹配任意值
+	UserID  string   // 订阅者用户 ID，匿名订阅为空
 	Output  chan any
 	Context context.Context
 }
@@ -25,5 +39,6 @@ type Subscription struct {
 type Event struct {
 	Topic   SubscriptionTopic
 	Channel string
+	Values  []string // 供订阅过滤条件匹配的字段值
 	Payload any
 }
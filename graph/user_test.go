@@ -0,0 +1,164 @@
+package graph
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gqlexample/graph/model"
+	"gqlexample/graph/store"
+	"gqlexample/graph/subscriptions"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+
+	"github.com/99designs/gqlgen/client"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestActiveUserGuard(t *testing.T) {
+	r := newTestResolver(t)
+	admin := middware.WithUserID(context.Background(), "admin")
+	user, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "guard", Name: "Guard"})
+	require.NoError(t, err)
+
+	guardWith := func(around graphql.OperationMiddleware, ctx context.Context, operation ast.Operation) string {
+		ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+			Operation: &ast.OperationDefinition{Operation: operation},
+		})
+		resp := around(ctx, func(ctx context.Context) graphql.ResponseHandler {
+			return graphql.OneShot(&graphql.Response{})
+		})(ctx)
+		if len(resp.Errors) == 0 {
+			return ""
+		}
+		return resp.Errors[0].Extensions["code"].(string)
+	}
+	guard := func(ctx context.Context, operation ast.Operation) string {
+		return guardWith(r.ActiveUserGuard, ctx, operation)
+	}
+
+	ctx := middware.WithUserID(context.Background(), user.ID)
+	assert.Empty(t, guard(ctx, ast.Mutation))
+	assert.Empty(t, guard(admin, ast.Mutation))
+	assert.Equal(t, CodeUnauthenticated, guard(context.Background(), ast.Mutation))
+	assert.Equal(t, CodeForbidden, guard(middware.WithUserID(context.Background(), "U404"), ast.Mutation))
+
+	// 停用后拒绝变更操作，查询不受影响
+	_, err = r.Mutation().DeactivateUser(admin, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, CodeForbidden, guard(ctx, ast.Mutation))
+	assert.Empty(t, guard(ctx, ast.Query))
+
+	// 导出接口的查询同样要求登录且用户未停用
+	assert.Equal(t, CodeForbidden, guardWith(r.ExportGuard, ctx, ast.Query))
+	assert.Equal(t, CodeUnauthenticated, guardWith(r.ExportGuard, context.Background(), ast.Query))
+	assert.Empty(t, guardWith(r.ExportGuard, admin, ast.Query))
+}
+
+func TestDeactivateUser_Subscriptions(t *testing.T) {
+	r := newTestResolver(t)
+	admin := middware.WithUserID(context.Background(), "admin")
+	user, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "sub_user", Name: "Sub"})
+	require.NoError(t, err)
+
+	ctx := middware.WithUserID(context.Background(), user.ID)
+	sub, err := r.SubscriptionManager.Subscribe(ctx, subscriptions.TopicMessages, "sse")
+	require.NoError(t, err)
+	_, err = r.SubscriptionManager.Subscribe(admin, subscriptions.TopicMessages, "sse")
+	require.NoError(t, err)
+	// 行情及 K 线不经管理器推送，同样随用户停用终止
+	quotes, err := r.Subscription().Quotes(ctx, []string{"600000.SH"}, nil)
+	require.NoError(t, err)
+	candles, err := r.Subscription().CandleUpdated(ctx, "600000.SH", "1m")
+	require.NoError(t, err)
+
+	_, err = r.Mutation().DeactivateUser(admin, user.ID)
+	require.NoError(t, err)
+	_, open := <-sub.Output
+	assert.False(t, open)
+	assert.Eventually(t, func() bool {
+		_, open := <-quotes
+		return !open
+	}, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		_, open := <-candles
+		return !open
+	}, time.Second, 10*time.Millisecond)
+	assert.Zero(t, r.SubscriptionManager.UnsubscribeUser(user.ID))
+
+	// 其他用户的订阅不受影响
+	assert.Equal(t, 1, r.SubscriptionManager.UnsubscribeUser("admin"))
+}
+
+func TestCreateTodo_DeactivatedUser(t *testing.T) {
+	r := newTestResolver(t)
+	admin := middware.WithUserID(context.Background(), "admin")
+	user, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "todo_user", Name: "Todo"})
+	require.NoError(t, err)
+
+	// 未注册或已停用的用户不能创建待办
+	_, err = r.Mutation().CreateTodo(admin, model.NewTodo{Text: "a", UserID: "legacy"})
+	assert.ErrorIs(t, err, store.ErrUserNotFound)
+	_, err = r.Mutation().CreateTodo(admin, model.NewTodo{Text: "b", UserID: user.ID})
+	require.NoError(t, err)
+	_, err = r.Mutation().DeactivateUser(admin, user.ID)
+	require.NoError(t, err)
+	_, err = r.Mutation().CreateTodo(admin, model.NewTodo{Text: "c", UserID: user.ID})
+	assert.ErrorIs(t, err, store.ErrUserDeactivated)
+	results, err := r.Mutation().CreateTodos(admin, []*model.NewTodo{{Text: "d", UserID: "legacy"}}, nil)
+	require.NoError(t, err)
+	assert.IsType(t, &model.BatchError{}, results[0])
+
+	// 重启前创建的待办对应的用户已不存在时，返回占位用户而不是让整个列表报错
+	legacy, err := r.todos.Create(&model.Todo{Text: "legacy", UserID: "legacy"})
+	require.NoError(t, err)
+	c := client.New(handler.NewDefaultServer(NewExecutableSchema(Config{Resolvers: r})))
+	var resp struct {
+		Todos []struct {
+			ID   string
+			User struct {
+				ID     string
+				Status string
+			}
+		}
+	}
+	require.NoError(t, c.Post(`{ todos { id user { id status } } }`, &resp))
+	require.Len(t, resp.Todos, 2)
+	assert.Equal(t, legacy.ID, resp.Todos[1].ID)
+	assert.Equal(t, "legacy", resp.Todos[1].User.ID)
+	assert.Equal(t, string(model.UserStatusDeactivated), resp.Todos[1].User.Status)
+}
+
+func TestUser_AdminOnly(t *testing.T) {
+	r := newTestResolver(t)
+	admin := middware.WithUserID(context.Background(), "admin")
+	alice, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "alice", Name: "Alice"})
+	require.NoError(t, err)
+	bob, err := r.Mutation().CreateUser(admin, model.NewUser{Username: "bob", Name: "Bob"})
+	require.NoError(t, err)
+	ctx := middware.WithUserID(context.Background(), alice.ID)
+
+	// 普通用户不能创建、修改或停用其他用户
+	_, err = r.Mutation().CreateUser(ctx, model.NewUser{Username: "eve", Name: "Eve"})
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	name := "Mallory"
+	_, err = r.Mutation().UpdateUser(ctx, bob.ID, model.UpdateUser{Name: &name})
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().DeactivateUser(ctx, bob.ID)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	_, err = r.Mutation().DeactivateUser(context.Background(), bob.ID)
+	assert.Equal(t, CodeForbidden, errcode.Code(err))
+	current, ok := r.users.Get(bob.ID)
+	require.True(t, ok)
+	assert.Equal(t, "Bob", current.Name)
+	assert.Equal(t, model.UserStatusActive, current.Status)
+
+	// 用户可修改自己的资料
+	updated, err := r.Mutation().UpdateUser(ctx, alice.ID, model.UpdateUser{Name: &name})
+	require.NoError(t, err)
+	assert.Equal(t, name, updated.Name)
+}
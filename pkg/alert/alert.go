@@ -0,0 +1,199 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrAlertNotFound = errors.New("alert not found")
+
+// Condition 触发条件
+type Condition int
+
+const (
+	Above Condition = iota // 最新价达到或高于阈值
+	Below                  // 最新价达到或低于阈值
+)
+
+// Alert 价格提醒
+type Alert struct {
+	ID           string
+	Owner        string
+	InstrumentID string
+	Condition    Condition
+	Price        decimal.Decimal
+	// Rearm 触发后价格回到阈值另一侧时重新生效，否则只触发一次
+	Rearm          bool
+	Armed          bool
+	TriggerCount   int
+	TriggeredPrice decimal.Decimal
+	TriggeredAt    time.Time
+	CreatedAt      time.Time
+}
+
+// side 按价格排序的一侧提醒
+// up 为 true 时按价格升序，最新价不低于价格的前缀命中，否则按价格降序，最新价不高于价格的前缀命中；strict 时等于价格不命中
+type side struct {
+	up     bool
+	strict bool
+	alerts []*Alert
+}
+
+// hit 价格为 price 的提醒在最新价为 last 时是否命中
+func (s *side) hit(price, last decimal.Decimal) bool {
+	c := last.Cmp(price)
+	if !s.up {
+		c = -c
+	}
+	return c > 0 || c == 0 && !s.strict
+}
+
+// take 取出并返回命中的提醒
+func (s *side) take(last decimal.Decimal) []*Alert {
+	n := sort.Search(len(s.alerts), func(i int) bool { return !s.hit(s.alerts[i].Price, last) })
+	taken := s.alerts[:n:n]
+	s.alerts = s.alerts[n:]
+	return taken
+}
+
+// insert 按价格插入，同价格按插入顺序排列
+func (s *side) insert(a *Alert) {
+	i := sort.Search(len(s.alerts), func(i int) bool {
+		c := s.alerts[i].Price.Cmp(a.Price)
+		return s.up && c > 0 || !s.up && c < 0
+	})
+	s.alerts = slices.Insert(s.alerts, i, a)
+}
+
+func (s *side) remove(a *Alert) {
+	s.alerts = slices.DeleteFunc(s.alerts, func(x *Alert) bool { return x == a })
+}
+
+// ladder 单个合约的提醒索引，按触发条件分别索引生效中和等待重新生效的提醒
+type ladder struct {
+	armed [2]side
+	// rearm 已触发的提醒，价格越过阈值回到另一侧后重新生效
+	rearm [2]side
+}
+
+func newLadder() *ladder {
+	return &ladder{
+		armed: [2]side{Above: {up: true}, Below: {up: false}},
+		rearm: [2]side{Above: {up: false, strict: true}, Below: {up: true, strict: true}},
+	}
+}
+
+// Book 按合约和价格索引的价格提醒，每笔行情只检查会被触发的提醒
+type Book struct {
+	mu      sync.Mutex
+	seq     int64
+	alerts  map[string]*Alert
+	ids     []string // 按创建顺序
+	ladders map[string]*ladder
+}
+
+func NewBook() *Book {
+	return &Book{
+		alerts:  make(map[string]*Alert),
+		ladders: make(map[string]*ladder),
+	}
+}
+
+// Add 创建提醒并返回副本
+func (b *Book) Add(owner, instrumentID string, cond Condition, price decimal.Decimal, rearm bool, at time.Time) Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	a := &Alert{
+		ID:           strconv.FormatInt(b.seq, 10),
+		Owner:        owner,
+		InstrumentID: instrumentID,
+		Condition:    cond,
+		Price:        price,
+		Rearm:        rearm,
+		Armed:        true,
+		CreatedAt:    at,
+	}
+	b.alerts[a.ID] = a
+	b.ids = append(b.ids, a.ID)
+	l, ok := b.ladders[instrumentID]
+	if !ok {
+		l = newLadder()
+		b.ladders[instrumentID] = l
+	}
+	l.armed[cond].insert(a)
+	return *a
+}
+
+// Delete 删除提醒，owner 不匹配时视为不存在
+func (b *Book) Delete(owner, id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	a, ok := b.alerts[id]
+	if !ok || a.Owner != owner {
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+	delete(b.alerts, id)
+	b.ids = slices.DeleteFunc(b.ids, func(x string) bool { return x == id })
+	l := b.ladders[a.InstrumentID]
+	l.armed[a.Condition].remove(a)
+	l.rearm[a.Condition].remove(a)
+	return nil
+}
+
+// List 返回用户的全部提醒，按创建顺序排列
+func (b *Book) List(owner string) []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := []Alert{}
+	for _, id := range b.ids {
+		if a := b.alerts[id]; a.Owner == owner {
+			result = append(result, *a)
+		}
+	}
+	return result
+}
+
+// Evaluate 按最新价检查合约的提醒，返回本次触发的提醒副本
+// 触发后不可重新生效的提醒失效，可重新生效的提醒等待价格回到阈值另一侧
+func (b *Book) Evaluate(instrumentID string, last decimal.Decimal, at time.Time) []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	l, ok := b.ladders[instrumentID]
+	if !ok {
+		return nil
+	}
+
+	for cond := range l.rearm {
+		for _, a := range l.rearm[cond].take(last) {
+			a.Armed = true
+			l.armed[cond].insert(a)
+		}
+	}
+
+	var fired []Alert
+	for cond := range l.armed {
+		for _, a := range l.armed[cond].take(last) {
+			a.Armed = false
+			a.TriggerCount++
+			a.TriggeredPrice = last
+			a.TriggeredAt = at
+			fired = append(fired, *a)
+			if a.Rearm {
+				l.rearm[cond].insert(a)
+			}
+		}
+	}
+	return fired
+}
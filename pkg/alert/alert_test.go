@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func price(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func ids(alerts []Alert) []string {
+	result := []string{}
+	for _, a := range alerts {
+		result = append(result, a.ID)
+	}
+	return result
+}
+
+func TestBook_Evaluate(t *testing.T) {
+	b := NewBook()
+	now := time.Now()
+	a1 := b.Add("U1", "X", Above, price("10.5"), false, now)
+	a2 := b.Add("U1", "X", Above, price("10.2"), false, now)
+	a3 := b.Add("U2", "X", Below, price("9.5"), false, now)
+	b.Add("U2", "Y", Above, price("1"), false, now)
+
+	assert.Empty(t, b.Evaluate("X", price("10.1"), now))
+	// 价格由低到高依次触发，每个提醒只触发一次
+	assert.Equal(t, []string{a2.ID, a1.ID}, ids(b.Evaluate("X", price("10.5"), now)))
+	assert.Empty(t, b.Evaluate("X", price("11"), now))
+	fired := b.Evaluate("X", price("9.5"), now)
+	require.Equal(t, []string{a3.ID}, ids(fired))
+	assert.Equal(t, "9.5", fired[0].TriggeredPrice.String())
+	assert.False(t, fired[0].Armed)
+	assert.Equal(t, 1, fired[0].TriggerCount)
+}
+
+func TestBook_Rearm(t *testing.T) {
+	b := NewBook()
+	now := time.Now()
+	a := b.Add("U1", "X", Above, price("10"), true, now)
+
+	require.Len(t, b.Evaluate("X", price("10"), now), 1)
+	// 停留在阈值不重新生效
+	assert.Empty(t, b.Evaluate("X", price("10"), now))
+	assert.Empty(t, b.Evaluate("X", price("9.99"), now))
+	assert.True(t, b.List("U1")[0].Armed)
+	fired := b.Evaluate("X", price("10.01"), now)
+	require.Len(t, fired, 1)
+	assert.Equal(t, 2, fired[0].TriggerCount)
+
+	require.NoError(t, b.Delete("U1", a.ID))
+	assert.Empty(t, b.Evaluate("X", price("9"), now))
+	assert.Empty(t, b.Evaluate("X", price("11"), now))
+	assert.Empty(t, b.List("U1"))
+}
+
+func TestBook_DeleteOwner(t *testing.T) {
+	b := NewBook()
+	a := b.Add("U1", "X", Below, price("10"), false, time.Now())
+
+	assert.ErrorIs(t, b.Delete("U2", a.ID), ErrAlertNotFound)
+	assert.Empty(t, b.List("U2"))
+	require.NoError(t, b.Delete("U1", a.ID))
+	assert.ErrorIs(t, b.Delete("U1", a.ID), ErrAlertNotFound)
+}
@@ -0,0 +1,227 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gqlexample/pkg/utils"
+)
+
+const (
+	StatusOK      = "OK"
+	StatusPartial = "PARTIAL" // 部分字段出错但已有实体变更
+	StatusError   = "ERROR"
+
+	redacted = "[REDACTED]"
+)
+
+// DefaultRedactFields 默认脱敏的字段名，按小写子串匹配
+var DefaultRedactFields = []string{"password", "secret", "token", "authorization", "credential"}
+
+// Change 变更操作影响的实体，Before 为空表示新建
+type Change struct {
+	EntityType string          `json:"entityType"`
+	EntityID   string          `json:"entityId"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+}
+
+// Entry 一次变更操作的审计记录
+type Entry struct {
+	Seq       int64          `json:"seq"`
+	Timestamp time.Time      `json:"timestamp"`
+	Operation string         `json:"operation"`
+	Principal string         `json:"principal,omitempty"`
+	Variables map[string]any `json:"variables,omitempty"`
+	Changes   []Change       `json:"changes,omitempty"`
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// Filter 查询条件，空值表示不限制，时间区间为 [From, To)
+type Filter struct {
+	EntityType string
+	EntityID   string
+	From       time.Time
+	To         time.Time
+}
+
+// Log 只追加的审计日志，path 非空时以 JSON Lines 追加写入文件，启动时重新加载
+type Log struct {
+	mu      sync.RWMutex
+	file    *os.File
+	entries []*Entry
+	redact  []string
+}
+
+func Open(path string, redactFields []string) (*Log, error) {
+	if len(redactFields) == 0 {
+		redactFields = DefaultRedactFields
+	}
+	l := &Log{}
+	for _, f := range redactFields {
+		l.redact = append(l.redact, strings.ToLower(f))
+	}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := utils.MkdirAll(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	l.file = file
+	return l, nil
+}
+
+// Append 分配序号并追加记录，写入前对变量及快照脱敏
+func (l *Log) Append(e *Entry) error {
+	e.Variables = l.redactMap(e.Variables)
+	for i := range e.Changes {
+		e.Changes[i].Before = l.redactJSON(e.Changes[i].Before)
+		e.Changes[i].After = l.redactJSON(e.Changes[i].After)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = int64(len(l.entries)) + 1
+	if l.file != nil {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if _, err := l.file.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	l.entries = append(l.entries, e)
+	return nil
+}
+
+// Query 按写入顺序返回符合条件的记录
+func (l *Log) Query(f Filter) []*Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var result []*Entry
+	for _, e := range l.entries {
+		if f.match(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+func (l *Log) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+func (f Filter) match(e *Entry) bool {
+	if !f.From.IsZero() && e.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !e.Timestamp.Before(f.To) {
+		return false
+	}
+	if f.EntityType == "" && f.EntityID == "" {
+		return true
+	}
+	for _, c := range e.Changes {
+		if (f.EntityType == "" || c.EntityType == f.EntityType) && (f.EntityID == "" || c.EntityID == f.EntityID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Log) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return err
+		}
+		l.entries = append(l.entries, &e)
+	}
+	return scanner.Err()
+}
+
+func (l *Log) sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, f := range l.redact {
+		if strings.Contains(key, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Log) redactMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		if l.sensitive(k) {
+			result[k] = redacted
+			continue
+		}
+		result[k] = l.redactValue(v)
+	}
+	return result
+}
+
+func (l *Log) redactValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return l.redactMap(v)
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = l.redactValue(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+func (l *Log) redactJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return raw
+	}
+	data, err := json.Marshal(l.redactValue(v))
+	if err != nil {
+		return raw
+	}
+	return data
+}
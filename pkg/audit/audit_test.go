@@ -0,0 +1,84 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gqlexample/pkg/utils"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog_AppendAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path, nil)
+	require.NoError(t, err)
+
+	t0 := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, l.Append(&Entry{
+		Timestamp: t0,
+		Operation: "createUser",
+		Principal: "U1",
+		Variables: map[string]any{
+			"input": map[string]any{"username": "alice", "password": "p@ss"},
+			"token": "abc",
+		},
+		Changes: []Change{{EntityType: "User", EntityID: "U2", After: []byte(`{"id":"U2","apiToken":"t"}`)}},
+		Status:  StatusOK,
+	}))
+	require.NoError(t, l.Append(&Entry{Timestamp: t0.Add(time.Hour), Operation: "cancelOrder", Status: StatusError}))
+	require.NoError(t, l.Close())
+
+	// 重新打开后记录仍在，且已脱敏
+	l, err = Open(path, nil)
+	require.NoError(t, err)
+	defer l.Close()
+
+	entries := l.Query(Filter{EntityType: "User", EntityID: "U2"})
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(1), entries[0].Seq)
+	assert.Equal(t, redacted, entries[0].Variables["token"])
+	assert.Equal(t, redacted, entries[0].Variables["input"].(map[string]any)["password"])
+	assert.JSONEq(t, `{"id":"U2","apiToken":"[REDACTED]"}`, string(entries[0].Changes[0].After))
+
+	assert.Len(t, l.Query(Filter{From: t0.Add(time.Minute)}), 1)
+	assert.Len(t, l.Query(Filter{To: t0.Add(time.Minute)}), 1)
+
+	require.NoError(t, l.Append(&Entry{Timestamp: t0, Operation: "deactivateUser", Status: StatusOK}))
+	assert.Equal(t, int64(3), l.Query(Filter{})[2].Seq)
+}
+
+func TestRecord_OutsideOperation(t *testing.T) {
+	// 不在审计上下文中调用时忽略
+	Record(context.Background(), "Todo", "T1", nil, map[string]any{"id": "T1"})
+}
+
+func TestWriteCsv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.csv")
+	err := WriteCsv([]*Entry{
+		{Seq: 1, Operation: "placeOrders", Status: StatusOK, Changes: []Change{
+			{EntityType: "Order", EntityID: "1", After: []byte(`{"id":"1"}`)},
+			{EntityType: "Order", EntityID: "2", After: []byte(`{"id":"2"}`)},
+		}},
+		{Seq: 2, Operation: "cancelOrder", Status: StatusError, Error: "order not found"},
+	}, path)
+	require.NoError(t, err)
+
+	type row struct {
+		Seq      string `csv:"seq"`
+		EntityID string `csv:"entity_id"`
+		Error    string `csv:"error"`
+	}
+	rows, err := utils.ReadFromCsv[row](path)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Equal(t, "2", rows[1].EntityID)
+	assert.Equal(t, "order not found", rows[2].Error)
+
+	_, err = os.Stat(path)
+	assert.NoError(t, err)
+}
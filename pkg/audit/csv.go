@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"gqlexample/pkg/utils"
+)
+
+// csvRecord 导出的审计记录，每个实体变更一行，无变更的操作单独一行
+type csvRecord struct {
+	Seq        string `csv:"seq"`
+	Timestamp  string `csv:"timestamp"`
+	Operation  string `csv:"operation"`
+	Principal  string `csv:"principal"`
+	Status     string `csv:"status"`
+	Error      string `csv:"error"`
+	EntityType string `csv:"entity_type"`
+	EntityID   string `csv:"entity_id"`
+	Variables  string `csv:"variables"`
+	Before     string `csv:"before"`
+	After      string `csv:"after"`
+}
+
+// WriteCsv 将审计记录导出为 CSV
+func WriteCsv(entries []*Entry, filePath string) error {
+	var records []csvRecord
+	for _, e := range entries {
+		base := csvRecord{
+			Seq:       strconv.FormatInt(e.Seq, 10),
+			Timestamp: e.Timestamp.Format(time.RFC3339Nano),
+			Operation: e.Operation,
+			Principal: e.Principal,
+			Status:    e.Status,
+			Error:     e.Error,
+		}
+		if len(e.Variables) > 0 {
+			vars, _ := json.Marshal(e.Variables)
+			base.Variables = string(vars)
+		}
+
+		if len(e.Changes) == 0 {
+			records = append(records, base)
+			continue
+		}
+		for _, c := range e.Changes {
+			r := base
+			r.EntityType = c.EntityType
+			r.EntityID = c.EntityID
+			r.Before = string(c.Before)
+			r.After = string(c.After)
+			records = append(records, r)
+		}
+	}
+	return utils.WriteToCsv(records, filePath)
+}
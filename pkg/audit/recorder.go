@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"gqlexample/pkg/middware"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"go.uber.org/zap"
+)
+
+type recorderKey struct{}
+
+// recorder 收集一次变更操作中各解析器记录的实体变更
+type recorder struct {
+	mu      sync.Mutex
+	changes []Change
+}
+
+// Record 记录当前变更操作影响的实体，before 为空表示新建
+// 快照在调用时序列化，不在审计上下文中调用时忽略
+func Record(ctx context.Context, entityType, entityID string, before, after any) {
+	rec, ok := ctx.Value(recorderKey{}).(*recorder)
+	if !ok {
+		return
+	}
+
+	change := Change{EntityType: entityType, EntityID: entityID}
+	change.Before = snapshot(before)
+	change.After = snapshot(after)
+
+	rec.mu.Lock()
+	rec.changes = append(rec.changes, change)
+	rec.mu.Unlock()
+}
+
+// AroundOperations 记录每次变更操作的审计日志，与 middware.GqlLogger 一同注册
+func (l *Log) AroundOperations(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	op := graphql.GetOperationContext(ctx)
+	if op.Operation == nil || op.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	rec := &recorder{}
+	ctx = context.WithValue(ctx, recorderKey{}, rec)
+	entry := &Entry{
+		Timestamp: time.Now(),
+		Operation: operationName(op),
+		Principal: middware.UserIDFromContext(ctx),
+		Variables: op.Variables,
+	}
+
+	handler := next(ctx)
+	return func(ctx context.Context) *graphql.Response {
+		resp := handler(ctx)
+		if resp == nil {
+			return nil
+		}
+
+		rec.mu.Lock()
+		entry.Changes = rec.changes
+		rec.mu.Unlock()
+
+		entry.Status = StatusOK
+		if len(resp.Errors) > 0 {
+			entry.Status = StatusError
+			if len(entry.Changes) > 0 {
+				entry.Status = StatusPartial
+			}
+			messages := make([]string, len(resp.Errors))
+			for i, err := range resp.Errors {
+				messages[i] = err.Message
+			}
+			entry.Error = strings.Join(messages, "; ")
+		}
+		if err := l.Append(entry); err != nil {
+			zap.L().Error("Failed to write audit log", zap.String("operation", entry.Operation), zap.Error(err))
+		}
+		return resp
+	}
+}
+
+// operationName 优先使用操作名，匿名操作使用根字段名
+func operationName(op *graphql.OperationContext) string {
+	if op.OperationName != "" {
+		return op.OperationName
+	}
+	var fields []string
+	for _, sel := range op.Operation.SelectionSet {
+		if f, ok := sel.(*ast.Field); ok {
+			fields = append(fields, f.Name)
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+func snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		zap.L().Warn("Failed to snapshot audited entity", zap.Error(err))
+		return nil
+	}
+	return data
+}
@@ -2,6 +2,7 @@ package cache
 
 import (
 	"testing"
+	"time"
 )
 
 type testStruct struct {
@@ -60,3 +61,31 @@ func TestConcurrentCache(t *testing.T) {
 	go write()
 	go read()
 }
+
+func TestTTLCache(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	c := NewTTLCache[string, int](50 * time.Millisecond)
+	c.now = func() time.Time { return now }
+	c.Set("a", 1)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("cache should have key a with value 1, but got %v", v)
+	}
+
+	if v, loaded := c.SetIfAbsent("a", 2); !loaded || v != 1 {
+		t.Errorf("SetIfAbsent should keep existing value 1, but got %v", v)
+	}
+
+	now = now.Add(50 * time.Millisecond)
+	if _, ok := c.Get("a"); ok {
+		t.Errorf("key a should be expired")
+	}
+	if v, loaded := c.SetIfAbsent("a", 3); loaded || v != 3 {
+		t.Errorf("SetIfAbsent should replace expired value, but got %v", v)
+	}
+
+	now = now.Add(time.Second)
+	c.cleanup()
+	if c.Len() != 0 {
+		t.Errorf("expired entries should be removed, but got %d", c.Len())
+	}
+}
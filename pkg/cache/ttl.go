@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+type ttlEntry[V any] struct {
+	val       V
+	expiresAt time.Time
+}
+
+// TTLCache 带过期时间的缓存，过期条目读取时视为不存在，并由 StartCleanup 定期清理
+type TTLCache[K comparable, V any] struct {
+	data   map[K]ttlEntry[V]
+	ttl    time.Duration
+	mu     sync.Mutex
+	stopCh chan struct{}
+	now    func() time.Time
+}
+
+func NewTTLCache[K comparable, V any](ttl time.Duration) *TTLCache[K, V] {
+	return &TTLCache[K, V]{
+		data: make(map[K]ttlEntry[V]),
+		ttl:  ttl,
+		now:  time.Now,
+	}
+}
+
+func (c *TTLCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key, c.now())
+}
+
+// Set 写入并重新计算过期时间
+func (c *TTLCache[K, V]) Set(key K, val V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data[key] = ttlEntry[V]{val: val, expiresAt: c.now().Add(c.ttl)}
+}
+
+// SetIfAbsent 不存在或已过期时写入，返回缓存中的值及是否已存在
+func (c *TTLCache[K, V]) SetIfAbsent(key K, val V) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if existing, ok := c.get(key, now); ok {
+		return existing, true
+	}
+	c.data[key] = ttlEntry[V]{val: val, expiresAt: now.Add(c.ttl)}
+	return val, false
+}
+
+func (c *TTLCache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.data, key)
+}
+
+// Len 返回条目数，包含尚未清理的过期条目
+func (c *TTLCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.data)
+}
+
+// StartCleanup 定期删除过期条目
+func (c *TTLCache[K, V]) StartCleanup(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if interval <= 0 || c.stopCh != nil {
+		return
+	}
+	c.stopCh = make(chan struct{})
+	stop := c.stopCh
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.cleanup()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *TTLCache[K, V]) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopCh != nil {
+		close(c.stopCh)
+		c.stopCh = nil
+	}
+}
+
+func (c *TTLCache[K, V]) get(key K, now time.Time) (V, bool) {
+	e, ok := c.data[key]
+	if !ok || !now.Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.val, true
+}
+
+func (c *TTLCache[K, V]) cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	for k, e := range c.data {
+		if !now.Before(e.expiresAt) {
+			delete(c.data, k)
+		}
+	}
+}
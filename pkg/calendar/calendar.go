@@ -0,0 +1,209 @@
+package calendar
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/pkg/config"
+	"gqlexample/pkg/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrUnknownProduct = errors.New("unknown product")
+
+// Calendar 交易日历，按产品维护每日交易时段，时段为左闭右开区间
+type Calendar struct {
+	mu         sync.RWMutex
+	loc        *time.Location
+	tradingDay string
+	phases     map[string][]utils.TimeRange
+}
+
+func New(cfg *config.MidServerConfig) (*Calendar, error) {
+	c := &Calendar{}
+	if err := c.Update(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Update 使用新的交易配置替换日历，用于配置重载
+func (c *Calendar) Update(cfg *config.MidServerConfig) error {
+	loc, err := cfg.Location()
+	if err != nil {
+		return err
+	}
+
+	phases := make(map[string][]utils.TimeRange, len(cfg.Obligation.TradingPhases))
+	for _, phase := range cfg.Obligation.TradingPhases {
+		sessions := make([]utils.TimeRange, len(phase.Sessions))
+		copy(sessions, phase.Sessions)
+		phases[phase.ProductID] = sessions
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loc = loc
+	c.tradingDay = cfg.Obligation.TradingDay.Data
+	c.phases = phases
+	return nil
+}
+
+// Products 返回已配置的产品，按名称排序
+func (c *Calendar) Products() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	products := make([]string, 0, len(c.phases))
+	for id := range c.phases {
+		products = append(products, id)
+	}
+	sort.Strings(products)
+	return products
+}
+
+// Sessions 返回产品每日的交易时段（当日秒数）
+func (c *Calendar) Sessions(productID string) ([]utils.TimeRange, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sessions, ok := c.phases[productID]
+	return sessions, ok
+}
+
+// TradingDay 返回配置中指定的交易日，未指定时为空
+func (c *Calendar) TradingDay() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tradingDay
+}
+
+// Location 返回交易时段所在时区
+func (c *Calendar) Location() *time.Location {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.loc
+}
+
+// TradingDayOf 返回时刻所属的交易日（YYYYMMDD），即交易时区的当日日期
+// 配置的交易日只限定哪一天可以交易，不固定返回值，按交易日计数的额度因此每日重置
+func (c *Calendar) TradingDayOf(at time.Time) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return at.In(c.loc).Format(config.TradingDayLayout)
+}
+
+// IsTradingDay 判断日期是否为交易日：配置了交易日时仅该日，否则为周一至周五
+func (c *Calendar) IsTradingDay(at time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.isTradingDay(at.In(c.loc))
+}
+
+// IsOpen 判断产品在指定时刻是否处于交易时段
+func (c *Calendar) IsOpen(productID string, at time.Time) (bool, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	sessions, ok := c.phases[productID]
+	if !ok {
+		return false, fmt.Errorf("%w: %s", ErrUnknownProduct, productID)
+	}
+
+	local := at.In(c.loc)
+	if !c.isTradingDay(local) {
+		return false, nil
+	}
+
+	seconds := local.Hour()*3600 + local.Minute()*60 + local.Second()
+	for _, s := range sessions {
+		if seconds >= s.Start && seconds < s.End {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SessionOf 返回时刻所在交易时段的起止时刻，不在交易时段内时返回 false
+func (c *Calendar) SessionOf(productID string, at time.Time) (start, end time.Time, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	local := at.In(c.loc)
+	if !c.isTradingDay(local) {
+		return start, end, false
+	}
+	day := startOfDay(local)
+	for _, s := range c.phases[productID] {
+		start = day.Add(time.Duration(s.Start) * time.Second)
+		end = day.Add(time.Duration(s.End) * time.Second)
+		if !local.Before(start) && local.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+func (c *Calendar) isTradingDay(local time.Time) bool {
+	if c.tradingDay != "" {
+		return local.Format(config.TradingDayLayout) == c.tradingDay
+	}
+	weekday := local.Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// PhaseEvent 交易时段切换事件
+type PhaseEvent struct {
+	ProductID string
+	Open      bool
+	At        time.Time
+}
+
+// Transitions 返回 (from, to] 内全部产品的开闭市时刻，按时间排序
+func (c *Calendar) Transitions(from, to time.Time) []PhaseEvent {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var events []PhaseEvent
+	from, to = from.In(c.loc), to.In(c.loc)
+	for day := startOfDay(from); !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !c.isTradingDay(day) {
+			continue
+		}
+		for productID, sessions := range c.phases {
+			for _, s := range sessions {
+				for _, e := range []PhaseEvent{
+					{ProductID: productID, Open: true, At: day.Add(time.Duration(s.Start) * time.Second)},
+					{ProductID: productID, Open: false, At: day.Add(time.Duration(s.End) * time.Second)},
+				} {
+					if e.At.After(from) && !e.At.After(to) {
+						events = append(events, e)
+					}
+				}
+			}
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].At.Equal(events[j].At) {
+			return events[i].At.Before(events[j].At)
+		}
+		return events[i].ProductID < events[j].ProductID
+	})
+	return events
+}
+
+// IsLastClose 判断事件是否为当日全部产品中最后一次收盘，之后当日不再有开闭市
+func (c *Calendar) IsLastClose(e PhaseEvent) bool {
+	if e.Open {
+		return false
+	}
+	local := e.At.In(c.Location())
+	return len(c.Transitions(local, startOfDay(local).AddDate(0, 0, 1))) == 0
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,126 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"gqlexample/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig(tradingDay, sessions string) *config.MidServerConfig {
+	return &config.MidServerConfig{
+		Obligation: config.ObligationConfig{
+			TradingDay:      config.TradingDay{Data: tradingDay},
+			TimeZone:        "Asia/Shanghai",
+			MaxTradingCount: 10,
+			TradingPhases: []config.TradingPhase{
+				{ProductID: "SSE_STOCK", DurationAuction: sessions},
+			},
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := newConfig("", "13:00:00-15:00:00, 09:30:00-11:30:00")
+	require.NoError(t, cfg.Validate())
+	// 时段按开始时间排序
+	sessions := cfg.Obligation.TradingPhases[0].Sessions
+	require.Len(t, sessions, 2)
+	assert.Equal(t, 9*3600+30*60, sessions[0].Start)
+	assert.Equal(t, 15*3600, sessions[1].End)
+
+	for name, cfg := range map[string]*config.MidServerConfig{
+		"bad format":  newConfig("", "09:30-11:30"),
+		"reversed":    newConfig("", "11:30:00-09:30:00"),
+		"overlap":     newConfig("", "09:30:00-11:30:00,11:00:00-13:00:00"),
+		"trading day": newConfig("2024-01-02", "09:30:00-11:30:00"),
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestCalendar_IsOpen(t *testing.T) {
+	cfg := newConfig("", "09:30:00-11:30:00,13:00:00-15:00:00")
+	require.NoError(t, cfg.Validate())
+	cal, err := New(cfg)
+	require.NoError(t, err)
+
+	loc := cal.Location()
+	at := func(day, clock string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04:05", day+" "+clock, loc)
+		require.NoError(t, err)
+		return ts
+	}
+
+	cases := []struct {
+		at   time.Time
+		open bool
+	}{
+		{at("2024-01-02", "09:29:59"), false},
+		{at("2024-01-02", "09:30:00"), true},
+		{at("2024-01-02", "11:30:00"), false},
+		{at("2024-01-02", "12:00:00"), false},
+		{at("2024-01-02", "14:59:59"), true},
+		{at("2024-01-02", "15:00:00"), false},
+		{at("2024-01-06", "10:00:00"), false}, // 周六
+	}
+	for _, c := range cases {
+		open, err := cal.IsOpen("SSE_STOCK", c.at)
+		require.NoError(t, err)
+		assert.Equal(t, c.open, open, c.at.String())
+	}
+
+	// 其他时区的时刻按交易时区换算
+	open, err := cal.IsOpen("SSE_STOCK", at("2024-01-02", "10:00:00").UTC())
+	require.NoError(t, err)
+	assert.True(t, open)
+
+	start, end, ok := cal.SessionOf("SSE_STOCK", at("2024-01-02", "14:00:00"))
+	assert.True(t, ok)
+	assert.Equal(t, at("2024-01-02", "13:00:00"), start)
+	assert.Equal(t, at("2024-01-02", "15:00:00"), end)
+	_, _, ok = cal.SessionOf("SSE_STOCK", at("2024-01-02", "12:00:00"))
+	assert.False(t, ok)
+
+	_, err = cal.IsOpen("UNKNOWN", at("2024-01-02", "10:00:00"))
+	assert.ErrorIs(t, err, ErrUnknownProduct)
+}
+
+func TestCalendar_ExplicitTradingDay(t *testing.T) {
+	cfg := newConfig("20240106", "09:30:00-11:30:00")
+	require.NoError(t, cfg.Validate())
+	cal, err := New(cfg)
+	require.NoError(t, err)
+
+	loc := cal.Location()
+	assert.True(t, cal.IsTradingDay(time.Date(2024, 1, 6, 10, 0, 0, 0, loc)))
+	assert.False(t, cal.IsTradingDay(time.Date(2024, 1, 5, 10, 0, 0, 0, loc)))
+
+	// 交易日随日期推进，不固定为配置的交易日
+	assert.Equal(t, "20240106", cal.TradingDayOf(time.Date(2024, 1, 6, 10, 0, 0, 0, loc)))
+	assert.Equal(t, "20240107", cal.TradingDayOf(time.Date(2024, 1, 7, 10, 0, 0, 0, loc)))
+}
+
+func TestCalendar_Transitions(t *testing.T) {
+	cfg := newConfig("", "09:30:00-11:30:00,13:00:00-15:00:00")
+	require.NoError(t, cfg.Validate())
+	cal, err := New(cfg)
+	require.NoError(t, err)
+
+	loc := cal.Location()
+	// 周五 10:00 至周一零点：仅剩周五午盘前收盘及午盘开闭市
+	from := time.Date(2024, 1, 5, 10, 0, 0, 0, loc)
+	events := cal.Transitions(from, time.Date(2024, 1, 8, 0, 0, 0, 0, loc))
+	require.Len(t, events, 3)
+	assert.Equal(t, PhaseEvent{ProductID: "SSE_STOCK", Open: false, At: time.Date(2024, 1, 5, 11, 30, 0, 0, loc)}, events[0])
+	assert.True(t, events[1].Open)
+	assert.Equal(t, time.Date(2024, 1, 5, 15, 0, 0, 0, loc), events[2].At)
+
+	// 午盘收盘为当日最后一次收盘
+	assert.False(t, cal.IsLastClose(events[0]))
+	assert.False(t, cal.IsLastClose(events[1]))
+	assert.True(t, cal.IsLastClose(events[2]))
+}
@@ -0,0 +1,104 @@
+package calendar
+
+import (
+	"fmt"
+	"gqlexample/pkg/timewheel"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// 时间轮按槽触发，可能比预期提前不足一格，延后一格保证事件不早于时段边界
+const tick = time.Second
+
+// Scheduler 在时间轮上调度当日剩余的交易时段切换，并在次日零点重新调度
+type Scheduler struct {
+	cal    *Calendar
+	notify func(PhaseEvent)
+	tw     *timewheel.TimeWheel
+	now    func() time.Time
+
+	mu   sync.Mutex
+	gen  int64    // 调度批次，重新调度后旧批次的定时器失效
+	keys []string // 当前批次的定时器
+}
+
+// scheduled 时间轮任务数据，event 为空表示在 at 时刻重新调度次日事件
+type scheduled struct {
+	gen   int64
+	event *PhaseEvent
+	at    time.Time
+}
+
+func NewScheduler(cal *Calendar, notify func(PhaseEvent)) *Scheduler {
+	s := &Scheduler{cal: cal, notify: notify, now: time.Now}
+	s.tw = timewheel.New(int64(tick/time.Second), 3600, s.run)
+	return s
+}
+
+// Start 启动时间轮并调度当日事件
+func (s *Scheduler) Start() {
+	s.tw.Start()
+	s.Reschedule()
+}
+
+func (s *Scheduler) Stop() {
+	s.tw.Stop()
+}
+
+// Reschedule 取消已调度的事件，按当前日历重新调度至次日零点
+func (s *Scheduler) Reschedule() {
+	s.reschedule(s.now())
+}
+
+// reschedule 调度 [from, 次日零点) 内的事件，零点重新调度时 from 为零点本身，
+// 定时器延后一格触发也不会漏掉零点整的事件
+func (s *Scheduler) reschedule(from time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.keys {
+		s.tw.RemoveTimer(key)
+	}
+	s.gen++
+	s.keys = s.keys[:0]
+
+	now := s.now()
+	from = from.In(s.cal.Location())
+	midnight := startOfDay(from).AddDate(0, 0, 1)
+	// Transitions 为左开右闭区间，前移 1ns 转为 [from, midnight)，零点整的事件归次日批次
+	events := s.cal.Transitions(from.Add(-time.Nanosecond), midnight.Add(-time.Nanosecond))
+	for i := range events {
+		s.add(events[i].At.Sub(now), scheduled{event: &events[i]})
+	}
+	s.add(midnight.Sub(now), scheduled{at: midnight})
+
+	zap.L().Info("Trading phase transitions scheduled",
+		zap.Int("count", len(events)), zap.Time("until", midnight))
+}
+
+// add 在 delay 后触发任务，已过期的任务在下一格触发
+func (s *Scheduler) add(delay time.Duration, task scheduled) {
+	key := fmt.Sprintf("phase:%d:%d", s.gen, len(s.keys))
+	s.keys = append(s.keys, key)
+	task.gen = s.gen
+	s.tw.AddTimer(max(delay, 0)+tick, key, task)
+}
+
+func (s *Scheduler) run(data any) {
+	task := data.(scheduled)
+
+	s.mu.Lock()
+	current := task.gen == s.gen
+	s.mu.Unlock()
+	if !current {
+		return
+	}
+
+	if task.event == nil {
+		s.reschedule(task.at)
+		return
+	}
+	s.notify(*task.event)
+}
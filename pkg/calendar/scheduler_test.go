@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMain(m *testing.M) {
+	// 测试日志不写入配置的日志文件
+	zap.ReplaceGlobals(zap.NewNop())
+	os.Exit(m.Run())
+}
+
+func TestScheduler_PhaseEvents(t *testing.T) {
+	cfg := newConfig("", "09:30:00-11:30:00")
+	require.NoError(t, cfg.Validate())
+	cal, err := New(cfg)
+	require.NoError(t, err)
+
+	events := make(chan PhaseEvent, 4)
+	s := NewScheduler(cal, func(e PhaseEvent) { events <- e })
+	// 开盘前启动，开盘事件在时段边界之后推送
+	open := time.Date(2024, 1, 2, 9, 30, 0, 0, cal.Location())
+	s.now = func() time.Time { return open.Add(-time.Millisecond) }
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, PhaseEvent{ProductID: "SSE_STOCK", Open: true, At: open}, e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no phase event received")
+	}
+
+	// 重新调度后旧批次的定时器不再推送
+	s.mu.Lock()
+	stale := scheduled{gen: s.gen, event: &PhaseEvent{ProductID: "SSE_STOCK", At: open.Add(2 * time.Hour)}}
+	s.mu.Unlock()
+	s.Reschedule()
+	s.run(stale)
+	assert.Empty(t, events)
+}
+
+func TestScheduler_MidnightReschedule(t *testing.T) {
+	cfg := newConfig("", "00:00:00-02:00:00")
+	require.NoError(t, cfg.Validate())
+	cal, err := New(cfg)
+	require.NoError(t, err)
+
+	events := make(chan PhaseEvent, 4)
+	s := NewScheduler(cal, func(e PhaseEvent) { events <- e })
+	// 零点重新调度的定时器延后一格触发，零点整的开盘事件仍需推送
+	midnight := time.Date(2024, 1, 3, 0, 0, 0, 0, cal.Location())
+	s.now = func() time.Time { return midnight.Add(tick) }
+	s.tw.Start()
+	t.Cleanup(s.Stop)
+
+	s.mu.Lock()
+	task := scheduled{gen: s.gen, at: midnight}
+	s.mu.Unlock()
+	s.run(task)
+
+	select {
+	case e := <-events:
+		assert.Equal(t, PhaseEvent{ProductID: "SSE_STOCK", Open: true, At: midnight}, e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no phase event received")
+	}
+}
@@ -0,0 +1,235 @@
+package candle
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const day = 24 * time.Hour
+
+var ErrUnsupportedInterval = errors.New("unsupported candle interval")
+
+// Bar 一根 OHLCV K 线，区间为 [Start, End)
+type Bar struct {
+	InstrumentID string
+	Interval     time.Duration
+	Start        time.Time
+	End          time.Time
+	Open         decimal.Decimal
+	High         decimal.Decimal
+	Low          decimal.Decimal
+	Close        decimal.Decimal
+	Volume       int64
+	// Complete 是否已结束，最后一根 K 线在下一周期的行情到来前视为进行中
+	Complete bool
+}
+
+// Aligner 计算时刻所属 K 线的起止时刻
+type Aligner func(instrumentID string, interval time.Duration, at time.Time) (start, end time.Time)
+
+// AlignClock 按时区的自然时间对齐，日线从零点开始
+func AlignClock(loc *time.Location) Aligner {
+	return func(_ string, interval time.Duration, at time.Time) (time.Time, time.Time) {
+		local := at.In(loc)
+		y, m, d := local.Date()
+		midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if interval >= day {
+			return midnight, midnight.AddDate(0, 0, int(interval/day))
+		}
+		start := midnight.Add(local.Sub(midnight) / interval * interval)
+		return start, start.Add(interval)
+	}
+}
+
+type seriesKey struct {
+	instrumentID string
+	interval     time.Duration
+}
+
+type series struct {
+	bars    []Bar // 已结束的 K 线，按时间排序
+	current *Bar
+}
+
+// Aggregator 将成交价和成交量聚合为各周期的 K 线，每个合约每个周期保留有限条历史
+type Aggregator struct {
+	mu        sync.Mutex
+	intervals []time.Duration
+	limit     int
+	align     Aligner
+	series    map[seriesKey]*series
+	onUpdate  func(Bar)
+}
+
+// New 创建聚合器，onUpdate 在每次 K 线更新后以进行中的 K 线调用
+func New(intervals []time.Duration, limit int, align Aligner, onUpdate func(Bar)) *Aggregator {
+	return &Aggregator{
+		intervals: intervals,
+		limit:     limit,
+		align:     align,
+		series:    make(map[seriesKey]*series),
+		onUpdate:  onUpdate,
+	}
+}
+
+// Intervals 返回支持的周期
+func (a *Aggregator) Intervals() []time.Duration {
+	return append([]time.Duration(nil), a.intervals...)
+}
+
+// Supports 是否支持该周期
+func (a *Aggregator) Supports(interval time.Duration) bool {
+	for _, i := range a.intervals {
+		if i == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// Add 记录一笔价格，volume 为 0 表示仅有报价没有成交
+// 早于当前 K 线的价格被忽略
+func (a *Aggregator) Add(instrumentID string, price decimal.Decimal, volume int64, at time.Time) {
+	a.mu.Lock()
+	updated := make([]Bar, 0, len(a.intervals))
+	for _, interval := range a.intervals {
+		key := seriesKey{instrumentID, interval}
+		s, ok := a.series[key]
+		if !ok {
+			s = &series{}
+			a.series[key] = s
+		}
+
+		start, end := a.align(instrumentID, interval, at)
+		cur := s.current
+		switch {
+		case cur == nil || start.After(cur.Start):
+			if cur != nil {
+				cur.Complete = true
+				s.bars = append(s.bars, *cur)
+				if a.limit > 0 && len(s.bars) > a.limit {
+					s.bars = s.bars[len(s.bars)-a.limit:]
+				}
+			}
+			s.current = &Bar{
+				InstrumentID: instrumentID,
+				Interval:     interval,
+				Start:        start,
+				End:          end,
+				Open:         price,
+				High:         price,
+				Low:          price,
+				Close:        price,
+				Volume:       volume,
+			}
+		case start.Equal(cur.Start):
+			cur.High = decimal.Max(cur.High, price)
+			cur.Low = decimal.Min(cur.Low, price)
+			cur.Close = price
+			cur.Volume += volume
+		default:
+			continue
+		}
+		updated = append(updated, *s.current)
+	}
+	a.mu.Unlock()
+
+	if a.onUpdate != nil {
+		for _, bar := range updated {
+			a.onUpdate(bar)
+		}
+	}
+}
+
+// Bars 返回开始时刻在 [from, to) 内的 K 线，包含进行中的 K 线；from、to 为零值时不限制
+func (a *Aggregator) Bars(instrumentID string, interval time.Duration, from, to time.Time) ([]Bar, error) {
+	if !a.Supports(interval) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedInterval, FormatInterval(interval))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s, ok := a.series[seriesKey{instrumentID, interval}]
+	if !ok {
+		return []Bar{}, nil
+	}
+	all := s.bars
+	if s.current != nil {
+		all = append(all[:len(all):len(all)], *s.current)
+	}
+
+	result := make([]Bar, 0, len(all))
+	for _, bar := range all {
+		if (from.IsZero() || !bar.Start.Before(from)) && (to.IsZero() || bar.Start.Before(to)) {
+			result = append(result, bar)
+		}
+	}
+	return result, nil
+}
+
+// Snapshot 返回合约全部周期的 K 线，instrumentID 为空时返回全部合约，按合约、周期、时间排序
+func (a *Aggregator) Snapshot(instrumentID string) []Bar {
+	a.mu.Lock()
+	var result []Bar
+	for key, s := range a.series {
+		if instrumentID != "" && key.instrumentID != instrumentID {
+			continue
+		}
+		result = append(result, s.bars...)
+		if s.current != nil {
+			result = append(result, *s.current)
+		}
+	}
+	a.mu.Unlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].InstrumentID != result[j].InstrumentID {
+			return result[i].InstrumentID < result[j].InstrumentID
+		}
+		if result[i].Interval != result[j].Interval {
+			return result[i].Interval < result[j].Interval
+		}
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// ParseInterval 解析周期，支持 time.ParseDuration 格式及按天的 "1d"
+func ParseInterval(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("%w: %s", ErrUnsupportedInterval, s)
+		}
+		return time.Duration(n) * day, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedInterval, s)
+	}
+	return d, nil
+}
+
+// FormatInterval 将周期格式化为 ParseInterval 可解析的最短形式，如 5m、1d
+func FormatInterval(d time.Duration) string {
+	switch {
+	case d%day == 0:
+		return strconv.FormatInt(int64(d/day), 10) + "d"
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	}
+	return d.String()
+}
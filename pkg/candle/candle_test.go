@@ -0,0 +1,95 @@
+package candle
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gqlexample/pkg/utils"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var cst = time.FixedZone("CST", 8*3600)
+
+func at(clock string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02 15:04:05", "2024-01-02 "+clock, cst)
+	return t
+}
+
+func price(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestAggregator_OHLCV(t *testing.T) {
+	var updates []Bar
+	a := New([]time.Duration{time.Minute, day}, 2, AlignClock(cst), func(b Bar) { updates = append(updates, b) })
+
+	a.Add("X", price("10.00"), 100, at("09:30:05"))
+	a.Add("X", price("10.20"), 200, at("09:30:30"))
+	a.Add("X", price("9.90"), 0, at("09:30:59"))
+	a.Add("X", price("10.10"), 300, at("09:31:00"))
+	// 早于当前 K 线的价格忽略
+	a.Add("X", price("1.00"), 100, at("09:30:10"))
+
+	bars, err := a.Bars("X", time.Minute, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, bars, 2)
+	first := bars[0]
+	assert.True(t, first.Complete)
+	assert.Equal(t, at("09:30:00"), first.Start)
+	assert.Equal(t, at("09:31:00"), first.End)
+	assert.Equal(t, []string{"10", "10.2", "9.9", "9.9"}, []string{first.Open.String(), first.High.String(), first.Low.String(), first.Close.String()})
+	assert.Equal(t, int64(300), first.Volume)
+	assert.False(t, bars[1].Complete)
+
+	// 日线按交易时区零点对齐，早于日线开始的价格仍计入
+	daily, err := a.Bars("X", day, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, at("00:00:00"), daily[0].Start)
+	assert.Equal(t, "1", daily[0].Low.String())
+	assert.Equal(t, int64(700), daily[0].Volume)
+
+	// 每次更新推送各周期进行中的 K 线
+	assert.Len(t, updates, 9)
+
+	// 历史条数受限
+	a.Add("X", price("10"), 1, at("09:32:00"))
+	a.Add("X", price("10"), 1, at("09:33:00"))
+	bars, _ = a.Bars("X", time.Minute, at("09:32:00"), time.Time{})
+	require.Len(t, bars, 2)
+	all, _ := a.Bars("X", time.Minute, time.Time{}, time.Time{})
+	assert.Equal(t, at("09:31:00"), all[0].Start)
+
+	_, err = a.Bars("X", 5*time.Minute, time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, ErrUnsupportedInterval)
+}
+
+func TestParseInterval(t *testing.T) {
+	for s, d := range map[string]time.Duration{"1s": time.Second, "5m": 5 * time.Minute, "1d": day, "2h": 2 * time.Hour} {
+		parsed, err := ParseInterval(s)
+		require.NoError(t, err)
+		assert.Equal(t, d, parsed)
+		assert.Equal(t, s, FormatInterval(d))
+	}
+	for _, s := range []string{"", "0s", "-1m", "xd"} {
+		_, err := ParseInterval(s)
+		assert.ErrorIs(t, err, ErrUnsupportedInterval, s)
+	}
+}
+
+func TestWriteCsv(t *testing.T) {
+	a := New([]time.Duration{time.Minute}, 0, AlignClock(cst), nil)
+	a.Add("X", price("10"), 100, at("09:30:00"))
+
+	path := filepath.Join(t.TempDir(), "candles.csv")
+	require.NoError(t, WriteCsv(a.Snapshot(""), path))
+	records, err := utils.ReadFromCsv[csvRecord](path)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "1m", records[0].Interval)
+	assert.Equal(t, "100", records[0].Volume)
+}
@@ -0,0 +1,42 @@
+package candle
+
+import (
+	"strconv"
+	"time"
+
+	"gqlexample/pkg/utils"
+)
+
+// csvRecord 导出的 K 线，每根一行
+type csvRecord struct {
+	InstrumentID string `csv:"instrument_id"`
+	Interval     string `csv:"interval"`
+	Start        string `csv:"start"`
+	End          string `csv:"end"`
+	Open         string `csv:"open"`
+	High         string `csv:"high"`
+	Low          string `csv:"low"`
+	Close        string `csv:"close"`
+	Volume       string `csv:"volume"`
+	Complete     string `csv:"complete"`
+}
+
+// WriteCsv 将 K 线导出为 CSV
+func WriteCsv(bars []Bar, filePath string) error {
+	records := make([]csvRecord, 0, len(bars))
+	for _, b := range bars {
+		records = append(records, csvRecord{
+			InstrumentID: b.InstrumentID,
+			Interval:     FormatInterval(b.Interval),
+			Start:        b.Start.Format(time.RFC3339),
+			End:          b.End.Format(time.RFC3339),
+			Open:         b.Open.String(),
+			High:         b.High.String(),
+			Low:          b.Low.String(),
+			Close:        b.Close.String(),
+			Volume:       strconv.FormatInt(b.Volume, 10),
+			Complete:     strconv.FormatBool(b.Complete),
+		})
+	}
+	return utils.WriteToCsv(records, filePath)
+}
@@ -20,11 +20,27 @@ const (
 )
 
 type Config struct {
-	ServerPort  int         `yaml:"server_port"`
-	SocketPath  string      `yaml:"socket_path"`
-	Environment string      `yaml:"environment"`
-	Logger      Logger      `yaml:"logger"`
-	Mysql       MysqlConfig `yaml:"mysql"`
+	ServerPort          int               `yaml:"server_port"`
+	SocketPath          string            `yaml:"socket_path"`
+	Environment         string            `yaml:"environment"`
+	Logger              Logger            `yaml:"logger"`
+	Mysql               MysqlConfig       `yaml:"mysql"`
+	Decimal             DecimalConfig     `yaml:"decimal"`
+	Instrument          InstrumentConfig  `yaml:"instrument"`
+	Dataloader          DataloaderConfig  `yaml:"dataloader"`
+	Message             MessageConfig     `yaml:"message"`
+	Store               StoreConfig       `yaml:"store"`
+	Batch               BatchConfig       `yaml:"batch"`
+	Audit               AuditConfig       `yaml:"audit"`
+	Idempotency         IdempotencyConfig `yaml:"idempotency"`
+	Admin               AdminConfig       `yaml:"admin"`
+	Simulator           SimulatorConfig   `yaml:"simulator"`
+	Candle              CandleConfig      `yaml:"candle"`
+	Risk                RiskConfig        `yaml:"risk"`
+	Account             AccountConfig     `yaml:"account"`
+	Approval            ApprovalConfig    `yaml:"approval"`
+	Settlement          SettlementConfig  `yaml:"settlement"`
+	MidServerConfigPath string            `yaml:"mid_server_config"`
 }
 
 type (
@@ -43,6 +59,121 @@ type (
 		Password string `yaml:"password"`
 		Database string `yaml:"database"`
 	}
+
+	// DecimalConfig 金额精度配置，instruments 按合约覆盖默认值，scale 未配置时沿用默认小数位数
+	DecimalConfig struct {
+		Scale       *int32                      `yaml:"scale"`
+		Rounding    string                      `yaml:"rounding"`
+		Instruments map[string]DecimalPrecision `yaml:"instruments"`
+	}
+
+	DecimalPrecision struct {
+		Scale    *int32 `yaml:"scale"`
+		Rounding string `yaml:"rounding"`
+	}
+
+	// InstrumentConfig 合约参考数据配置，catalog_path 为相对配置目录的 CSV 路径
+	InstrumentConfig struct {
+		CatalogPath     string        `yaml:"catalog_path"`
+		RefreshInterval time.Duration `yaml:"refresh_interval"`
+	}
+
+	// DataloaderConfig 请求级批量加载配置
+	DataloaderConfig struct {
+		Wait     time.Duration `yaml:"wait"`
+		MaxBatch int           `yaml:"max_batch"`
+	}
+
+	// MessageConfig 消息历史配置，history_dir 为空时仅保存在内存
+	MessageConfig struct {
+		HistoryDir string `yaml:"history_dir"`
+	}
+
+	// StoreConfig 待办及订单持久化配置，dir 为空时仅保存在内存
+	StoreConfig struct {
+		Dir string `yaml:"dir"`
+	}
+
+	// BatchConfig 批量变更配置，max_size 为单次请求允许的最大条数
+	BatchConfig struct {
+		MaxSize int `yaml:"max_size"`
+	}
+
+	// IdempotencyConfig 幂等键保存时长
+	IdempotencyConfig struct {
+		TTL time.Duration `yaml:"ttl"`
+	}
+
+	// AdminConfig 可执行管理操作的用户 ID
+	AdminConfig struct {
+		Users []string `yaml:"users"`
+	}
+
+	// SimulatorConfig 行情模拟配置，instruments 为空时模拟目录中全部合约
+	// intervals 按合约覆盖生成间隔，prices 为初始价格，未配置时使用 default_price
+	SimulatorConfig struct {
+		Enabled      bool                     `yaml:"enabled"`
+		Seed         int64                    `yaml:"seed"`
+		Interval     time.Duration            `yaml:"interval"`
+		Intervals    map[string]time.Duration `yaml:"intervals"`
+		Volatility   float64                  `yaml:"volatility"`
+		Instruments  []string                 `yaml:"instruments"`
+		Prices       map[string]string        `yaml:"prices"`
+		DefaultPrice string                   `yaml:"default_price"`
+	}
+
+	// CandleConfig K 线配置，intervals 支持 1s、1m、5m、1d 等格式，history 为每个合约每个周期保留的条数
+	CandleConfig struct {
+		Intervals []string `yaml:"intervals"`
+		History   int      `yaml:"history"`
+		ExportDir string   `yaml:"export_dir"`
+	}
+
+	// RiskConfig 下单前风控配置，金额为字符串，0 表示不检查
+	// path 非空时管理员修改的限额保存到该文件，文件存在时启动以文件为准
+	RiskConfig struct {
+		Path             string                          `yaml:"path"`
+		MaxQuantity      int32                           `yaml:"max_quantity"`
+		MaxNotional      string                          `yaml:"max_notional"`
+		Instruments      map[string]RiskInstrumentConfig `yaml:"instruments"`
+		DailyNotional    string                          `yaml:"daily_notional"`
+		CollarPercent    float64                         `yaml:"collar_percent"`
+		FatFingerPercent float64                         `yaml:"fat_finger_percent"`
+	}
+
+	// RiskInstrumentConfig 按合约覆盖的单笔限额
+	RiskInstrumentConfig struct {
+		MaxQuantity int32  `yaml:"max_quantity"`
+		MaxNotional string `yaml:"max_notional"`
+	}
+
+	// AccountConfig 资金账户配置，fee_rate 为成交金额的手续费率
+	AccountConfig struct {
+		FeeRate float64 `yaml:"fee_rate"`
+	}
+
+	// ApprovalConfig 大额订单审批配置，委托金额超过 threshold 的订单需 approvers 中的另一位用户审批
+	// threshold 为空或 0 时不需审批，超过 expiry 未审批的订单自动拒绝
+	ApprovalConfig struct {
+		Threshold string        `yaml:"threshold"`
+		Approvers []string      `yaml:"approvers"`
+		Expiry    time.Duration `yaml:"expiry"`
+	}
+
+	// SettlementConfig 日终结算配置，报表按交易日写入 dir 下的子目录，schedule 为 true 时在当日最后一次收盘后自动结算
+	SettlementConfig struct {
+		Dir      string `yaml:"dir"`
+		Schedule bool   `yaml:"schedule"`
+	}
+
+	// AuditConfig 审计日志配置，path 为空时仅保存在内存，redact_fields 为需脱敏的字段名
+	// auditors 为可查看和导出审计日志的用户，管理员始终可以访问
+	AuditConfig struct {
+		Path         string   `yaml:"path"`
+		ExportDir    string   `yaml:"export_dir"`
+		RedactFields []string `yaml:"redact_fields"`
+		Auditors     []string `yaml:"auditors"`
+	}
 )
 
 var (
@@ -50,11 +181,22 @@ var (
 	once sync.Once
 )
 
-func LoadConfig() *Config {
+// configDir 配置文件所在目录
+func configDir() string {
 	_, filename, _, _ := runtime.Caller(0)
-	currentDir := filepath.Dir(filename)
+	return filepath.Dir(filename)
+}
 
-	yamlFile, err := os.ReadFile(filepath.Join(currentDir, "config.yaml"))
+// ResolvePath 将相对路径解析为相对配置目录的绝对路径
+func ResolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(configDir(), path)
+}
+
+func LoadConfig() *Config {
+	yamlFile, err := os.ReadFile(filepath.Join(configDir(), "config.yaml"))
 	if err != nil {
 		log.Printf("Error on reading configuration file, error: %v", err)
 	}
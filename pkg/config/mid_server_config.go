@@ -1,5 +1,155 @@
 package config
 
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gqlexample/pkg/utils"
+)
+
+const TradingDayLayout = "20060102"
+
+var sessionPattern = regexp.MustCompile(`^([01]\d|2[0-4]):[0-5]\d:[0-5]\d-([01]\d|2[0-4]):[0-5]\d:[0-5]\d$`)
+
+var validLogLevels = map[string]bool{
+	"DEBUG": true,
+	"INFO":  true,
+	"WARN":  true,
+	"ERROR": true,
+}
+
+// MidServerConfig 对应 mid_server_config.xml
 type MidServerConfig struct {
-	
-}
\ No newline at end of file
+	XMLName    xml.Name         `xml:"server_config"`
+	Obligation ObligationConfig `xml:"obliagation_config"`
+}
+
+type (
+	ObligationConfig struct {
+		TradingDay      TradingDay     `xml:"trading_day"`
+		LogLevel        LogLevel       `xml:"log_level"`
+		TimeZone        string         `xml:"timezone"`
+		TradingInterval int            `xml:"trading_interval"`
+		MaxTradingCount int            `xml:"max_trading_count"`
+		TradingPhases   []TradingPhase `xml:"trading_phase_config>trading_phase"`
+	}
+
+	// TradingDay 指定交易日（YYYYMMDD），为空时周一至周五均为交易日
+	TradingDay struct {
+		Data string `xml:"data,attr"`
+	}
+
+	LogLevel struct {
+		Level string `xml:"level,attr"`
+	}
+
+	// TradingPhase 产品的交易时段，duration_auction 支持逗号分隔的多个时段
+	TradingPhase struct {
+		ProductID       string            `xml:"product_id,attr"`
+		DurationAuction string            `xml:"duration_auction,attr"`
+		Sessions        []utils.TimeRange `xml:"-"`
+	}
+)
+
+// LoadMidServerConfig 读取并校验交易配置
+func LoadMidServerConfig(path string) (*MidServerConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg MidServerConfig
+	if err := xml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate 校验配置并解析各产品的交易时段
+func (c *MidServerConfig) Validate() error {
+	oc := &c.Obligation
+
+	if day := oc.TradingDay.Data; day != "" {
+		if _, err := time.Parse(TradingDayLayout, day); err != nil {
+			return fmt.Errorf("trading_day %q must be YYYYMMDD", day)
+		}
+	}
+	if level := oc.LogLevel.Level; level != "" && !validLogLevels[strings.ToUpper(level)] {
+		return fmt.Errorf("unknown log_level %q", level)
+	}
+	if _, err := c.Location(); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", oc.TimeZone, err)
+	}
+	if oc.TradingInterval < 0 {
+		return errors.New("trading_interval must not be negative")
+	}
+	if oc.MaxTradingCount <= 0 {
+		return errors.New("max_trading_count must be positive")
+	}
+	if len(oc.TradingPhases) == 0 {
+		return errors.New("trading_phase_config has no trading_phase")
+	}
+
+	seen := make(map[string]bool, len(oc.TradingPhases))
+	for i := range oc.TradingPhases {
+		phase := &oc.TradingPhases[i]
+		if phase.ProductID == "" {
+			return fmt.Errorf("trading_phase #%d has no product_id", i+1)
+		}
+		if seen[phase.ProductID] {
+			return fmt.Errorf("duplicate trading_phase for %s", phase.ProductID)
+		}
+		seen[phase.ProductID] = true
+
+		sessions, err := parseSessions(phase.DurationAuction)
+		if err != nil {
+			return fmt.Errorf("trading_phase %s: %w", phase.ProductID, err)
+		}
+		phase.Sessions = sessions
+	}
+	return nil
+}
+
+// Location 交易时段所在时区，未配置时使用本地时区
+func (c *MidServerConfig) Location() (*time.Location, error) {
+	if c.Obligation.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.Obligation.TimeZone)
+}
+
+// parseSessions 解析并校验时段：格式正确、起止有序、互不重叠
+func parseSessions(s string) ([]utils.TimeRange, error) {
+	for _, part := range strings.Split(s, ",") {
+		if !sessionPattern.MatchString(strings.TrimSpace(part)) {
+			return nil, fmt.Errorf("invalid duration_auction %q, want HH:MM:SS-HH:MM:SS[,...]", s)
+		}
+	}
+
+	sessions := utils.ProcessTimeRange(s)
+	for _, r := range sessions {
+		if r.Start < 0 || r.End > 24*3600 || r.Start >= r.End {
+			return nil, fmt.Errorf("invalid session %s", formatRange(r))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Start < sessions[j].Start })
+	for i := 1; i < len(sessions); i++ {
+		if sessions[i].Start < sessions[i-1].End {
+			return nil, fmt.Errorf("session %s overlaps %s", formatRange(sessions[i]), formatRange(sessions[i-1]))
+		}
+	}
+	return sessions, nil
+}
+
+func formatRange(r utils.TimeRange) string {
+	return fmt.Sprintf("%s-%s", utils.FormatSeconds(r.Start), utils.FormatSeconds(r.End))
+}
@@ -0,0 +1,142 @@
+package conflate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Hub 按键保存最新值并推送给订阅者
+// 订阅者消费不及时时只保留每个键的最新值，发布方从不阻塞
+type Hub[T any] struct {
+	mu     sync.RWMutex
+	latest map[string]T
+	subs   map[string]map[*Subscriber[T]]struct{}
+}
+
+func NewHub[T any]() *Hub[T] {
+	return &Hub[T]{
+		latest: make(map[string]T),
+		subs:   make(map[string]map[*Subscriber[T]]struct{}),
+	}
+}
+
+// Publish 更新键的最新值并通知订阅了该键的订阅者
+func (h *Hub[T]) Publish(key string, v T) {
+	h.mu.Lock()
+	h.latest[key] = v
+	subs := make([]*Subscriber[T], 0, len(h.subs[key]))
+	for s := range h.subs[key] {
+		subs = append(subs, s)
+	}
+	h.mu.Unlock()
+
+	for _, s := range subs {
+		s.offer(key, v)
+	}
+}
+
+// Latest 返回键的最新值
+func (h *Hub[T]) Latest(key string) (T, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	v, ok := h.latest[key]
+	return v, ok
+}
+
+// Subscribe 订阅指定的键，已有最新值的键会先推送一次
+func (h *Hub[T]) Subscribe(keys []string) *Subscriber[T] {
+	s := &Subscriber[T]{
+		hub:     h,
+		keys:    keys,
+		pending: make(map[string]T),
+		ready:   make(chan struct{}, 1),
+	}
+
+	h.mu.Lock()
+	for _, key := range keys {
+		if h.subs[key] == nil {
+			h.subs[key] = make(map[*Subscriber[T]]struct{})
+		}
+		h.subs[key][s] = struct{}{}
+		if v, ok := h.latest[key]; ok {
+			s.offer(key, v)
+		}
+	}
+	h.mu.Unlock()
+	return s
+}
+
+func (h *Hub[T]) unsubscribe(s *Subscriber[T]) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range s.keys {
+		delete(h.subs[key], s)
+		if len(h.subs[key]) == 0 {
+			delete(h.subs, key)
+		}
+	}
+}
+
+// Subscriber 一个订阅，待推送的值按键合并
+type Subscriber[T any] struct {
+	hub       *Hub[T]
+	keys      []string
+	mu        sync.Mutex
+	pending   map[string]T
+	order     []string // 待推送键的首次更新顺序
+	ready     chan struct{}
+	conflated atomic.Int64
+}
+
+// offer 记录待推送的值，覆盖尚未取走的旧值
+func (s *Subscriber[T]) offer(key string, v T) {
+	s.mu.Lock()
+	if _, ok := s.pending[key]; ok {
+		s.conflated.Add(1)
+	} else {
+		s.order = append(s.order, key)
+	}
+	s.pending[key] = v
+	s.mu.Unlock()
+
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Next 阻塞直到有待推送的值，按键首次更新的顺序返回各键的最新值
+// ctx 结束时返回 false
+func (s *Subscriber[T]) Next(ctx context.Context) ([]T, bool) {
+	for {
+		s.mu.Lock()
+		if len(s.order) > 0 {
+			values := make([]T, 0, len(s.order))
+			for _, key := range s.order {
+				values = append(values, s.pending[key])
+				delete(s.pending, key)
+			}
+			s.order = s.order[:0]
+			s.mu.Unlock()
+			return values, true
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// Conflated 被合并丢弃的旧值数量
+func (s *Subscriber[T]) Conflated() int64 {
+	return s.conflated.Load()
+}
+
+// Close 取消订阅
+func (s *Subscriber[T]) Close() {
+	s.hub.unsubscribe(s)
+}
@@ -0,0 +1,60 @@
+package conflate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHub_Conflation(t *testing.T) {
+	h := NewHub[int]()
+	h.Publish("A", 1)
+
+	s := h.Subscribe([]string{"A", "B"})
+	defer s.Close()
+
+	// 订阅时先收到已有的最新值
+	values, ok := s.Next(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, []int{1}, values)
+
+	// 未及时消费时每个键只保留最新值，按首次更新顺序返回
+	for i := 2; i <= 100; i++ {
+		h.Publish("B", i*10)
+		h.Publish("A", i)
+	}
+	h.Publish("C", 1)
+	values, ok = s.Next(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, []int{1000, 100}, values)
+	assert.Equal(t, int64(196), s.Conflated())
+
+	latest, ok := h.Latest("C")
+	assert.True(t, ok)
+	assert.Equal(t, 1, latest)
+}
+
+func TestHub_NextBlocksUntilPublish(t *testing.T) {
+	h := NewHub[string]()
+	s := h.Subscribe([]string{"A"})
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		h.Publish("A", "x")
+	}()
+	values, ok := s.Next(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, []string{"x"}, values)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, ok = s.Next(ctx)
+	assert.False(t, ok)
+
+	s.Close()
+	h.Publish("A", "y")
+	assert.Empty(t, h.subs)
+}
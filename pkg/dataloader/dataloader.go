@@ -0,0 +1,223 @@
+package dataloader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DEFAULT_WAIT      = 2 * time.Millisecond
+	DEFAULT_MAX_BATCH = 100
+)
+
+// BatchFunc 批量加载函数，返回值与 keys 按下标一一对应
+// errs 可以为空、长度为 1（作用于全部 key）或与 keys 等长
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) ([]V, []error)
+
+type options struct {
+	wait     time.Duration
+	maxBatch int
+}
+
+type Option func(*options)
+
+// WithWait 设置批处理等待窗口
+func WithWait(wait time.Duration) Option {
+	return func(o *options) {
+		o.wait = wait
+	}
+}
+
+// WithMaxBatch 设置单批最大 key 数量，达到后立即发起加载
+func WithMaxBatch(size int) Option {
+	return func(o *options) {
+		o.maxBatch = size
+	}
+}
+
+// Stats 加载器统计信息
+type Stats struct {
+	Loads   int64 // Load 调用次数
+	Hits    int64 // 命中请求级缓存次数
+	Batches int64 // 批量加载次数
+	Keys    int64 // 批量加载的 key 总数
+}
+
+type result[V any] struct {
+	value V
+	err   error
+	done  chan struct{}
+}
+
+type batch[K comparable, V any] struct {
+	ctx     context.Context
+	keys    []K
+	results []*result[V]
+}
+
+// Loader 请求级数据加载器，合并等待窗口内的 Load 调用为一次批量加载，并缓存结果
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	opts  options
+
+	mu    sync.Mutex
+	cache map[K]*result[V]
+	batch *batch[K, V]
+
+	loads   atomic.Int64
+	hits    atomic.Int64
+	batches atomic.Int64
+	keys    atomic.Int64
+}
+
+func New[K comparable, V any](fetch BatchFunc[K, V], opts ...Option) *Loader[K, V] {
+	o := options{
+		wait:     DEFAULT_WAIT,
+		maxBatch: DEFAULT_MAX_BATCH,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Loader[K, V]{
+		fetch: fetch,
+		opts:  o,
+		cache: make(map[K]*result[V]),
+	}
+}
+
+// Load 加载单个 key
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.loads.Add(1)
+
+	l.mu.Lock()
+	res, ok := l.cache[key]
+	if ok {
+		l.hits.Add(1)
+		l.mu.Unlock()
+	} else {
+		res = &result[V]{done: make(chan struct{})}
+		l.cache[key] = res
+		l.enqueue(ctx, key, res)
+	}
+
+	select {
+	case <-res.done:
+		return res.value, res.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadAll 加载多个 key，结果与 keys 按下标对应
+func (l *Loader[K, V]) LoadAll(ctx context.Context, keys []K) ([]V, []error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	wg.Add(len(keys))
+	for i, key := range keys {
+		go func(i int, key K) {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}(i, key)
+	}
+	wg.Wait()
+
+	return values, errs
+}
+
+// Prime 预先写入缓存，已存在时忽略
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; !ok {
+		res := &result[V]{value: value, done: make(chan struct{})}
+		close(res.done)
+		l.cache[key] = res
+	}
+}
+
+// Clear 清除指定 key 的缓存
+func (l *Loader[K, V]) Clear(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, key)
+}
+
+// Stats 返回统计信息快照
+func (l *Loader[K, V]) Stats() Stats {
+	return Stats{
+		Loads:   l.loads.Load(),
+		Hits:    l.hits.Load(),
+		Batches: l.batches.Load(),
+		Keys:    l.keys.Load(),
+	}
+}
+
+// enqueue 将 key 加入当前批次，调用方需持有锁，返回前释放锁
+func (l *Loader[K, V]) enqueue(ctx context.Context, key K, res *result[V]) {
+	if l.batch == nil {
+		l.batch = &batch[K, V]{ctx: ctx}
+		b := l.batch
+		time.AfterFunc(l.opts.wait, func() {
+			l.mu.Lock()
+			if l.batch != b {
+				// 批次已因达到上限提前发起
+				l.mu.Unlock()
+				return
+			}
+			l.batch = nil
+			l.mu.Unlock()
+			l.dispatch(b)
+		})
+	}
+
+	b := l.batch
+	b.keys = append(b.keys, key)
+	b.results = append(b.results, res)
+
+	if l.opts.maxBatch > 0 && len(b.keys) >= l.opts.maxBatch {
+		l.batch = nil
+		l.mu.Unlock()
+		go l.dispatch(b)
+		return
+	}
+	l.mu.Unlock()
+}
+
+func (l *Loader[K, V]) dispatch(b *batch[K, V]) {
+	l.batches.Add(1)
+	l.keys.Add(int64(len(b.keys)))
+
+	values, errs := l.fetch(b.ctx, b.keys)
+
+	for i, res := range b.results {
+		switch {
+		case len(values) != len(b.keys) && len(errs) == 0:
+			res.err = fmt.Errorf("dataloader: batch function returned %d values for %d keys", len(values), len(b.keys))
+		case len(errs) == 1:
+			res.err = errs[0]
+		case len(errs) == len(b.keys):
+			res.err = errs[i]
+		}
+		if res.err == nil && i < len(values) {
+			res.value = values[i]
+		}
+
+		// 错误结果不缓存，下次 Load 重新加载
+		if res.err != nil {
+			l.mu.Lock()
+			if l.cache[b.keys[i]] == res {
+				delete(l.cache, b.keys[i])
+			}
+			l.mu.Unlock()
+		}
+		close(res.done)
+	}
+}
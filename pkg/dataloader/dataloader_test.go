@@ -0,0 +1,72 @@
+package dataloader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoader_Batch(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]int
+
+	l := New(func(ctx context.Context, keys []int) ([]int, []error) {
+		mu.Lock()
+		batches = append(batches, keys)
+		mu.Unlock()
+
+		values := make([]int, len(keys))
+		for i, k := range keys {
+			values[i] = k * 10
+		}
+		return values, nil
+	}, WithWait(10*time.Millisecond))
+
+	values, errs := l.LoadAll(context.Background(), []int{1, 2, 3, 2})
+	assert.Equal(t, []int{10, 20, 30, 20}, values)
+	assert.Equal(t, []error{nil, nil, nil, nil}, errs)
+	assert.Len(t, batches, 1)
+	assert.Len(t, batches[0], 3)
+
+	// 命中缓存不再触发批量加载
+	v, err := l.Load(context.Background(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 30, v)
+
+	stats := l.Stats()
+	assert.Equal(t, int64(5), stats.Loads)
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(1), stats.Batches)
+	assert.Equal(t, int64(3), stats.Keys)
+}
+
+func TestLoader_MaxBatch(t *testing.T) {
+	l := New(func(ctx context.Context, keys []int) ([]int, []error) {
+		return keys, nil
+	}, WithWait(time.Second), WithMaxBatch(2))
+
+	start := time.Now()
+	values, _ := l.LoadAll(context.Background(), []int{1, 2})
+	assert.Equal(t, []int{1, 2}, values)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestLoader_Error(t *testing.T) {
+	calls := 0
+	l := New(func(ctx context.Context, keys []string) ([]string, []error) {
+		calls++
+		return nil, []error{errors.New("boom")}
+	}, WithWait(time.Millisecond))
+
+	_, err := l.Load(context.Background(), "a")
+	assert.Error(t, err)
+
+	// 错误结果不缓存
+	_, err = l.Load(context.Background(), "a")
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
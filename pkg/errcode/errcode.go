@@ -0,0 +1,64 @@
+package errcode
+
+import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Error 带错误码的业务错误，错误码通过 GraphQL 错误的 extensions.code 返回
+type Error struct {
+	Code       string
+	Err        error
+	Extensions map[string]any // 附加到 extensions 的其他字段
+}
+
+// New 为错误附加错误码
+func New(code string, err error) *Error {
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// With 附加 extensions 字段
+func (e *Error) With(key string, value any) *Error {
+	if e.Extensions == nil {
+		e.Extensions = make(map[string]any)
+	}
+	e.Extensions[key] = value
+	return e
+}
+
+// Code 返回错误链中的错误码，没有时返回空字符串
+func Code(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// Presenter GraphQL 错误处理，将错误码写入 extensions
+func Presenter(ctx context.Context, err error) *gqlerror.Error {
+	gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+
+	var e *Error
+	if errors.As(err, &e) {
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = make(map[string]any, len(e.Extensions)+1)
+		}
+		for k, v := range e.Extensions {
+			gqlErr.Extensions[k] = v
+		}
+		gqlErr.Extensions["code"] = e.Code
+	}
+	return gqlErr
+}
@@ -0,0 +1,308 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gqlexample/pkg/utils"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"go.uber.org/zap"
+)
+
+// ErrorTrailer 导出中途出错时设置的 HTTP trailer，值为错误信息
+const ErrorTrailer = "X-Export-Error"
+
+// errorMarker 导出中途出错时追加的最后一行首列
+const errorMarker = "#ERROR"
+
+// params 导出参数，GET 请求使用同名查询参数，columns 以逗号分隔
+// pageSize 大于 0 时按游标分页执行查询：查询需声明 $first 和 $after 变量，
+// path 指向连接类型中的列表（如 user.todos.edges），同级需查询 pageInfo { hasNextPage endCursor }
+type params struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    map[string]any `json:"extensions"`
+	Path          string         `json:"path"`
+	Columns       []string       `json:"columns"`
+	Filename      string         `json:"filename"`
+	PageSize      int            `json:"pageSize"`
+}
+
+// Handler 执行 GraphQL 查询（支持持久化查询），将 path 指向的列表逐行导出为 CSV
+// 未分页时单次查询的结果在内存中完整生成；指定 pageSize 时逐页查询并写出，内存占用与页大小相关
+// 开始写出后出错时追加 "#ERROR,<错误信息>" 行并设置 X-Export-Error trailer
+func Handler(exec graphql.GraphExecutor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, err := parseParams(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if p.Path == "" {
+			writeError(w, http.StatusBadRequest, "path is required")
+			return
+		}
+		path := strings.Split(p.Path, ".")
+		if p.PageSize > 0 && len(path) < 2 {
+			writeError(w, http.StatusBadRequest, "path must point into a connection when pageSize is set")
+			return
+		}
+
+		q := &query{exec: exec, req: r, params: p}
+		data, status, resp := q.page("")
+		if resp != nil {
+			writeJSON(w, status, resp)
+			return
+		}
+		dec, err := openList(data, path)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		var next *pageInfo
+		if p.PageSize > 0 {
+			if next, err = readPageInfo(data, path[:len(path)-1]); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		// 未指定列时按首个元素推断
+		var first json.RawMessage
+		hasFirst := dec.More()
+		if hasFirst {
+			if err := dec.Decode(&first); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		columns := ParseColumns(p.Columns)
+		if len(columns) == 0 && hasFirst {
+			if columns, err = inferColumns(first); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+
+		headers := make([]string, len(columns))
+		for i, c := range columns {
+			headers[i] = c.Header
+		}
+
+		filename := p.Filename
+		if filename == "" {
+			filename = "export.csv"
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		w.Header().Set("Trailer", ErrorTrailer)
+		w.WriteHeader(http.StatusOK)
+
+		rows := make(chan []string)
+		var streamErr error
+		go func() {
+			defer close(rows)
+			send := func(item json.RawMessage) error {
+				values, err := row(item, columns)
+				if err != nil {
+					return err
+				}
+				select {
+				case rows <- values:
+					return nil
+				case <-r.Context().Done():
+					return r.Context().Err()
+				}
+			}
+			if hasFirst {
+				if streamErr = send(first); streamErr != nil {
+					return
+				}
+			}
+			for {
+				if streamErr = eachItem(dec, send); streamErr != nil {
+					return
+				}
+				if next == nil || !next.HasNextPage {
+					return
+				}
+				if dec, next, streamErr = q.nextPage(path, next.EndCursor); streamErr != nil {
+					return
+				}
+			}
+		}()
+
+		if err := utils.WriteCsvStream(flushWriter{w}, headers, rows); err != nil {
+			zap.L().Warn("Export aborted", zap.String("path", p.Path), zap.Error(err))
+			return
+		}
+		if streamErr != nil {
+			zap.L().Error("Export failed", zap.String("path", p.Path), zap.Error(streamErr))
+			writeAbort(w, streamErr)
+		}
+	})
+}
+
+// query 按导出参数执行查询，分页时替换 first 和 after 变量
+type query struct {
+	exec   graphql.GraphExecutor
+	req    *http.Request
+	params *params
+}
+
+// page 执行一次查询，出错时返回状态码及 GraphQL 错误响应
+func (q *query) page(after string) ([]byte, int, *graphql.Response) {
+	variables := q.params.Variables
+	if q.params.PageSize > 0 {
+		variables = make(map[string]any, len(q.params.Variables)+2)
+		for k, v := range q.params.Variables {
+			variables[k] = v
+		}
+		variables["first"] = q.params.PageSize
+		if after != "" {
+			variables["after"] = after
+		}
+	}
+
+	raw := &graphql.RawParams{
+		Query:         q.params.Query,
+		OperationName: q.params.OperationName,
+		Variables:     variables,
+		Extensions:    q.params.Extensions,
+		Headers:       q.req.Header,
+	}
+	raw.ReadTime.Start = graphql.Now()
+	raw.ReadTime.End = graphql.Now()
+
+	ctx := graphql.StartOperationTrace(q.req.Context())
+	opCtx, errs := q.exec.CreateOperationContext(ctx, raw)
+	if errs != nil {
+		return nil, http.StatusUnprocessableEntity, q.exec.DispatchError(graphql.WithOperationContext(ctx, opCtx), errs)
+	}
+	if opCtx.Operation.Operation != ast.Query {
+		return nil, http.StatusBadRequest, &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("only queries can be exported")}}
+	}
+
+	handler, ctx := q.exec.DispatchOperation(ctx, opCtx)
+	resp := handler(ctx)
+	if resp == nil {
+		return nil, http.StatusUnprocessableEntity, &graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("empty response")}}
+	}
+	if len(resp.Errors) > 0 {
+		return nil, http.StatusUnprocessableEntity, resp
+	}
+	return resp.Data, http.StatusOK, nil
+}
+
+// nextPage 查询 after 之后的一页，返回列表解码器及该页的分页信息
+func (q *query) nextPage(path []string, after string) (*json.Decoder, *pageInfo, error) {
+	data, _, resp := q.page(after)
+	if resp != nil {
+		msgs := make([]string, len(resp.Errors))
+		for i, e := range resp.Errors {
+			msgs[i] = e.Message
+		}
+		return nil, nil, fmt.Errorf("page after %q failed: %s", after, strings.Join(msgs, "; "))
+	}
+	dec, err := openList(data, path)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := readPageInfo(data, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	return dec, info, nil
+}
+
+// eachItem 逐个解码列表剩余元素
+func eachItem(dec *json.Decoder, fn func(json.RawMessage) error) error {
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeAbort 追加错误行并设置 trailer，客户端据此识别不完整的导出
+func writeAbort(w http.ResponseWriter, err error) {
+	writer := csv.NewWriter(flushWriter{w})
+	if werr := writer.Write([]string{errorMarker, err.Error()}); werr == nil {
+		writer.Flush()
+	}
+	w.Header().Set(ErrorTrailer, err.Error())
+}
+
+func parseParams(r *http.Request) (*params, error) {
+	var p params
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		p.Query = q.Get("query")
+		p.OperationName = q.Get("operationName")
+		p.Path = q.Get("path")
+		p.Filename = q.Get("filename")
+		if size := q.Get("pageSize"); size != "" {
+			n, err := strconv.Atoi(size)
+			if err != nil {
+				return nil, fmt.Errorf("pageSize could not be decoded")
+			}
+			p.PageSize = n
+		}
+		if columns := q.Get("columns"); columns != "" {
+			p.Columns = strings.Split(columns, ",")
+		}
+		for name, dst := range map[string]*map[string]any{"variables": &p.Variables, "extensions": &p.Extensions} {
+			if v := q.Get(name); v != "" {
+				if err := json.Unmarshal([]byte(v), dst); err != nil {
+					return nil, fmt.Errorf("%s could not be decoded", name)
+				}
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			return nil, fmt.Errorf("body could not be decoded: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("method %s not allowed", r.Method)
+	}
+	return &p, nil
+}
+
+// flushWriter 每次写入后立即发送给客户端
+type flushWriter struct {
+	w http.ResponseWriter
+}
+
+func (f flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	if flusher, ok := f.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]any{"errors": []map[string]string{{"message": msg}}})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		zap.L().Warn("Failed to write export error", zap.Error(err))
+	}
+}
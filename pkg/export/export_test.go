@@ -0,0 +1,172 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// stubExecutor 返回固定响应数据
+type stubExecutor struct {
+	data string
+}
+
+func (e stubExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return &graphql.OperationContext{
+		RawQuery:  params.Query,
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	}, nil
+}
+
+func (e stubExecutor) DispatchOperation(ctx context.Context, opCtx *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	return graphql.OneShot(&graphql.Response{Data: []byte(e.data)}), ctx
+}
+
+func (e stubExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+const ordersData = `{"user":{"id":"U1","orders":[
+	{"id":"1","price":{"amount":"10.5","currency":"CNY"},"tags":["a","b"],"filled":false},
+	{"id":"2","price":{"amount":"11","currency":"CNY"},"tags":[],"filled":true}
+]}}`
+
+func export(t *testing.T, query url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/export?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	Handler(stubExecutor{data: ordersData}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_InferColumns(t *testing.T) {
+	rec := export(t, url.Values{"query": {"{ user { orders } }"}, "path": {"user.orders"}})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "id,price.amount,price.currency,tags,filled\n"+
+		"1,10.5,CNY,\"[\"\"a\"\",\"\"b\"\"]\",false\n"+
+		"2,11,CNY,[],true\n", rec.Body.String())
+}
+
+func TestHandler_SelectColumns(t *testing.T) {
+	rec := export(t, url.Values{
+		"query":   {"{ user { orders } }"},
+		"path":    {"user.orders"},
+		"columns": {"id:Order ID,price.amount:Price,missing"},
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "Order ID,Price,missing\n1,10.5,\n2,11,\n", rec.Body.String())
+}
+
+func TestHandler_BadPath(t *testing.T) {
+	rec := export(t, url.Values{"query": {"{ user { id } }"}, "path": {"user.id"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = export(t, url.Values{"query": {"{ user { id } }"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+// pagedExecutor 按 after 变量返回 todos 连接的分页数据，pages 中缺失的游标返回错误
+type pagedExecutor struct {
+	pages map[string]string
+}
+
+func (e pagedExecutor) CreateOperationContext(ctx context.Context, params *graphql.RawParams) (*graphql.OperationContext, gqlerror.List) {
+	return &graphql.OperationContext{
+		RawQuery:  params.Query,
+		Variables: params.Variables,
+		Operation: &ast.OperationDefinition{Operation: ast.Query},
+	}, nil
+}
+
+func (e pagedExecutor) DispatchOperation(ctx context.Context, opCtx *graphql.OperationContext) (graphql.ResponseHandler, context.Context) {
+	after, _ := opCtx.Variables["after"].(string)
+	data, ok := e.pages[after]
+	if !ok || opCtx.Variables["first"] != 2 {
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{gqlerror.Errorf("page %q unavailable", after)}}), ctx
+	}
+	return graphql.OneShot(&graphql.Response{Data: []byte(data)}), ctx
+}
+
+func (e pagedExecutor) DispatchError(ctx context.Context, list gqlerror.List) *graphql.Response {
+	return &graphql.Response{Errors: list}
+}
+
+func exportPaged(t *testing.T, pages map[string]string) *httptest.ResponseRecorder {
+	query := url.Values{
+		"query":    {"query($first: Int, $after: String) { todos(first: $first, after: $after) }"},
+		"path":     {"todos.edges"},
+		"columns":  {"node.id:ID"},
+		"pageSize": {"2"},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/export?"+query.Encode(), nil)
+	rec := httptest.NewRecorder()
+	Handler(pagedExecutor{pages: pages}).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandler_Paged(t *testing.T) {
+	rec := exportPaged(t, map[string]string{
+		"":   `{"todos":{"edges":[{"node":{"id":"1"}},{"node":{"id":"2"}}],"pageInfo":{"hasNextPage":true,"endCursor":"c2"}}}`,
+		"c2": `{"todos":{"edges":[{"node":{"id":"3"}}],"pageInfo":{"hasNextPage":false,"endCursor":"c3"}}}`,
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ID\n1\n2\n3\n", rec.Body.String())
+	assert.Empty(t, rec.Result().Trailer.Get(ErrorTrailer))
+
+	// 缺少 pageInfo 时在写出前拒绝
+	rec = exportPaged(t, map[string]string{"": `{"todos":{"edges":[]}}`})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestHandler_PagedFailure(t *testing.T) {
+	// 后续页失败时已写出的行保留，并追加错误行及 trailer
+	rec := exportPaged(t, map[string]string{
+		"": `{"todos":{"edges":[{"node":{"id":"1"}}],"pageInfo":{"hasNextPage":true,"endCursor":"c1"}}}`,
+	})
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ID\n1\n#ERROR,\"page after \"\"c1\"\" failed: page \"\"c1\"\" unavailable\"\n", rec.Body.String())
+	assert.Contains(t, rec.Result().Trailer.Get(ErrorTrailer), "unavailable")
+}
+
+func TestFileHandler(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "audit_1.csv"), []byte("seq\n1\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(t.TempDir(), "secret.csv"), []byte("x"), 0o644))
+	allowed := true
+	handler := FileHandler(dir, func(ctx context.Context) error {
+		if !allowed {
+			return errors.New("forbidden")
+		}
+		return nil
+	})
+	get := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := get("/audit/export/audit_1.csv")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Header().Get("Content-Disposition"), "audit_1.csv")
+	assert.Equal(t, "seq\n1\n", rec.Body.String())
+
+	// 仅允许访问导出目录中的 CSV 文件
+	assert.Equal(t, http.StatusNotFound, get("/audit/export/missing.csv").Code)
+	assert.Equal(t, http.StatusNotFound, get("/audit/export/..%2fsecret.csv").Code)
+	assert.Equal(t, http.StatusNotFound, get("/audit/export/").Code)
+
+	allowed = false
+	assert.Equal(t, http.StatusForbidden, get("/audit/export/audit_1.csv").Code)
+}
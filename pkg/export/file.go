@@ -0,0 +1,47 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// FileHandler 下载 dir 中已导出的 CSV 文件，请求路径最后一段为文件名
+// authorize 返回错误时拒绝访问，不允许访问 dir 之外的文件
+func FileHandler(dir string, authorize func(ctx context.Context) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed", r.Method))
+			return
+		}
+		if err := authorize(r.Context()); err != nil {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+
+		name := path.Base(r.URL.Path)
+		if !strings.HasSuffix(name, ".csv") || name != filepath.Base(name) {
+			writeError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			writeError(w, http.StatusNotFound, "file not found")
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			writeError(w, http.StatusNotFound, "file not found")
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		http.ServeContent(w, r, name, info.ModTime(), f)
+	})
+}
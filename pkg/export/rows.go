@@ -0,0 +1,208 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrNotList    = errors.New("export path is not a list")
+	ErrNoPageInfo = errors.New("export path is not in a paged connection")
+)
+
+// Column 导出列，Path 为相对列表元素的字段路径（点分隔），Header 为表头
+type Column struct {
+	Path   string
+	Header string
+}
+
+// ParseColumns 解析列定义 "path[:header]"，未指定表头时使用路径
+func ParseColumns(specs []string) []Column {
+	var columns []Column
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		path, header, ok := strings.Cut(spec, ":")
+		path = strings.TrimSpace(path)
+		if !ok || strings.TrimSpace(header) == "" {
+			header = path
+		}
+		columns = append(columns, Column{Path: path, Header: strings.TrimSpace(header)})
+	}
+	return columns
+}
+
+// openList 定位到响应数据中 path 指向的列表，返回的解码器可逐个读取列表元素
+func openList(data []byte, path []string) (*json.Decoder, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	for _, key := range path {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrNotList, strings.Join(path, "."))
+		}
+		found := false
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			if tok == key {
+				found = true
+				break
+			}
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s not found", ErrNotList, strings.Join(path, "."))
+		}
+	}
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotList, strings.Join(path, "."))
+	}
+	return dec, nil
+}
+
+// pageInfo 连接类型的分页信息
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// readPageInfo 读取 path 指向的连接中的 pageInfo，分页查询时每页数据量有限，直接完整解码
+func readPageInfo(data []byte, path []string) (*pageInfo, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	raw, ok := lookup(v, strings.Join(append(path[:len(path):len(path)], "pageInfo"), ".")).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s.pageInfo not found", ErrNoPageInfo, strings.Join(path, "."))
+	}
+	info := &pageInfo{}
+	info.HasNextPage, _ = raw["hasNextPage"].(bool)
+	info.EndCursor, _ = raw["endCursor"].(string)
+	if info.HasNextPage && info.EndCursor == "" {
+		return nil, fmt.Errorf("%w: %s.pageInfo.endCursor is empty", ErrNoPageInfo, strings.Join(path, "."))
+	}
+	return info, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+	return nil
+}
+
+// inferColumns 按字段在响应中的顺序列出元素的全部叶子字段，列表字段整体作为一列
+func inferColumns(item json.RawMessage) ([]Column, error) {
+	dec := json.NewDecoder(bytes.NewReader(item))
+	var columns []Column
+	var walk func(prefix string) error
+	walk = func(prefix string) error {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				path := key.(string)
+				if prefix != "" {
+					path = prefix + "." + path
+				}
+				if err := walk(path); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+			return err
+		case json.Delim('['):
+			columns = append(columns, Column{Path: prefix, Header: prefix})
+			for depth := 1; depth > 0; {
+				tok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				switch tok {
+				case json.Delim('['), json.Delim('{'):
+					depth++
+				case json.Delim(']'), json.Delim('}'):
+					depth--
+				}
+			}
+			return nil
+		default:
+			columns = append(columns, Column{Path: prefix, Header: prefix})
+			return nil
+		}
+	}
+	return columns, walk("")
+}
+
+// row 按列取出元素的值，对象及列表字段序列化为 JSON
+func row(item json.RawMessage, columns []Column) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(item))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	values := make([]string, len(columns))
+	for i, c := range columns {
+		values[i] = format(lookup(v, c.Path))
+	}
+	return values, nil
+}
+
+func lookup(v any, path string) any {
+	if path == "" {
+		return v
+	}
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	return v
+}
+
+func format(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
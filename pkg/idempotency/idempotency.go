@@ -0,0 +1,128 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gqlexample/pkg/cache"
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/middware"
+	"gqlexample/pkg/utils"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const CodeKeyReused = "IDEMPOTENCY_KEY_REUSED"
+
+var (
+	ErrKeyReused = errors.New("idempotency key was used with a different request")
+
+	// errResponse 响应包含错误，不保存
+	errResponse = errors.New("response has errors")
+)
+
+// record 幂等键对应的首次执行，done 关闭后 result 可读
+type record struct {
+	fingerprint string
+	done        chan struct{}
+	result      any
+	err         error
+}
+
+// Store 按幂等键及用户保存首次执行结果，在 TTL 内重复请求直接返回该结果
+// 执行失败的结果不保存，便于客户端修正后使用同一键重试
+type Store struct {
+	records *cache.TTLCache[string, *record]
+}
+
+func New(ttl time.Duration) *Store {
+	s := &Store{records: cache.NewTTLCache[string, *record](ttl)}
+	s.records.StartCleanup(ttl)
+	return s
+}
+
+func (s *Store) Stop() {
+	s.records.Stop()
+}
+
+// Do 以幂等键执行 fn，principal 为空的匿名请求不去重
+// 相同键重复调用时等待并返回首次结果；请求内容不同时返回 IDEMPOTENCY_KEY_REUSED 错误
+func (s *Store) Do(ctx context.Context, principal, key, fingerprint string, fn func() (any, error)) (any, error) {
+	result, found, finish, err := s.Begin(ctx, principal, key, fingerprint)
+	if err != nil || found {
+		return result, err
+	}
+	result, err = fn()
+	finish(result, err)
+	return result, err
+}
+
+// Begin 占用幂等键：键已成功执行过时返回首次结果且 found 为 true，
+// 否则由调用方执行后必须调用 finish 保存结果，执行失败的结果不保存
+// principal 为空时不占用键，finish 不做任何处理
+func (s *Store) Begin(ctx context.Context, principal, key, fingerprint string) (result any, found bool, finish func(any, error), err error) {
+	if principal == "" {
+		return nil, false, func(any, error) {}, nil
+	}
+
+	id := principal + "\x00" + key
+	for {
+		rec, loaded := s.records.SetIfAbsent(id, &record{fingerprint: fingerprint, done: make(chan struct{})})
+		if !loaded {
+			return nil, false, func(result any, err error) {
+				rec.result, rec.err = result, err
+				if err != nil {
+					s.records.Delete(id)
+				}
+				close(rec.done)
+			}, nil
+		}
+
+		if rec.fingerprint != fingerprint {
+			return nil, false, nil, errcode.New(CodeKeyReused, ErrKeyReused)
+		}
+		select {
+		case <-rec.done:
+		case <-ctx.Done():
+			return nil, false, nil, ctx.Err()
+		}
+		if rec.err == nil {
+			return rec.result, true, nil, nil
+		}
+		// 首次执行失败，重新占用
+	}
+}
+
+// Fingerprint 计算请求内容摘要，用于识别相同键的不同请求
+func Fingerprint(parts ...any) string {
+	data, _ := json.Marshal(parts)
+	return utils.Md5Encode(string(data))
+}
+
+// AroundOperations 对携带 Idempotency-Key 请求头的变更操作去重，返回首次成功的响应
+func (s *Store) AroundOperations(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	op := graphql.GetOperationContext(ctx)
+	key := middware.IdempotencyKeyFromContext(ctx)
+	if key == "" || op.Operation == nil || op.Operation.Operation != ast.Mutation {
+		return next(ctx)
+	}
+
+	fingerprint := Fingerprint(op.RawQuery, op.OperationName, op.Variables)
+	result, err := s.Do(ctx, middware.UserIDFromContext(ctx), "operation:"+key, fingerprint, func() (any, error) {
+		resp := next(ctx)(ctx)
+		if resp == nil || len(resp.Errors) > 0 {
+			// 出错的响应不保存，直接返回给本次请求
+			return resp, errResponse
+		}
+		return resp, nil
+	})
+	if err != nil && !errors.Is(err, errResponse) {
+		return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{errcode.Presenter(ctx, err)}})
+	}
+	resp, _ := result.(*graphql.Response)
+	return graphql.OneShot(resp)
+}
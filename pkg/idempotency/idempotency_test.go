@@ -0,0 +1,101 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gqlexample/pkg/errcode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Do(t *testing.T) {
+	s := New(time.Minute)
+	defer s.Stop()
+	ctx := context.Background()
+
+	var calls int32
+	fn := func() (any, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+
+	// 并发重复请求只执行一次
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.Do(ctx, "U1", "k1", Fingerprint("a"), fn)
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), result)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+
+	// 不同用户的同名键互不影响
+	result, err := s.Do(ctx, "U2", "k1", Fingerprint("a"), fn)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), result)
+
+	// 相同键不同请求内容
+	_, err = s.Do(ctx, "U1", "k1", Fingerprint("b"), fn)
+	assert.ErrorIs(t, err, ErrKeyReused)
+	assert.Equal(t, CodeKeyReused, errcode.Code(err))
+}
+
+func TestStore_DoFailureNotStored(t *testing.T) {
+	s := New(time.Minute)
+	defer s.Stop()
+	ctx := context.Background()
+
+	_, err := s.Do(ctx, "U1", "k", "f", func() (any, error) { return nil, errors.New("boom") })
+	require.Error(t, err)
+
+	result, err := s.Do(ctx, "U1", "k", "f", func() (any, error) { return "ok", nil })
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+}
+
+func TestStore_DoAnonymous(t *testing.T) {
+	s := New(time.Minute)
+	defer s.Stop()
+	ctx := context.Background()
+
+	// 匿名请求不共享键空间，每次都执行
+	var calls int32
+	fn := func() (any, error) {
+		return atomic.AddInt32(&calls, 1), nil
+	}
+	for i := int32(1); i <= 2; i++ {
+		result, err := s.Do(ctx, "", "k", Fingerprint("a"), fn)
+		require.NoError(t, err)
+		assert.Equal(t, i, result)
+	}
+	_, err := s.Do(ctx, "", "k", Fingerprint("b"), fn)
+	assert.NoError(t, err)
+}
+
+func TestStore_Begin(t *testing.T) {
+	s := New(time.Minute)
+	defer s.Stop()
+	ctx := context.Background()
+
+	_, found, finish, err := s.Begin(ctx, "U1", "k", "f")
+	require.NoError(t, err)
+	require.False(t, found)
+	finish("first", nil)
+
+	result, found, _, err := s.Begin(ctx, "U1", "k", "f")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "first", result)
+
+	_, _, _, err = s.Begin(ctx, "U1", "k", "g")
+	assert.ErrorIs(t, err, ErrKeyReused)
+}
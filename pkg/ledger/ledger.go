@@ -0,0 +1,166 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrEmptyTransaction  = errors.New("transaction has no postings")
+	ErrInvalidPosting    = errors.New("posting amount must be non-zero")
+	ErrUnbalanced        = errors.New("transaction postings do not balance")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+)
+
+// Kind 交易类型
+type Kind string
+
+const (
+	Deposit    Kind = "DEPOSIT"
+	Withdrawal Kind = "WITHDRAWAL"
+	Reserve    Kind = "RESERVE" // 下单占用买入资金
+	Release    Kind = "RELEASE" // 撤单或订单结束释放占用
+	Trade      Kind = "TRADE"   // 成交结算
+	Fee        Kind = "FEE"
+)
+
+// Posting 交易中对单个账户的记账，正数增加余额，负数减少余额
+type Posting struct {
+	Account string
+	Amount  decimal.Decimal
+}
+
+// Guard 过账后账户余额不得低于 Min，否则整笔交易不记账
+type Guard struct {
+	Account string
+	Min     decimal.Decimal
+}
+
+// Entry 一条分录，记账后不可修改
+type Entry struct {
+	ID        string
+	TxID      string
+	Kind      Kind
+	Account   string
+	Currency  string
+	Amount    decimal.Decimal
+	Reference string
+	At        time.Time
+}
+
+// Ledger 复式记账账簿，每笔交易的分录按币种合计为零，因此全部余额合计始终为零
+// 余额由分录汇总得出，账簿只追加不修改
+type Ledger struct {
+	mu        sync.RWMutex
+	txSeq     int64
+	entries   []Entry
+	byAccount map[string][]int // 账户到分录下标
+}
+
+func New() *Ledger {
+	return &Ledger{byAccount: make(map[string][]int)}
+}
+
+// Post 记录一笔交易并返回交易 ID，postings 合计须为零，guards 中任一账户过账后余额不足时返回 ErrInsufficientFunds
+func (l *Ledger) Post(kind Kind, currency, reference string, at time.Time, postings []Posting, guards ...Guard) (string, error) {
+	if len(postings) == 0 {
+		return "", ErrEmptyTransaction
+	}
+	sum := decimal.Zero
+	for _, p := range postings {
+		if p.Amount.IsZero() {
+			return "", fmt.Errorf("%w: %s", ErrInvalidPosting, p.Account)
+		}
+		sum = sum.Add(p.Amount)
+	}
+	if !sum.IsZero() {
+		return "", fmt.Errorf("%w: %s %s off by %s", ErrUnbalanced, kind, reference, sum)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, g := range guards {
+		after := l.balance(g.Account, currency)
+		for _, p := range postings {
+			if p.Account == g.Account {
+				after = after.Add(p.Amount)
+			}
+		}
+		if after.LessThan(g.Min) {
+			return "", fmt.Errorf("%w: %s would be %s %s", ErrInsufficientFunds, g.Account, after, currency)
+		}
+	}
+
+	l.txSeq++
+	txID := strconv.FormatInt(l.txSeq, 10)
+	for _, p := range postings {
+		l.byAccount[p.Account] = append(l.byAccount[p.Account], len(l.entries))
+		l.entries = append(l.entries, Entry{
+			ID:        strconv.Itoa(len(l.entries) + 1),
+			TxID:      txID,
+			Kind:      kind,
+			Account:   p.Account,
+			Currency:  currency,
+			Amount:    p.Amount,
+			Reference: reference,
+			At:        at,
+		})
+	}
+	return txID, nil
+}
+
+// Balance 汇总账户在币种下的分录
+func (l *Ledger) Balance(account, currency string) decimal.Decimal {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.balance(account, currency)
+}
+
+func (l *Ledger) balance(account, currency string) decimal.Decimal {
+	sum := decimal.Zero
+	for _, i := range l.byAccount[account] {
+		if e := l.entries[i]; e.Currency == currency {
+			sum = sum.Add(e.Amount)
+		}
+	}
+	return sum
+}
+
+// Entries 返回各账户的分录，按记账顺序排列
+func (l *Ledger) Entries(accounts ...string) []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var indexes []int
+	for _, account := range accounts {
+		indexes = append(indexes, l.byAccount[account]...)
+	}
+	slices.Sort(indexes)
+
+	result := make([]Entry, 0, len(indexes))
+	for _, i := range slices.Compact(indexes) {
+		result = append(result, l.entries[i])
+	}
+	return result
+}
+
+// Total 返回全部账户在币种下的余额合计，账簿平衡时为零
+func (l *Ledger) Total(currency string) decimal.Decimal {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	sum := decimal.Zero
+	for _, e := range l.entries {
+		if e.Currency == currency {
+			sum = sum.Add(e.Amount)
+		}
+	}
+	return sum
+}
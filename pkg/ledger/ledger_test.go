@@ -0,0 +1,48 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func amount(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestLedger_Post(t *testing.T) {
+	l := New()
+	now := time.Now()
+
+	_, err := l.Post(Deposit, "CNY", "D1", now, []Posting{{"bank", amount("-100")}, {"cash", amount("100")}})
+	require.NoError(t, err)
+	_, err = l.Post(Reserve, "CNY", "O1", now, []Posting{{"cash", amount("-60")}, {"reserved", amount("60")}},
+		Guard{Account: "cash"})
+	require.NoError(t, err)
+
+	// 不平衡或余额不足的交易整体不记账
+	_, err = l.Post(Deposit, "CNY", "D2", now, []Posting{{"bank", amount("-100")}, {"cash", amount("99")}})
+	assert.ErrorIs(t, err, ErrUnbalanced)
+	_, err = l.Post(Reserve, "CNY", "O2", now, []Posting{{"cash", amount("-60")}, {"reserved", amount("60")}},
+		Guard{Account: "cash"})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = l.Post(Fee, "CNY", "F1", now, []Posting{{"cash", decimal.Zero}})
+	assert.ErrorIs(t, err, ErrInvalidPosting)
+	_, err = l.Post(Fee, "CNY", "F1", now, nil)
+	assert.ErrorIs(t, err, ErrEmptyTransaction)
+
+	assert.Equal(t, "40", l.Balance("cash", "CNY").String())
+	assert.Equal(t, "60", l.Balance("reserved", "CNY").String())
+	assert.True(t, l.Balance("cash", "USD").IsZero())
+	assert.True(t, l.Total("CNY").IsZero())
+
+	entries := l.Entries("cash")
+	require.Len(t, entries, 2)
+	assert.Equal(t, Reserve, entries[1].Kind)
+	assert.Equal(t, "O1", entries[1].Reference)
+	assert.Equal(t, entries[1].TxID, l.Entries("reserved")[0].TxID)
+	assert.Len(t, l.Entries("cash", "reserved", "cash"), 3)
+}
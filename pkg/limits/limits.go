@@ -0,0 +1,177 @@
+package limits
+
+import (
+	"errors"
+	"fmt"
+	"gqlexample/pkg/errcode"
+	"sync"
+	"time"
+)
+
+const (
+	CodeMaxTradingCount = "MAX_TRADING_COUNT_EXCEEDED"
+	CodeTradingInterval = "TRADING_INTERVAL_NOT_ELAPSED"
+)
+
+var (
+	ErrMaxTradingCount = errors.New("max trading count exceeded")
+	ErrTradingInterval = errors.New("trading interval not elapsed")
+)
+
+// Limiter 按账户限制每个交易日的下单次数及两次下单的最小间隔
+type Limiter struct {
+	mu         sync.Mutex
+	maxCount   int
+	interval   time.Duration
+	tradingDay string
+	accounts   map[string]*usage
+}
+
+// usage 账户的下单计数，acquiredAt、acquired 及 prevLast 记录最近一次占用，用于撤销
+type usage struct {
+	count      int
+	last       time.Time
+	acquiredAt time.Time
+	acquired   int
+	prevLast   time.Time
+}
+
+// Usage 账户在交易日内的限额使用情况
+type Usage struct {
+	AccountID     string
+	TradingDay    string
+	Count         int
+	MaxCount      int
+	Interval      time.Duration
+	LastOrderAt   time.Time // 尚未下单时为零值
+	NextAllowedAt time.Time // 不受间隔限制时为零值
+}
+
+func (u Usage) Remaining() int {
+	return max(u.MaxCount-u.Count, 0)
+}
+
+func New(maxCount int, interval time.Duration) *Limiter {
+	return &Limiter{
+		maxCount: maxCount,
+		interval: interval,
+		accounts: make(map[string]*usage),
+	}
+}
+
+// Update 更新限额配置，已有计数保留
+func (l *Limiter) Update(maxCount int, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxCount = maxCount
+	l.interval = interval
+}
+
+// Acquire 校验并占用一次下单额度，交易日变化时清零全部计数
+func (l *Limiter) Acquire(accountID, tradingDay string, at time.Time) error {
+	return l.AcquireBatch(map[string]int{accountID: 1}, tradingDay, at, false)[accountID]
+}
+
+// AcquireBatch 为批量下单按账户占用额度，atomic 为 true 时任一账户超限则全部不占用，返回值为各超限账户的错误
+// 账户的 n 笔订单视为从 at 开始按最小间隔依次下单，下一笔订单须在最后一笔之后再间隔一次
+func (l *Limiter) AcquireBatch(counts map[string]int, tradingDay string, at time.Time, atomic bool) map[string]error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(tradingDay)
+	errs := make(map[string]error)
+	for accountID, n := range counts {
+		if err := l.check(accountID, tradingDay, at, n); err != nil {
+			errs[accountID] = err
+		}
+	}
+	if atomic && len(errs) > 0 {
+		return errs
+	}
+
+	for accountID, n := range counts {
+		if errs[accountID] != nil {
+			continue
+		}
+		u, ok := l.accounts[accountID]
+		if !ok {
+			u = &usage{}
+			l.accounts[accountID] = u
+		}
+		u.prevLast, u.acquiredAt, u.acquired = u.last, at, n
+		u.count += n
+		u.last = at.Add(time.Duration(n-1) * l.interval)
+	}
+	return errs
+}
+
+// Release 退回 at 时刻占用的 n 笔额度，下单最终失败时调用
+// 该次占用之后账户没有新的占用时，按剩余笔数恢复最小间隔的计时
+func (l *Limiter) Release(accountID, tradingDay string, at time.Time, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.accounts[accountID]
+	if !ok || tradingDay != l.tradingDay || n <= 0 {
+		return
+	}
+	u.count = max(u.count-n, 0)
+	if !u.acquiredAt.Equal(at) || u.acquired <= 0 {
+		return
+	}
+	u.acquired = max(u.acquired-n, 0)
+	if u.acquired == 0 {
+		u.last = u.prevLast
+	} else {
+		u.last = at.Add(time.Duration(u.acquired-1) * l.interval)
+	}
+}
+
+func (l *Limiter) check(accountID, tradingDay string, at time.Time, n int) error {
+	u, ok := l.accounts[accountID]
+	if !ok {
+		u = &usage{}
+	}
+
+	if u.count+n > l.maxCount {
+		return errcode.New(CodeMaxTradingCount,
+			fmt.Errorf("%w: account %s placed %d orders on %s", ErrMaxTradingCount, accountID, u.count, tradingDay)).
+			With("limit", l.maxCount)
+	}
+	if !u.last.IsZero() && at.Sub(u.last) < l.interval {
+		next := u.last.Add(l.interval)
+		return errcode.New(CodeTradingInterval,
+			fmt.Errorf("%w: account %s may place next order at %s", ErrTradingInterval, accountID, next.Format(time.RFC3339Nano))).
+			With("retryAfterMs", next.Sub(at).Milliseconds())
+	}
+	return nil
+}
+
+// Usage 返回账户在交易日内的使用情况
+func (l *Limiter) Usage(accountID, tradingDay string) Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.roll(tradingDay)
+	result := Usage{
+		AccountID:  accountID,
+		TradingDay: tradingDay,
+		MaxCount:   l.maxCount,
+		Interval:   l.interval,
+	}
+	if u, ok := l.accounts[accountID]; ok {
+		result.Count = u.count
+		result.LastOrderAt = u.last
+		if l.interval > 0 {
+			result.NextAllowedAt = u.last.Add(l.interval)
+		}
+	}
+	return result
+}
+
+func (l *Limiter) roll(tradingDay string) {
+	if tradingDay != l.tradingDay {
+		l.tradingDay = tradingDay
+		l.accounts = make(map[string]*usage)
+	}
+}
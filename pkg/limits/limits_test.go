@@ -0,0 +1,93 @@
+package limits
+
+import (
+	"testing"
+	"time"
+
+	"gqlexample/pkg/errcode"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Acquire(t *testing.T) {
+	l := New(2, time.Second)
+	t0 := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, l.Acquire("A1", "20240102", t0))
+
+	// 间隔不足一秒
+	err := l.Acquire("A1", "20240102", t0.Add(500*time.Millisecond))
+	assert.ErrorIs(t, err, ErrTradingInterval)
+	assert.Equal(t, CodeTradingInterval, errcode.Code(err))
+
+	// 其他账户不受影响
+	require.NoError(t, l.Acquire("A2", "20240102", t0.Add(500*time.Millisecond)))
+
+	require.NoError(t, l.Acquire("A1", "20240102", t0.Add(time.Second)))
+	err = l.Acquire("A1", "20240102", t0.Add(5*time.Second))
+	assert.ErrorIs(t, err, ErrMaxTradingCount)
+	assert.Equal(t, CodeMaxTradingCount, errcode.Code(err))
+
+	u := l.Usage("A1", "20240102")
+	assert.Equal(t, 2, u.Count)
+	assert.Equal(t, 0, u.Remaining())
+	assert.Equal(t, t0.Add(2*time.Second), u.NextAllowedAt)
+
+	// 新交易日重新计数
+	require.NoError(t, l.Acquire("A1", "20240103", t0.Add(24*time.Hour)))
+	assert.Equal(t, 1, l.Usage("A1", "20240103").Count)
+}
+
+func TestLimiter_AcquireBatch(t *testing.T) {
+	l := New(3, time.Second)
+	t0 := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	// 批次内的订单按间隔依次计算，A1 的两笔订单占用至 t0+1s
+	errs := l.AcquireBatch(map[string]int{"A1": 2, "A2": 1}, "20240102", t0, false)
+	assert.Empty(t, errs)
+	assert.Equal(t, t0.Add(2*time.Second), l.Usage("A1", "20240102").NextAllowedAt)
+	assert.ErrorIs(t, l.Acquire("A1", "20240102", t0.Add(time.Second)), ErrTradingInterval)
+
+	// 原子模式下 A1 超限时 A2 也不占用
+	errs = l.AcquireBatch(map[string]int{"A1": 2, "A2": 1}, "20240102", t0.Add(time.Second), true)
+	assert.ErrorIs(t, errs["A1"], ErrMaxTradingCount)
+	assert.NotContains(t, errs, "A2")
+	assert.Equal(t, 1, l.Usage("A2", "20240102").Count)
+
+	errs = l.AcquireBatch(map[string]int{"A1": 2, "A2": 1}, "20240102", t0.Add(time.Second), false)
+	assert.ErrorIs(t, errs["A1"], ErrMaxTradingCount)
+	assert.Equal(t, 2, l.Usage("A2", "20240102").Count)
+}
+
+func TestLimiter_Release(t *testing.T) {
+	l := New(2, time.Second)
+	t0 := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, l.Acquire("A1", "20240102", t0))
+	errs := l.AcquireBatch(map[string]int{"A1": 1, "A2": 2}, "20240102", t0.Add(time.Second), false)
+	require.Empty(t, errs)
+
+	// 退回批次中的一笔，间隔按剩余笔数计算
+	l.Release("A2", "20240102", t0.Add(time.Second), 1)
+	u := l.Usage("A2", "20240102")
+	assert.Equal(t, 1, u.Count)
+	assert.Equal(t, t0.Add(2*time.Second), u.NextAllowedAt)
+
+	// 全部退回后恢复上一次下单的计时
+	l.Release("A1", "20240102", t0.Add(time.Second), 1)
+	u = l.Usage("A1", "20240102")
+	assert.Equal(t, 1, u.Count)
+	assert.Equal(t, t0, u.LastOrderAt)
+	require.NoError(t, l.Acquire("A1", "20240102", t0.Add(time.Second)))
+
+	// 非最近一次占用只退回计数
+	l.Release("A1", "20240102", t0, 1)
+	u = l.Usage("A1", "20240102")
+	assert.Equal(t, 1, u.Count)
+	assert.Equal(t, t0.Add(time.Second), u.LastOrderAt)
+
+	// 交易日变化后忽略
+	l.Release("A1", "20240101", t0.Add(time.Second), 1)
+	assert.Equal(t, 1, l.Usage("A1", "20240102").Count)
+}
@@ -0,0 +1,318 @@
+package matching
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrDuplicateOrder  = errors.New("order already in book")
+	ErrOrderNotFound   = errors.New("order not in book")
+	ErrInvalidQuantity = errors.New("quantity must be positive")
+)
+
+// Side 买卖方向
+type Side int8
+
+const (
+	Buy Side = iota
+	Sell
+)
+
+// Order 送入撮合的订单，Quantity 为待成交数量
+type Order struct {
+	ID       string
+	Side     Side
+	Price    decimal.Decimal // 市价单忽略
+	Market   bool
+	Quantity int32
+}
+
+// Fill 一笔成交，按被动方（maker）挂单价格成交
+type Fill struct {
+	TakerID  string
+	MakerID  string
+	Price    decimal.Decimal
+	Quantity int32
+}
+
+// Level 价位聚合数量
+type Level struct {
+	Side     Side
+	Price    decimal.Decimal
+	Quantity int64
+	Orders   int
+}
+
+// Result 一次订单簿操作的结果
+type Result struct {
+	Fills     []Fill
+	Resting   int32   // 挂入订单簿的剩余数量
+	Remaining int32   // 未成交也未挂单的数量，即市价单剩余
+	Changes   []Level // 发生变化的价位的最新聚合，Quantity 为 0 表示价位已移除
+	Sequence  int64   // 订单簿变化序号，Changes 为空时不递增
+}
+
+type entry struct {
+	order Order
+	level *priceLevel
+	elem  *list.Element
+}
+
+type priceLevel struct {
+	side     Side
+	price    decimal.Decimal
+	quantity int64
+	orders   *list.List // 按时间排队的 *entry
+}
+
+func (l *priceLevel) snapshot() Level {
+	return Level{Side: l.side, Price: l.price, Quantity: l.quantity, Orders: l.orders.Len()}
+}
+
+// Book 单个合约的限价订单簿，价格优先、时间优先
+// 非并发安全，由 Engine.Do 串行化访问
+type Book struct {
+	bids      []*priceLevel // 价格从高到低
+	asks      []*priceLevel // 价格从低到高
+	index     map[string]*entry
+	seq       int64
+	lastPrice decimal.Decimal
+	traded    bool
+
+	touched []Level // 当前操作涉及的价位，操作结束时转换为 Changes
+}
+
+func NewBook() *Book {
+	return &Book{index: make(map[string]*entry)}
+}
+
+// Submit 撮合新订单，限价单剩余部分挂单，市价单剩余部分不挂单
+func (b *Book) Submit(o Order) (Result, error) {
+	if _, ok := b.index[o.ID]; ok {
+		return Result{}, fmt.Errorf("%w: %s", ErrDuplicateOrder, o.ID)
+	}
+	if o.Quantity <= 0 {
+		return Result{}, fmt.Errorf("%w: %d", ErrInvalidQuantity, o.Quantity)
+	}
+
+	var res Result
+	b.match(&o, &res)
+	if o.Quantity > 0 {
+		if o.Market {
+			res.Remaining = o.Quantity
+		} else {
+			b.rest(o)
+			res.Resting = o.Quantity
+		}
+	}
+	return b.finish(res), nil
+}
+
+// Cancel 撤销挂单
+func (b *Book) Cancel(id string) (Result, error) {
+	e, ok := b.index[id]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
+	}
+	b.remove(e)
+	return b.finish(Result{}), nil
+}
+
+// Amend 修改挂单价格及剩余数量
+// 价格不变且数量减少时保留时间优先级，否则按新订单重新撮合
+func (b *Book) Amend(id string, price decimal.Decimal, quantity int32) (Result, error) {
+	e, ok := b.index[id]
+	if !ok {
+		return Result{}, fmt.Errorf("%w: %s", ErrOrderNotFound, id)
+	}
+	if quantity <= 0 {
+		return Result{}, fmt.Errorf("%w: %d", ErrInvalidQuantity, quantity)
+	}
+
+	if price.Equal(e.order.Price) && quantity <= e.order.Quantity {
+		e.level.quantity -= int64(e.order.Quantity - quantity)
+		e.order.Quantity = quantity
+		b.touch(e.level)
+		return b.finish(Result{Resting: quantity}), nil
+	}
+
+	o := e.order
+	o.Price = price
+	o.Quantity = quantity
+	b.remove(e)
+
+	var res Result
+	b.match(&o, &res)
+	if o.Quantity > 0 {
+		b.rest(o)
+		res.Resting = o.Quantity
+	}
+	return b.finish(res), nil
+}
+
+// Preview 返回提交 o 将产生的成交，不修改订单簿，撮合前据此结算资金
+func (b *Book) Preview(o Order) []Fill {
+	opposite := b.asks
+	if o.Side == Sell {
+		opposite = b.bids
+	}
+
+	var fills []Fill
+	for _, level := range opposite {
+		if o.Quantity <= 0 || (!o.Market && !crosses(&o, level.price)) {
+			break
+		}
+		for e := level.orders.Front(); e != nil && o.Quantity > 0; e = e.Next() {
+			maker := e.Value.(*entry)
+			qty := min(o.Quantity, maker.order.Quantity)
+			fills = append(fills, Fill{TakerID: o.ID, MakerID: maker.order.ID, Price: level.price, Quantity: qty})
+			o.Quantity -= qty
+		}
+	}
+	return fills
+}
+
+// Depth 返回买卖双方最优的 n 个价位
+func (b *Book) Depth(n int) (bids, asks []Level) {
+	return depth(b.bids, n), depth(b.asks, n)
+}
+
+// Sequence 当前变化序号
+func (b *Book) Sequence() int64 {
+	return b.seq
+}
+
+// LastPrice 最新成交价，尚无成交时返回 false
+func (b *Book) LastPrice() (decimal.Decimal, bool) {
+	return b.lastPrice, b.traded
+}
+
+// match 与对手方挂单撮合，成交后扣减 o.Quantity
+func (b *Book) match(o *Order, res *Result) {
+	opposite := &b.asks
+	if o.Side == Sell {
+		opposite = &b.bids
+	}
+
+	for o.Quantity > 0 && len(*opposite) > 0 {
+		best := (*opposite)[0]
+		if !o.Market && !crosses(o, best.price) {
+			return
+		}
+		for o.Quantity > 0 && best.orders.Len() > 0 {
+			maker := best.orders.Front().Value.(*entry)
+			qty := min(o.Quantity, maker.order.Quantity)
+			res.Fills = append(res.Fills, Fill{
+				TakerID:  o.ID,
+				MakerID:  maker.order.ID,
+				Price:    best.price,
+				Quantity: qty,
+			})
+			b.lastPrice = best.price
+			b.traded = true
+
+			o.Quantity -= qty
+			maker.order.Quantity -= qty
+			best.quantity -= int64(qty)
+			b.touch(best)
+			if maker.order.Quantity == 0 {
+				b.remove(maker)
+			}
+		}
+	}
+}
+
+// crosses 限价单价格是否可与对手方价位成交
+func crosses(o *Order, price decimal.Decimal) bool {
+	if o.Side == Buy {
+		return o.Price.GreaterThanOrEqual(price)
+	}
+	return o.Price.LessThanOrEqual(price)
+}
+
+// rest 将剩余数量挂入订单簿
+func (b *Book) rest(o Order) {
+	levels := &b.bids
+	better := func(p decimal.Decimal) bool { return p.LessThanOrEqual(o.Price) }
+	if o.Side == Sell {
+		levels = &b.asks
+		better = func(p decimal.Decimal) bool { return p.GreaterThanOrEqual(o.Price) }
+	}
+
+	// 找到第一个不优于新价格的价位
+	i := sort.Search(len(*levels), func(i int) bool { return better((*levels)[i].price) })
+	var level *priceLevel
+	if i < len(*levels) && (*levels)[i].price.Equal(o.Price) {
+		level = (*levels)[i]
+	} else {
+		level = &priceLevel{side: o.Side, price: o.Price, orders: list.New()}
+		*levels = append(*levels, nil)
+		copy((*levels)[i+1:], (*levels)[i:])
+		(*levels)[i] = level
+	}
+
+	e := &entry{order: o, level: level}
+	e.elem = level.orders.PushBack(e)
+	level.quantity += int64(o.Quantity)
+	b.index[o.ID] = e
+	b.touch(level)
+}
+
+// remove 将挂单移出订单簿，价位为空时一并移除
+func (b *Book) remove(e *entry) {
+	level := e.level
+	level.orders.Remove(e.elem)
+	level.quantity -= int64(e.order.Quantity)
+	delete(b.index, e.order.ID)
+	b.touch(level)
+
+	if level.orders.Len() > 0 {
+		return
+	}
+	levels := &b.bids
+	if level.side == Sell {
+		levels = &b.asks
+	}
+	for i, l := range *levels {
+		if l == level {
+			*levels = append((*levels)[:i], (*levels)[i+1:]...)
+			break
+		}
+	}
+}
+
+// touch 记录发生变化的价位
+func (b *Book) touch(level *priceLevel) {
+	for i, t := range b.touched {
+		if t.Side == level.side && t.Price.Equal(level.price) {
+			b.touched[i] = level.snapshot()
+			return
+		}
+	}
+	b.touched = append(b.touched, level.snapshot())
+}
+
+// finish 汇总本次操作的价位变化并递增序号
+func (b *Book) finish(res Result) Result {
+	if len(b.touched) > 0 {
+		res.Changes = b.touched
+		b.touched = nil
+		b.seq++
+	}
+	res.Sequence = b.seq
+	return res
+}
+
+func depth(levels []*priceLevel, n int) []Level {
+	n = min(n, len(levels))
+	result := make([]Level, 0, n)
+	for _, l := range levels[:n] {
+		result = append(result, l.snapshot())
+	}
+	return result
+}
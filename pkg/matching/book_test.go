@@ -0,0 +1,141 @@
+package matching
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func limit(id string, side Side, price string, qty int32) Order {
+	return Order{ID: id, Side: side, Price: decimal.RequireFromString(price), Quantity: qty}
+}
+
+func submit(t *testing.T, b *Book, o Order) Result {
+	res, err := b.Submit(o)
+	require.NoError(t, err)
+	return res
+}
+
+func TestBook_PriceTimePriority(t *testing.T) {
+	b := NewBook()
+	submit(t, b, limit("s1", Sell, "10.02", 100))
+	submit(t, b, limit("s2", Sell, "10.01", 100))
+	submit(t, b, limit("s3", Sell, "10.01", 100))
+	submit(t, b, limit("b1", Buy, "9.99", 100))
+
+	// 先成交更优价格，同价位按时间先后
+	res := submit(t, b, limit("b2", Buy, "10.02", 250))
+	require.Len(t, res.Fills, 3)
+	assert.Equal(t, "s2", res.Fills[0].MakerID)
+	assert.Equal(t, "s3", res.Fills[1].MakerID)
+	assert.Equal(t, "s1", res.Fills[2].MakerID)
+	assert.Equal(t, int32(50), res.Fills[2].Quantity)
+	assert.True(t, decimal.RequireFromString("10.02").Equal(res.Fills[2].Price))
+	assert.Zero(t, res.Resting)
+
+	// 10.01 价位已移除，10.02 剩余 50
+	require.Len(t, res.Changes, 2)
+	assert.Zero(t, res.Changes[0].Quantity)
+	assert.Equal(t, int64(50), res.Changes[1].Quantity)
+
+	bids, asks := b.Depth(5)
+	require.Len(t, asks, 1)
+	assert.Equal(t, int64(50), asks[0].Quantity)
+	require.Len(t, bids, 1)
+	assert.Equal(t, "9.99", bids[0].Price.String())
+
+	last, ok := b.LastPrice()
+	assert.True(t, ok)
+	assert.Equal(t, "10.02", last.String())
+}
+
+func TestBook_LimitRestsRemainder(t *testing.T) {
+	b := NewBook()
+	submit(t, b, limit("s1", Sell, "10.00", 100))
+
+	res := submit(t, b, limit("b1", Buy, "10.00", 300))
+	require.Len(t, res.Fills, 1)
+	assert.Equal(t, int32(200), res.Resting)
+
+	bids, asks := b.Depth(5)
+	assert.Empty(t, asks)
+	require.Len(t, bids, 1)
+	assert.Equal(t, Level{Side: Buy, Price: bids[0].Price, Quantity: 200, Orders: 1}, bids[0])
+}
+
+func TestBook_MarketOrder(t *testing.T) {
+	b := NewBook()
+	submit(t, b, limit("b1", Buy, "9.98", 100))
+	submit(t, b, limit("b2", Buy, "9.99", 100))
+
+	res := submit(t, b, Order{ID: "m1", Side: Sell, Market: true, Quantity: 300})
+	require.Len(t, res.Fills, 2)
+	assert.Equal(t, "b2", res.Fills[0].MakerID)
+	assert.Equal(t, int32(100), res.Remaining)
+	assert.Zero(t, res.Resting)
+
+	bids, asks := b.Depth(5)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+}
+
+func TestBook_CancelAndAmend(t *testing.T) {
+	b := NewBook()
+	submit(t, b, limit("b1", Buy, "10.00", 100))
+	submit(t, b, limit("b2", Buy, "10.00", 100))
+	seq := b.Sequence()
+
+	// 减量保留优先级
+	res, err := b.Amend("b1", decimal.RequireFromString("10.00"), 50)
+	require.NoError(t, err)
+	assert.Equal(t, seq+1, res.Sequence)
+	require.Len(t, res.Changes, 1)
+	assert.Equal(t, int64(150), res.Changes[0].Quantity)
+
+	res = submit(t, b, limit("s1", Sell, "10.00", 60))
+	require.Len(t, res.Fills, 2)
+	assert.Equal(t, "b1", res.Fills[0].MakerID)
+	assert.Equal(t, "b2", res.Fills[1].MakerID)
+
+	// 改价后按新订单撮合
+	submit(t, b, limit("s2", Sell, "10.05", 100))
+	res, err = b.Amend("b2", decimal.RequireFromString("10.05"), 90)
+	require.NoError(t, err)
+	require.Len(t, res.Fills, 1)
+	assert.Equal(t, "b2", res.Fills[0].TakerID)
+	assert.Equal(t, int32(90), res.Fills[0].Quantity)
+
+	res, err = b.Cancel("s2")
+	require.NoError(t, err)
+	assert.Zero(t, res.Changes[0].Quantity)
+
+	_, err = b.Cancel("s2")
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	_, err = b.Submit(limit("x", Buy, "1", 0))
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+}
+
+func TestBook_Preview(t *testing.T) {
+	b := NewBook()
+	submit(t, b, limit("s1", Sell, "10.02", 100))
+	submit(t, b, limit("s2", Sell, "10.01", 100))
+	submit(t, b, limit("s3", Sell, "10.01", 100))
+
+	// 预演不修改订单簿，随后提交的成交与预演一致
+	for _, o := range []Order{
+		limit("b1", Buy, "10.01", 150),
+		{ID: "b2", Side: Buy, Market: true, Quantity: 500},
+		limit("b3", Buy, "10.00", 100),
+	} {
+		seq := b.Sequence()
+		bids, asks := b.Depth(5)
+		fills := b.Preview(o)
+		assert.Equal(t, seq, b.Sequence())
+		gotBids, gotAsks := b.Depth(5)
+		assert.Equal(t, bids, gotBids)
+		assert.Equal(t, asks, gotAsks)
+		assert.Equal(t, fills, submit(t, b, o).Fills)
+	}
+}
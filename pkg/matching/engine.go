@@ -0,0 +1,34 @@
+package matching
+
+import "sync"
+
+// Engine 按合约管理订单簿，同一合约的操作串行执行
+type Engine struct {
+	mu    sync.Mutex
+	books map[string]*lockedBook
+}
+
+type lockedBook struct {
+	mu   sync.Mutex
+	book *Book
+}
+
+func NewEngine() *Engine {
+	return &Engine{books: make(map[string]*lockedBook)}
+}
+
+// Do 持有合约订单簿的锁执行 fn，订单簿不存在时创建
+// 调用方可在 fn 内同步更新订单状态，保证与订单簿变化顺序一致
+func (e *Engine) Do(instrumentID string, fn func(*Book) error) error {
+	e.mu.Lock()
+	lb, ok := e.books[instrumentID]
+	if !ok {
+		lb = &lockedBook{book: NewBook()}
+		e.books[instrumentID] = lb
+	}
+	e.mu.Unlock()
+
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return fn(lb.book)
+}
@@ -0,0 +1,31 @@
+package middware
+
+import (
+	"context"
+	"net/http"
+)
+
+const UserIDHeader = "X-User-Id"
+
+type userIDKey struct{}
+
+// Auth 从请求头读取当前用户 ID 并写入上下文
+func Auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(UserIDHeader); id != "" {
+			r = r.WithContext(WithUserID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithUserID 将用户 ID 写入上下文
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserIDFromContext 获取当前用户 ID，匿名请求返回空字符串
+func UserIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,31 @@
+package middware
+
+import (
+	"context"
+	"net/http"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKey struct{}
+
+// IdempotencyKey 从请求头读取幂等键并写入上下文
+func IdempotencyKey(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			r = r.WithContext(WithIdempotencyKey(r.Context(), key))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithIdempotencyKey 将幂等键写入上下文
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey{}, key)
+}
+
+// IdempotencyKeyFromContext 获取请求的幂等键，未携带时返回空字符串
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey{}).(string)
+	return key
+}
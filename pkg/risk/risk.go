@@ -0,0 +1,320 @@
+package risk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"gqlexample/pkg/errcode"
+	"gqlexample/pkg/utils"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	CodeKillSwitch    = "RISK_KILL_SWITCH"
+	CodeMaxQuantity   = "RISK_MAX_QUANTITY"
+	CodeMaxNotional   = "RISK_MAX_NOTIONAL"
+	CodeDailyNotional = "RISK_DAILY_NOTIONAL"
+	CodePriceCollar   = "RISK_PRICE_COLLAR"
+	CodeFatFinger     = "RISK_FAT_FINGER"
+)
+
+var (
+	ErrKillSwitch    = errors.New("account trading halted by kill switch")
+	ErrMaxQuantity   = errors.New("order quantity exceeds limit")
+	ErrMaxNotional   = errors.New("order notional exceeds limit")
+	ErrDailyNotional = errors.New("daily notional limit exceeded")
+	ErrPriceCollar   = errors.New("order price outside collar")
+	ErrFatFinger     = errors.New("order would trade too far through the book")
+	ErrInvalidLimit  = errors.New("risk limit must be non-negative")
+)
+
+var hundred = decimal.NewFromInt(100)
+
+// InstrumentLimit 单笔委托限额，0 表示不限制
+type InstrumentLimit struct {
+	MaxQuantity int32           `json:"max_quantity"`
+	MaxNotional decimal.Decimal `json:"max_notional"`
+}
+
+// Limits 风控限额，0 表示不检查
+type Limits struct {
+	// Default 未单独配置的合约使用的单笔限额
+	Default     InstrumentLimit            `json:"default"`
+	Instruments map[string]InstrumentLimit `json:"instruments,omitempty"`
+	// DailyNotional 每个账户每个交易日的委托金额上限
+	DailyNotional decimal.Decimal `json:"daily_notional"`
+	// CollarPercent 限价单委托价偏离最新成交价的最大百分比
+	CollarPercent decimal.Decimal `json:"collar_percent"`
+	// FatFingerPercent 委托按数量依次成交对手方挂单时，最差成交价偏离对手方最优价的最大百分比
+	FatFingerPercent decimal.Decimal `json:"fat_finger_percent"`
+	// KilledAccounts 已暂停交易的账户
+	KilledAccounts []string `json:"killed_accounts,omitempty"`
+}
+
+// Instrument 返回合约适用的单笔限额
+func (l Limits) Instrument(instrumentID string) InstrumentLimit {
+	if limit, ok := l.Instruments[instrumentID]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Killed 账户是否已暂停交易
+func (l Limits) Killed(accountID string) bool {
+	return slices.Contains(l.KilledAccounts, accountID)
+}
+
+// SetKilled 开启或关闭账户的交易暂停
+func (l *Limits) SetKilled(accountID string, killed bool) {
+	l.KilledAccounts = slices.DeleteFunc(l.KilledAccounts, func(id string) bool { return id == accountID })
+	if killed {
+		l.KilledAccounts = append(l.KilledAccounts, accountID)
+		slices.Sort(l.KilledAccounts)
+	}
+}
+
+func (l Limits) clone() Limits {
+	c := l
+	if l.Instruments != nil {
+		c.Instruments = make(map[string]InstrumentLimit, len(l.Instruments))
+		for id, limit := range l.Instruments {
+			c.Instruments[id] = limit
+		}
+	}
+	c.KilledAccounts = slices.Clone(l.KilledAccounts)
+	return c
+}
+
+func (l Limits) validate() error {
+	check := func(name string, v decimal.Decimal) error {
+		if v.IsNegative() {
+			return fmt.Errorf("%w: %s %s", ErrInvalidLimit, name, v)
+		}
+		return nil
+	}
+	all := append([]InstrumentLimit{l.Default}, mapValues(l.Instruments)...)
+	for _, limit := range all {
+		if limit.MaxQuantity < 0 {
+			return fmt.Errorf("%w: max quantity %d", ErrInvalidLimit, limit.MaxQuantity)
+		}
+		if err := check("max notional", limit.MaxNotional); err != nil {
+			return err
+		}
+	}
+	return errors.Join(
+		check("daily notional", l.DailyNotional),
+		check("collar percent", l.CollarPercent),
+		check("fat finger percent", l.FatFingerPercent),
+	)
+}
+
+// Market 下单时的行情，价格为零值表示不存在
+type Market struct {
+	Last decimal.Decimal // 最新成交价
+	Best decimal.Decimal // 对手方最优价
+	// Sweep 按委托数量依次成交对手方挂单的最差价格，限价单不超过委托价
+	Sweep decimal.Decimal
+}
+
+// Order 待检查的委托
+type Order struct {
+	AccountID    string
+	InstrumentID string
+	Quantity     int32
+	Price        decimal.Decimal // 限价单委托价，市价单为零值
+	Market       Market
+}
+
+// Notional 委托金额，市价单依次按最差成交价、对手方最优价、最新成交价估算，均不存在时为 0
+func (o Order) Notional() decimal.Decimal {
+	for _, price := range []decimal.Decimal{o.Price, o.Market.Sweep, o.Market.Best, o.Market.Last} {
+		if price.IsPositive() {
+			return price.Mul(decimal.NewFromInt32(o.Quantity))
+		}
+	}
+	return decimal.Zero
+}
+
+// Engine 下单前风控，限额修改后保存到文件，账户当日委托金额仅保存在内存
+type Engine struct {
+	mu         sync.Mutex
+	path       string
+	limits     Limits
+	tradingDay string
+	used       map[string]decimal.Decimal
+}
+
+// New 创建风控引擎，path 对应的文件存在时以文件中的限额为准，path 为空时不保存
+func New(defaults Limits, path string) (*Engine, error) {
+	e := &Engine{
+		path:   path,
+		limits: defaults.clone(),
+		used:   make(map[string]decimal.Decimal),
+	}
+	if path == "" || utils.NotExistFile(path) {
+		return e, e.limits.validate()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var saved Limits
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("invalid risk limits file %s: %w", path, err)
+	}
+	if err := saved.validate(); err != nil {
+		return nil, err
+	}
+	e.limits = saved
+	return e, nil
+}
+
+// Limits 返回当前限额
+func (e *Engine) Limits() Limits {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.limits.clone()
+}
+
+// Update 修改限额并保存，校验或保存失败时不生效
+func (e *Engine) Update(fn func(*Limits)) (Limits, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	next := e.limits.clone()
+	fn(&next)
+	if err := next.validate(); err != nil {
+		return Limits{}, err
+	}
+	if e.path != "" {
+		data, err := json.MarshalIndent(next, "", "  ")
+		if err != nil {
+			return Limits{}, err
+		}
+		if err := utils.WriteFile(e.path, data); err != nil {
+			return Limits{}, err
+		}
+	}
+	e.limits = next
+	return next.clone(), nil
+}
+
+// Check 检查单笔委托，返回估算的委托金额
+func (e *Engine) Check(o Order) (decimal.Decimal, error) {
+	e.mu.Lock()
+	limits := e.limits
+	killed := limits.Killed(o.AccountID)
+	e.mu.Unlock()
+
+	if killed {
+		return decimal.Zero, errcode.New(CodeKillSwitch, fmt.Errorf("%w: %s", ErrKillSwitch, o.AccountID))
+	}
+
+	limit := limits.Instrument(o.InstrumentID)
+	if limit.MaxQuantity > 0 && o.Quantity > limit.MaxQuantity {
+		return decimal.Zero, errcode.New(CodeMaxQuantity,
+			fmt.Errorf("%w: %s quantity %d", ErrMaxQuantity, o.InstrumentID, o.Quantity)).
+			With("limit", limit.MaxQuantity)
+	}
+	notional := o.Notional()
+	if limit.MaxNotional.IsPositive() && notional.GreaterThan(limit.MaxNotional) {
+		return decimal.Zero, errcode.New(CodeMaxNotional,
+			fmt.Errorf("%w: %s notional %s", ErrMaxNotional, o.InstrumentID, notional)).
+			With("limit", limit.MaxNotional.String())
+	}
+
+	last := o.Market.Last
+	if pct := limits.CollarPercent; pct.IsPositive() && o.Price.IsPositive() && last.IsPositive() {
+		if deviation(o.Price, last).GreaterThan(pct) {
+			return decimal.Zero, errcode.New(CodePriceCollar,
+				fmt.Errorf("%w: %s price %s, last %s", ErrPriceCollar, o.InstrumentID, o.Price, last)).
+				With("collarPercent", pct.String())
+		}
+	}
+
+	best, sweep := o.Market.Best, o.Market.Sweep
+	if pct := limits.FatFingerPercent; pct.IsPositive() && best.IsPositive() && sweep.IsPositive() {
+		if deviation(sweep, best).GreaterThan(pct) {
+			return decimal.Zero, errcode.New(CodeFatFinger,
+				fmt.Errorf("%w: %s would trade at %s, best %s", ErrFatFinger, o.InstrumentID, sweep, best)).
+				With("fatFingerPercent", pct.String())
+		}
+	}
+	return notional, nil
+}
+
+// Reserve 按账户占用当日委托金额，交易日变化时清零
+// atomic 为 true 时任一账户超限则全部不占用，返回值为各超限账户的错误
+func (e *Engine) Reserve(amounts map[string]decimal.Decimal, tradingDay string, atomic bool) map[string]error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if tradingDay != e.tradingDay {
+		e.tradingDay = tradingDay
+		e.used = make(map[string]decimal.Decimal)
+	}
+
+	errs := make(map[string]error)
+	if limit := e.limits.DailyNotional; limit.IsPositive() {
+		for accountID, amount := range amounts {
+			if used := e.used[accountID].Add(amount); used.GreaterThan(limit) {
+				errs[accountID] = errcode.New(CodeDailyNotional,
+					fmt.Errorf("%w: account %s would reach %s on %s", ErrDailyNotional, accountID, used, tradingDay)).
+					With("limit", limit.String())
+			}
+		}
+	}
+	if atomic && len(errs) > 0 {
+		return errs
+	}
+
+	for accountID, amount := range amounts {
+		if errs[accountID] == nil {
+			e.used[accountID] = e.used[accountID].Add(amount)
+		}
+	}
+	return errs
+}
+
+// Release 退回账户在交易日内占用的委托金额，交易日已变化时忽略
+func (e *Engine) Release(accountID, tradingDay string, amount decimal.Decimal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if tradingDay != e.tradingDay || !amount.IsPositive() {
+		return
+	}
+	if used := e.used[accountID].Sub(amount); used.IsPositive() {
+		e.used[accountID] = used
+	} else {
+		delete(e.used, accountID)
+	}
+}
+
+// Used 返回账户在交易日内已占用的委托金额
+func (e *Engine) Used(accountID, tradingDay string) decimal.Decimal {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if tradingDay != e.tradingDay {
+		return decimal.Zero
+	}
+	return e.used[accountID]
+}
+
+// deviation 返回 price 偏离 ref 的百分比
+func deviation(price, ref decimal.Decimal) decimal.Decimal {
+	return price.Sub(ref).Abs().Mul(hundred).Div(ref)
+}
+
+func mapValues(m map[string]InstrumentLimit) []InstrumentLimit {
+	result := make([]InstrumentLimit, 0, len(m))
+	for _, v := range m {
+		result = append(result, v)
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package risk
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gqlexample/pkg/errcode"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func price(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func TestEngine_Check(t *testing.T) {
+	e, err := New(Limits{
+		Default:          InstrumentLimit{MaxQuantity: 1000, MaxNotional: price("50000")},
+		Instruments:      map[string]InstrumentLimit{"Y": {MaxQuantity: 10}},
+		CollarPercent:    price("5"),
+		FatFingerPercent: price("2"),
+		KilledAccounts:   []string{"K"},
+	}, "")
+	require.NoError(t, err)
+
+	market := Market{Last: price("10"), Best: price("10.01"), Sweep: price("10.05")}
+	order := Order{AccountID: "A", InstrumentID: "X", Quantity: 1000, Price: price("10.2"), Market: market}
+	notional, err := e.Check(order)
+	require.NoError(t, err)
+	assert.Equal(t, "10200", notional.String())
+
+	for code, o := range map[string]Order{
+		CodeKillSwitch:  {AccountID: "K", InstrumentID: "X", Quantity: 1, Price: price("10")},
+		CodeMaxQuantity: {AccountID: "A", InstrumentID: "Y", Quantity: 11, Price: price("10")},
+		CodeMaxNotional: {AccountID: "A", InstrumentID: "X", Quantity: 1000, Price: price("51")},
+		CodePriceCollar: {AccountID: "A", InstrumentID: "X", Quantity: 100, Price: price("10.6"), Market: market},
+		// 市价单按最差成交价估算
+		CodeFatFinger: {AccountID: "A", InstrumentID: "X", Quantity: 100, Market: Market{Last: price("10"), Best: price("10"), Sweep: price("10.3")}},
+	} {
+		_, err := e.Check(o)
+		assert.Equal(t, code, errcode.Code(err), code)
+	}
+
+	// 无行情时不检查价格偏离
+	_, err = e.Check(Order{AccountID: "A", InstrumentID: "X", Quantity: 100, Price: price("100")})
+	assert.NoError(t, err)
+}
+
+func TestEngine_Reserve(t *testing.T) {
+	e, err := New(Limits{DailyNotional: price("1000")}, "")
+	require.NoError(t, err)
+
+	errs := e.Reserve(map[string]decimal.Decimal{"A": price("600"), "B": price("600")}, "20240102", false)
+	assert.Empty(t, errs)
+	errs = e.Reserve(map[string]decimal.Decimal{"A": price("500"), "B": price("100")}, "20240102", true)
+	assert.ErrorIs(t, errs["A"], ErrDailyNotional)
+	assert.Equal(t, CodeDailyNotional, errcode.Code(errs["A"]))
+	assert.Equal(t, "600", e.Used("B", "20240102").String())
+
+	// 退回后可再次占用，其他交易日的退回忽略
+	e.Release("A", "20240102", price("200"))
+	assert.Equal(t, "400", e.Used("A", "20240102").String())
+	assert.Empty(t, e.Reserve(map[string]decimal.Decimal{"A": price("500")}, "20240102", false))
+	e.Release("A", "20240101", price("900"))
+	assert.Equal(t, "900", e.Used("A", "20240102").String())
+	e.Release("A", "20240102", price("1000"))
+	assert.True(t, e.Used("A", "20240102").IsZero())
+
+	// 交易日变化后清零
+	assert.Empty(t, e.Reserve(map[string]decimal.Decimal{"A": price("1000")}, "20240103", false))
+	assert.True(t, e.Used("B", "20240103").IsZero())
+}
+
+func TestEngine_UpdatePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "risk.json")
+	e, err := New(Limits{DailyNotional: price("1000")}, path)
+	require.NoError(t, err)
+
+	_, err = e.Update(func(l *Limits) { l.CollarPercent = price("-1") })
+	assert.ErrorIs(t, err, ErrInvalidLimit)
+	limits, err := e.Update(func(l *Limits) {
+		l.SetKilled("A", true)
+		l.Instruments = map[string]InstrumentLimit{"X": {MaxQuantity: 5}}
+	})
+	require.NoError(t, err)
+	assert.True(t, limits.Killed("A"))
+
+	// 重新创建时以文件中的限额为准
+	reloaded, err := New(Limits{}, path)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", reloaded.Limits().DailyNotional.String())
+	assert.True(t, reloaded.Limits().Killed("A"))
+	assert.Equal(t, int32(5), reloaded.Limits().Instrument("X").MaxQuantity)
+	assert.True(t, reloaded.Limits().CollarPercent.IsZero())
+
+	_, err = reloaded.Update(func(l *Limits) { l.SetKilled("A", false) })
+	require.NoError(t, err)
+	_, err = reloaded.Check(Order{AccountID: "A", InstrumentID: "X", Quantity: 5})
+	assert.NoError(t, err)
+}
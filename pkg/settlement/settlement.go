@@ -0,0 +1,163 @@
+package settlement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gqlexample/pkg/utils"
+)
+
+var (
+	ErrInProgress     = errors.New("settlement already in progress")
+	ErrAlreadySettled = errors.New("trading day already settled")
+)
+
+type Status string
+
+const (
+	Running   Status = "RUNNING"
+	Succeeded Status = "SUCCEEDED"
+	Failed    Status = "FAILED"
+)
+
+// Trigger 结算触发方式，定时结算对已成功的交易日不再执行，手工结算可重跑
+type Trigger string
+
+const (
+	Scheduled Trigger = "SCHEDULED"
+	Manual    Trigger = "MANUAL"
+)
+
+// 进程退出时未完成的结算，重新加载后视为失败
+const interruptedError = "interrupted"
+
+// Run 交易日的结算记录，重跑时覆盖上一次结果，首次结算时的快照保留给重跑使用
+type Run struct {
+	TradingDay      string    `json:"trading_day"`
+	Trigger         Trigger   `json:"trigger"`
+	Status          Status    `json:"status"`
+	Attempts        int       `json:"attempts"`
+	StartedAt       time.Time `json:"started_at"`
+	FinishedAt      time.Time `json:"finished_at,omitempty"`
+	CancelledOrders int       `json:"cancelled_orders"`
+	Orders          int       `json:"orders"`
+	Fills           int       `json:"fills"`
+	Positions       int       `json:"positions"`
+	Accounts        int       `json:"accounts"`
+	ReportDir       string    `json:"report_dir,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	// SnapshotAt 及 Snapshot 为收盘后的持仓及余额快照，内容由调用方编码
+	SnapshotAt time.Time       `json:"snapshot_at,omitempty"`
+	Snapshot   json.RawMessage `json:"snapshot,omitempty"`
+}
+
+// Registry 按交易日记录结算，保证同一交易日同时只有一次结算，记录变化后保存到文件
+type Registry struct {
+	mu   sync.Mutex
+	path string
+	runs map[string]Run
+}
+
+// Open 创建结算记录，path 对应的文件存在时加载已有记录，path 为空时不保存
+func Open(path string) (*Registry, error) {
+	r := &Registry{path: path, runs: make(map[string]Run)}
+	if path == "" || utils.NotExistFile(path) {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var runs []Run
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("invalid settlement runs file %s: %w", path, err)
+	}
+	for _, run := range runs {
+		if run.Status == Running {
+			run.Status, run.Error = Failed, interruptedError
+		}
+		r.runs[run.TradingDay] = run
+	}
+	return r, nil
+}
+
+// Begin 开始交易日的结算，沿用上一次的快照，进行中时返回 ErrInProgress，定时结算遇到已成功的交易日返回 ErrAlreadySettled
+func (r *Registry) Begin(tradingDay string, trigger Trigger, at time.Time) (Run, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, ok := r.runs[tradingDay]
+	if ok && prev.Status == Running {
+		return prev, fmt.Errorf("%w: %s", ErrInProgress, tradingDay)
+	}
+	if ok && prev.Status == Succeeded && trigger == Scheduled {
+		return prev, fmt.Errorf("%w: %s", ErrAlreadySettled, tradingDay)
+	}
+
+	run := Run{
+		TradingDay: tradingDay,
+		Trigger:    trigger,
+		Status:     Running,
+		Attempts:   prev.Attempts + 1,
+		StartedAt:  at,
+		SnapshotAt: prev.SnapshotAt,
+		Snapshot:   prev.Snapshot,
+	}
+	r.runs[tradingDay] = run
+	return run, r.save()
+}
+
+// Finish 保存结算结果，err 不为空时结算失败
+func (r *Registry) Finish(run Run, at time.Time, err error) (Run, error) {
+	run.FinishedAt = at
+	run.Status, run.Error = Succeeded, ""
+	if err != nil {
+		run.Status, run.Error = Failed, err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.runs[run.TradingDay] = run
+	return run, r.save()
+}
+
+// Get 查询交易日的结算记录
+func (r *Registry) Get(tradingDay string) (Run, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	run, ok := r.runs[tradingDay]
+	return run, ok
+}
+
+// List 返回全部结算记录，交易日较近的在前
+func (r *Registry) List() []Run {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sorted()
+}
+
+func (r *Registry) sorted() []Run {
+	runs := make([]Run, 0, len(r.runs))
+	for _, run := range r.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].TradingDay > runs[j].TradingDay })
+	return runs
+}
+
+func (r *Registry) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return utils.WriteFile(r.path, data)
+}
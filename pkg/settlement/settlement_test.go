@@ -0,0 +1,65 @@
+package settlement
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Idempotent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.json")
+	r, err := Open(path)
+	require.NoError(t, err)
+	at := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	run, err := r.Begin("20240102", Scheduled, at)
+	require.NoError(t, err)
+	assert.Equal(t, Running, run.Status)
+	assert.Equal(t, 1, run.Attempts)
+
+	// 同一交易日同时只能有一次结算
+	_, err = r.Begin("20240102", Manual, at)
+	assert.ErrorIs(t, err, ErrInProgress)
+
+	run.Orders = 3
+	run.SnapshotAt, run.Snapshot = at, []byte(`{"positions":[]}`)
+	run, err = r.Finish(run, at.Add(time.Second), nil)
+	require.NoError(t, err)
+	assert.Equal(t, Succeeded, run.Status)
+
+	// 定时结算不重复执行，手工结算可重跑
+	_, err = r.Begin("20240102", Scheduled, at)
+	assert.ErrorIs(t, err, ErrAlreadySettled)
+	run, err = r.Begin("20240102", Manual, at)
+	require.NoError(t, err)
+	assert.Equal(t, 2, run.Attempts)
+	assert.Zero(t, run.Orders)
+	assert.Equal(t, at, run.SnapshotAt)
+	assert.JSONEq(t, `{"positions":[]}`, string(run.Snapshot))
+	_, err = r.Finish(run, at, errors.New("disk full"))
+	require.NoError(t, err)
+
+	// 失败后定时结算可重试
+	_, err = r.Begin("20240103", Manual, at)
+	require.NoError(t, err)
+	failed, ok := r.Get("20240102")
+	require.True(t, ok)
+	assert.Equal(t, Failed, failed.Status)
+	assert.Equal(t, "disk full", failed.Error)
+
+	// 重新加载后进行中的结算视为中断
+	loaded, err := Open(path)
+	require.NoError(t, err)
+	runs := loaded.List()
+	require.Len(t, runs, 2)
+	assert.Equal(t, "20240103", runs[0].TradingDay)
+	assert.Equal(t, Failed, runs[0].Status)
+	assert.Equal(t, interruptedError, runs[0].Error)
+	run, err = loaded.Begin("20240103", Scheduled, at)
+	require.NoError(t, err)
+	assert.Equal(t, 2, run.Attempts)
+}
@@ -0,0 +1,268 @@
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"gqlexample/pkg/event"
+
+	"github.com/shopspring/decimal"
+	"go.uber.org/zap"
+)
+
+// TopicQuotes 模拟行情在事件总线上的主题
+const TopicQuotes = "simulator.quotes"
+
+const defaultInterval = time.Second
+
+var (
+	ErrUnknownInstrument = errors.New("instrument is not simulated")
+	ErrInvalidVolatility = errors.New("volatility must be non-negative")
+	ErrInvalidJump       = errors.New("price jump must be greater than -100%")
+)
+
+// Instrument 参与模拟的合约
+type Instrument struct {
+	ID       string
+	TickSize decimal.Decimal
+	LotSize  int32
+	Price    decimal.Decimal // 初始价格
+	Interval time.Duration   // 行情生成间隔，为 0 时使用默认值
+}
+
+// Quote 一笔模拟行情，买卖价为最新价上下一个最小变动价位
+type Quote struct {
+	InstrumentID string
+	Bid          decimal.Decimal
+	BidSize      int32
+	Ask          decimal.Decimal
+	AskSize      int32
+	Last         decimal.Decimal
+	At           time.Time
+}
+
+// Options 模拟器参数
+type Options struct {
+	Seed       int64
+	Volatility float64 // 每步收益率的标准差
+	Bus        *event.EventBus
+	// IsOpen 判断合约是否处于交易时段，为空时始终生成行情
+	IsOpen func(instrumentID string, at time.Time) bool
+	Now    func() time.Time
+}
+
+// Status 模拟器运行状态
+type Status struct {
+	Running     bool
+	Seed        int64
+	Volatility  float64
+	Instruments []string
+}
+
+type walk struct {
+	Instrument
+	rng        *rand.Rand
+	volatility *float64 // 按合约覆盖的波动率
+	next       time.Time
+}
+
+// Simulator 按随机游走为各合约生成行情
+// 每个合约使用由种子和合约 ID 派生的独立随机源，相同种子下各合约的价格序列固定
+type Simulator struct {
+	mu         sync.Mutex
+	opts       Options
+	volatility float64
+	walks      map[string]*walk
+	ids        []string
+	stop       chan struct{}
+}
+
+func New(instruments []Instrument, opts Options) *Simulator {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	s := &Simulator{
+		opts:       opts,
+		volatility: opts.Volatility,
+		walks:      make(map[string]*walk, len(instruments)),
+	}
+	for _, inst := range instruments {
+		if inst.Interval <= 0 {
+			inst.Interval = defaultInterval
+		}
+		h := fnv.New64a()
+		h.Write([]byte(inst.ID))
+		s.walks[inst.ID] = &walk{
+			Instrument: inst,
+			rng:        rand.New(rand.NewSource(opts.Seed ^ int64(h.Sum64()))),
+		}
+		s.ids = append(s.ids, inst.ID)
+	}
+	sort.Strings(s.ids)
+	return s
+}
+
+// Start 启动定时生成，已在运行时不做处理
+func (s *Simulator) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil || len(s.walks) == 0 {
+		return
+	}
+
+	resolution := defaultInterval
+	for _, w := range s.walks {
+		resolution = min(resolution, w.Interval)
+	}
+	s.stop = make(chan struct{})
+	go s.run(s.stop, resolution)
+	zap.L().Info("Market simulator started", zap.Int("instruments", len(s.walks)), zap.Duration("resolution", resolution))
+}
+
+// Stop 停止定时生成
+func (s *Simulator) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+		zap.L().Info("Market simulator stopped")
+	}
+}
+
+func (s *Simulator) run(stop chan struct{}, resolution time.Duration) {
+	ticker := time.NewTicker(resolution)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.Tick(s.opts.Now())
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Tick 为到期且处于交易时段的合约生成一笔行情并发布，返回生成的行情
+func (s *Simulator) Tick(at time.Time) []Quote {
+	s.mu.Lock()
+	var quotes []Quote
+	for _, id := range s.ids {
+		w := s.walks[id]
+		if at.Before(w.next) {
+			continue
+		}
+		w.next = at.Add(w.Interval)
+		if s.opts.IsOpen != nil && !s.opts.IsOpen(id, at) {
+			continue
+		}
+		vol := s.volatility
+		if w.volatility != nil {
+			vol = *w.volatility
+		}
+		w.move(1 + w.rng.NormFloat64()*vol)
+		quotes = append(quotes, w.quote(at))
+	}
+	s.mu.Unlock()
+
+	for _, q := range quotes {
+		s.publish(q)
+	}
+	return quotes
+}
+
+// SetVolatility 设置波动率，instrumentID 为空时设置全局波动率并清除各合约的覆盖值
+func (s *Simulator) SetVolatility(instrumentID string, volatility float64) error {
+	if volatility < 0 {
+		return fmt.Errorf("%w: %g", ErrInvalidVolatility, volatility)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if instrumentID == "" {
+		s.volatility = volatility
+		for _, w := range s.walks {
+			w.volatility = nil
+		}
+		return nil
+	}
+	w, ok := s.walks[instrumentID]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownInstrument, instrumentID)
+	}
+	w.volatility = &volatility
+	return nil
+}
+
+// Jump 使合约价格按百分比跳变并立即发布行情，不受交易时段限制
+func (s *Simulator) Jump(instrumentID string, percent float64) (Quote, error) {
+	if percent <= -100 {
+		return Quote{}, fmt.Errorf("%w: %g", ErrInvalidJump, percent)
+	}
+	s.mu.Lock()
+	w, ok := s.walks[instrumentID]
+	if !ok {
+		s.mu.Unlock()
+		return Quote{}, fmt.Errorf("%w: %s", ErrUnknownInstrument, instrumentID)
+	}
+	at := s.opts.Now()
+	w.move(1 + percent/100)
+	q := w.quote(at)
+	s.mu.Unlock()
+
+	zap.L().Info("Simulated price jump", zap.String("instrument", instrumentID), zap.Float64("percent", percent), zap.String("price", q.Last.String()))
+	s.publish(q)
+	return q, nil
+}
+
+// Status 返回运行状态
+func (s *Simulator) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Status{
+		Running:     s.stop != nil,
+		Seed:        s.opts.Seed,
+		Volatility:  s.volatility,
+		Instruments: append([]string(nil), s.ids...),
+	}
+}
+
+func (s *Simulator) publish(q Quote) {
+	if s.opts.Bus != nil {
+		s.opts.Bus.Publish(TopicQuotes, q)
+	}
+}
+
+// move 按比例调整价格，取整到最小变动价位且不低于一个价位
+func (w *walk) move(factor float64) {
+	price := w.Price.Mul(decimal.NewFromFloat(factor))
+	if w.TickSize.IsPositive() {
+		price = price.Div(w.TickSize).Round(0).Mul(w.TickSize)
+		if price.LessThan(w.TickSize) {
+			price = w.TickSize
+		}
+	}
+	w.Price = price
+}
+
+func (w *walk) quote(at time.Time) Quote {
+	lot := max(w.LotSize, 1)
+	bid := w.Price.Sub(w.TickSize)
+	if !bid.IsPositive() {
+		bid = w.Price
+	}
+	return Quote{
+		InstrumentID: w.ID,
+		Bid:          bid,
+		BidSize:      lot * int32(1+w.rng.Intn(10)),
+		Ask:          w.Price.Add(w.TickSize),
+		AskSize:      lot * int32(1+w.rng.Intn(10)),
+		Last:         w.Price,
+		At:           at,
+	}
+}
@@ -0,0 +1,91 @@
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"gqlexample/pkg/event"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func instruments() []Instrument {
+	return []Instrument{
+		{ID: "600000.SH", TickSize: decimal.RequireFromString("0.01"), LotSize: 100, Price: decimal.RequireFromString("10.00")},
+		{ID: "000001.SZ", TickSize: decimal.RequireFromString("0.01"), LotSize: 100, Price: decimal.RequireFromString("12.00"), Interval: 2 * time.Second},
+	}
+}
+
+func prices(s *Simulator, start time.Time, steps int) []string {
+	var result []string
+	for i := range steps {
+		for _, q := range s.Tick(start.Add(time.Duration(i) * time.Second)) {
+			result = append(result, q.InstrumentID+"="+q.Last.String())
+		}
+	}
+	return result
+}
+
+func TestSimulator_Deterministic(t *testing.T) {
+	start := time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+	a := prices(New(instruments(), Options{Seed: 7, Volatility: 0.01}), start, 20)
+	b := prices(New(instruments(), Options{Seed: 7, Volatility: 0.01}), start, 20)
+	c := prices(New(instruments(), Options{Seed: 8, Volatility: 0.01}), start, 20)
+
+	// 000001.SZ 间隔 2 秒，20 步内生成 10 笔
+	assert.Len(t, a, 30)
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, c)
+}
+
+func TestSimulator_TradingPhase(t *testing.T) {
+	open := false
+	s := New(instruments(), Options{
+		Seed:   1,
+		IsOpen: func(string, time.Time) bool { return open },
+	})
+	at := time.Now()
+	assert.Empty(t, s.Tick(at))
+
+	open = true
+	quotes := s.Tick(at.Add(2 * time.Second))
+	require.Len(t, quotes, 2)
+	// 波动率为 0 时价格不变
+	assert.Equal(t, "12", quotes[0].Last.String())
+	assert.Equal(t, "11.99", quotes[0].Bid.String())
+	assert.Equal(t, "12.01", quotes[0].Ask.String())
+	assert.Zero(t, quotes[0].BidSize%100)
+}
+
+func TestSimulator_Controls(t *testing.T) {
+	bus := event.NewEventBus()
+	ch := make(event.DataChannel, 10)
+	bus.Subscribe(TopicQuotes, ch)
+
+	s := New(instruments(), Options{Seed: 1, Bus: bus})
+	q, err := s.Jump("600000.SH", 5)
+	require.NoError(t, err)
+	assert.Equal(t, "10.5", q.Last.String())
+
+	select {
+	case e := <-ch:
+		assert.Equal(t, q, e.Data)
+	case <-time.After(time.Second):
+		t.Fatal("quote not published")
+	}
+
+	_, err = s.Jump("600000.SH", -100)
+	assert.ErrorIs(t, err, ErrInvalidJump)
+	_, err = s.Jump("404.SH", 1)
+	assert.ErrorIs(t, err, ErrUnknownInstrument)
+	assert.ErrorIs(t, s.SetVolatility("", -1), ErrInvalidVolatility)
+	require.NoError(t, s.SetVolatility("", 0.05))
+	assert.Equal(t, 0.05, s.Status().Volatility)
+
+	s.Start()
+	assert.True(t, s.Status().Running)
+	s.Stop()
+	assert.False(t, s.Status().Running)
+}
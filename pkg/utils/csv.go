@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"strconv"
@@ -68,6 +69,26 @@ func WriteToCsv[T any](data []T, filePath string) error {
 	return nil
 }
 
+// WriteCsvStream 逐行写入 CSV，不在内存中缓存全部数据
+// 写入失败时继续读取 rows 直至关闭，避免生产者阻塞
+func WriteCsvStream(w io.Writer, headers []string, rows Stream[[]string]) error {
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(headers)
+	for row := range rows {
+		if err != nil {
+			continue
+		}
+		err = writer.Write(row)
+	}
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
 func ReadFromCsv[T any](filePath string) ([]T, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -15,29 +17,43 @@ func TestWriteToCsv(t *testing.T) {
 	for i := range 10 {
 		data = append(data, testStruct{
 			Name:  "test",
-			Age:   i+1,
+			Age:   i + 1,
 			Price: float64(i),
 		})
 	}
-	
-	if err := WriteToCsv(data, "test.csv"); err != nil {
+
+	path := filepath.Join(t.TempDir(), "test.csv")
+	if err := WriteToCsv(data, path); err != nil {
 		t.Errorf("Failed to write CSV: %v", err)
 	}
-	
+
 	// 测试读取
-	readData, err := ReadFromCsv[testStruct]("test.csv")
+	readData, err := ReadFromCsv[testStruct](path)
 	if err != nil {
 		t.Errorf("Failed to read CSV: %v", err)
 	}
-	
+
 	// 验证数据
 	if len(readData) != len(data) {
 		t.Errorf("Expected %d records, got %d", len(data), len(readData))
 	}
-	
+
 	for i, item := range readData {
 		if item.Name != data[i].Name || item.Age != data[i].Age || item.Price != data[i].Price {
 			t.Errorf("Record %d mismatch: expected %v, got %v", i, data[i], item)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestWriteCsvStream(t *testing.T) {
+	var sb strings.Builder
+	rows := NewStream([]string{"1", "a,b"}, []string{"2", ""})
+	if err := WriteCsvStream(&sb, []string{"id", "name"}, rows); err != nil {
+		t.Errorf("Failed to write CSV stream: %v", err)
+	}
+
+	expected := "id,name\n1,\"a,b\"\n2,\n"
+	if sb.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, sb.String())
+	}
+}
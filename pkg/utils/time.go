@@ -55,6 +55,11 @@ func ProcessTimeRange(s string) []TimeRange {
 	return result
 }
 
+// FormatSeconds 将当日秒数格式化为 HH:MM:SS
+func FormatSeconds(seconds int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
+
 func ParseIntervalString(s string) (int, int, int, int, error) {
 	// 使用正则表达式匹配格式
 	re := regexp.MustCompile(`^\[(\d+)-(\d+)\):(\d+):(\d+)$`)